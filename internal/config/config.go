@@ -13,14 +13,83 @@ type Config struct {
 	EthereumNodeURL   string `mapstructure:"ethereum_node_url"`
 	ServerHost        string `mapstructure:"server_host"`
 	ServerPort        int    `mapstructure:"server_port"`
-	PrivateKey        string `mapstructure:"private_key"`
+	SignerConfig      `mapstructure:",squash"`
 	ServerAddress     string
 	SwapRouterAddress string `mapstructure:"swap_router_address"`
 	LPRouterAddress   string `mapstructure:"lp_router_address"`
 	ManagerAddress    string `mapstructure:"manager_address"`
 	HookAddress       string `mapstructure:"hook_address"`
-	Token0_address    string `mapstructure:"token0_address"`
-	Token1_address    string `mapstructure:"token1_address"`
+	// v4-periphery PositionManager and the Permit2 it pulls tokens through.
+	// The position NFT routes are disabled when unset.
+	PositionManagerAddress string `mapstructure:"position_manager_address"`
+	Permit2Address         string `mapstructure:"permit2_address"`
+	// v4-periphery V4Router, and the router swaps go through by default:
+	// "test" (swap_router_address) or "v4router"
+	V4RouterAddress string `mapstructure:"v4_router_address"`
+	SwapRouterMode  string `mapstructure:"swap_router_mode"`
+	// PoolDonateTest router for /donate, which is disabled when unset
+	DonateRouterAddress string `mapstructure:"donate_router_address"`
+	Token0_address      string `mapstructure:"token0_address"`
+	Token1_address      string `mapstructure:"token1_address"`
+	// Relayer accounts for the permit routes. When empty the server signer
+	// is the only relayer.
+	Relayers                []SignerConfig    `mapstructure:"relayers"`
+	RelayerSelection        string            `mapstructure:"relayer_selection"`
+	RelayerMinBalance       string            `mapstructure:"relayer_min_balance"`
+	RelayerBalanceCheckSecs int               `mapstructure:"relayer_balance_check_secs"`
+	Sponsorship             SponsorshipConfig `mapstructure:"sponsorship"`
+	// Token required in the X-Admin-Token header for /admin routes. Admin
+	// routes are disabled when empty.
+	AdminToken string `mapstructure:"admin_token"`
+	// File the labelled position registry is persisted to, in memory only
+	// when empty
+	PositionsPath string `mapstructure:"positions_path"`
+	// First block the pool registry reads Initialize events from, and how
+	// often it follows new blocks
+	PoolsFromBlock uint64 `mapstructure:"pools_from_block"`
+	PoolSyncSecs   int    `mapstructure:"pool_sync_secs"`
+	// hookData schemas by hook address, merged over those in the JSON file
+	// at hook_data_schemas_path
+	HookDataSchemas     map[string][]HookDataField `mapstructure:"hook_data_schemas"`
+	HookDataSchemasPath string                     `mapstructure:"hook_data_schemas_path"`
+	// ABI files by hook address, a JSON ABI or a forge artifact, whose view
+	// functions are served under /hooks/:address/views
+	HookABIs map[string]string `mapstructure:"hook_abis"`
+	// Hook adapter names by hook address, for deployments adapters do not
+	// match by themselves
+	HookAdapters map[string]string `mapstructure:"hook_adapters"`
+}
+
+// HookDataField is one ABI parameter of a hook's hookData. A tuple lists its
+// fields in components.
+type HookDataField struct {
+	Name       string          `mapstructure:"name" json:"name"`
+	Type       string          `mapstructure:"type" json:"type"`
+	Components []HookDataField `mapstructure:"components" json:"components,omitempty"`
+}
+
+// SponsorshipConfig limits which relays the server pays gas for. Amounts are
+// in wei (gas budgets) or raw token units (max_amount_per_tx).
+type SponsorshipConfig struct {
+	UserDailyGasWei string            `mapstructure:"user_daily_gas_wei"`
+	PoolDailyGasWei string            `mapstructure:"pool_daily_gas_wei"`
+	MaxAmountPerTx  map[string]string `mapstructure:"max_amount_per_tx"`
+	AllowedTokens   []string          `mapstructure:"allowed_tokens"`
+	AllowedPools    []string          `mapstructure:"allowed_pools"`
+	RelayerFeeBps   int64             `mapstructure:"relayer_fee_bps"`
+	LedgerPath      string            `mapstructure:"ledger_path"`
+}
+
+// SignerConfig selects how an account signs: "local" (private_key),
+// "keystore" or "remote".
+type SignerConfig struct {
+	SignerType             string `mapstructure:"signer_type"`
+	PrivateKey             string `mapstructure:"private_key"`
+	KeystorePath           string `mapstructure:"keystore_path"`
+	KeystorePassphraseEnv  string `mapstructure:"keystore_passphrase_env"`
+	KeystorePassphraseFile string `mapstructure:"keystore_passphrase_file"`
+	RemoteSignerURL        string `mapstructure:"remote_signer_url"`
+	RemoteSignerAddress    string `mapstructure:"remote_signer_address"`
 }
 
 func Load() (*Config, error) {
@@ -0,0 +1,55 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ClaimID returns the ERC-6909 id the PoolManager keeps claims of currency
+// under, uint256(uint160(currency)).
+func ClaimID(currency common.Address) *big.Int {
+	return new(big.Int).SetBytes(currency.Bytes())
+}
+
+func callManager(method string, args ...interface{}) ([]interface{}, error) {
+	data, err := ManagerABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &ManagerAddress, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ManagerABI.Unpack(method, out)
+}
+
+// GetClaimBalance returns owner's ERC-6909 claims of currency.
+func GetClaimBalance(owner, currency common.Address) (*big.Int, error) {
+	values, err := callManager("balanceOf", owner, ClaimID(currency))
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// GetClaimAllowance returns how many of owner's claims of currency spender
+// may transfer.
+func GetClaimAllowance(owner, spender, currency common.Address) (*big.Int, error) {
+	values, err := callManager("allowance", owner, spender, ClaimID(currency))
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// IsClaimOperator reports whether spender may transfer any of owner's claims.
+func IsClaimOperator(owner, spender common.Address) (bool, error) {
+	values, err := callManager("isOperator", owner, spender)
+	if err != nil {
+		return false, err
+	}
+	return values[0].(bool), nil
+}
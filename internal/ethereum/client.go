@@ -1,15 +1,19 @@
 package ethereum
 
 import (
-	"crypto/ecdsa"
+	"context"
+	"uniswap-v4-rpc/internal/signer"
 
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 var (
-	Client     *ethclient.Client
-	PrivateKey *ecdsa.PrivateKey
+	Client *ethclient.Client
+	// Signer is the server account used to send transactions
+	Signer signer.Signer
+	// AdminToken guards the /admin routes, which are disabled when empty
+	AdminToken string
 )
 
 func InitClient(nodeURL string) error {
@@ -21,11 +25,19 @@ func InitClient(nodeURL string) error {
 	return nil
 }
 
-func SetPrivateKey(hexPrivateKey string) error {
-	var err error
-	PrivateKey, err = crypto.HexToECDSA(hexPrivateKey)
+func SetSigner(s signer.Signer) {
+	Signer = s
+}
+
+func SetAdminToken(token string) {
+	AdminToken = token
+}
+
+// IsContract reports whether address has code deployed.
+func IsContract(address common.Address) (bool, error) {
+	code, err := Client.CodeAt(context.Background(), address, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return len(code) > 0, nil
 }
@@ -38,6 +38,18 @@ func InitContracts(cfg *config.Config) error {
 		return err
 	}
 
+	if err := initPositionManager(cfg.PositionManagerAddress, cfg.Permit2Address); err != nil {
+		return err
+	}
+
+	if err := initV4Router(cfg.V4RouterAddress, cfg.SwapRouterMode); err != nil {
+		return err
+	}
+
+	if err := initDonateRouter(cfg.DonateRouterAddress); err != nil {
+		return err
+	}
+
 	SwapRouterAddress = common.HexToAddress(cfg.SwapRouterAddress)
 	LPRouterAddress = common.HexToAddress(cfg.LPRouterAddress)
 	ManagerAddress = common.HexToAddress(cfg.ManagerAddress)
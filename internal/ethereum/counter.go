@@ -0,0 +1,135 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CounterABI is the bundled Counter hook, which counts the callbacks it
+// receives per pool.
+var CounterABI abi.ABI
+
+func init() {
+	var err error
+	CounterABI, err = abi.JSON(strings.NewReader(CounterABIJSON))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CounterStats are the Counter hook's counters of one pool.
+type CounterStats struct {
+	BeforeSwap            *big.Int
+	AfterSwap             *big.Int
+	BeforeAddLiquidity    *big.Int
+	BeforeRemoveLiquidity *big.Int
+}
+
+func callCounter(hook common.Address, method string, poolID common.Hash) (*big.Int, error) {
+	data, err := CounterABI.Pack(method, poolID)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &hook, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := CounterABI.Unpack(method, out)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// GetHookStats reads the counters a Counter hook keeps for a pool.
+func GetHookStats(hook common.Address, poolID common.Hash) (*CounterStats, error) {
+	var stats CounterStats
+	for _, counter := range []struct {
+		method string
+		value  **big.Int
+	}{
+		{"beforeSwapCount", &stats.BeforeSwap},
+		{"afterSwapCount", &stats.AfterSwap},
+		{"beforeAddLiquidityCount", &stats.BeforeAddLiquidity},
+		{"beforeRemoveLiquidityCount", &stats.BeforeRemoveLiquidity},
+	} {
+		value, err := callCounter(hook, counter.method, poolID)
+		if err != nil {
+			return nil, err
+		}
+		*counter.value = value
+	}
+	return &stats, nil
+}
+
+const CounterABIJSON = `[
+  {
+    "type": "function",
+    "name": "beforeSwapCount",
+    "inputs": [{ "name": "", "type": "bytes32", "internalType": "PoolId" }],
+    "outputs": [{ "name": "count", "type": "uint256", "internalType": "uint256" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "afterSwapCount",
+    "inputs": [{ "name": "", "type": "bytes32", "internalType": "PoolId" }],
+    "outputs": [{ "name": "count", "type": "uint256", "internalType": "uint256" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "beforeAddLiquidityCount",
+    "inputs": [{ "name": "", "type": "bytes32", "internalType": "PoolId" }],
+    "outputs": [{ "name": "count", "type": "uint256", "internalType": "uint256" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "beforeRemoveLiquidityCount",
+    "inputs": [{ "name": "", "type": "bytes32", "internalType": "PoolId" }],
+    "outputs": [{ "name": "count", "type": "uint256", "internalType": "uint256" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "poolManager",
+    "inputs": [],
+    "outputs": [{ "name": "", "type": "address", "internalType": "contract IPoolManager" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "getHookPermissions",
+    "inputs": [],
+    "outputs": [
+      {
+        "name": "",
+        "type": "tuple",
+        "internalType": "struct Hooks.Permissions",
+        "components": [
+          { "name": "beforeInitialize", "type": "bool", "internalType": "bool" },
+          { "name": "afterInitialize", "type": "bool", "internalType": "bool" },
+          { "name": "beforeAddLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "afterAddLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "beforeRemoveLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "afterRemoveLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "beforeSwap", "type": "bool", "internalType": "bool" },
+          { "name": "afterSwap", "type": "bool", "internalType": "bool" },
+          { "name": "beforeDonate", "type": "bool", "internalType": "bool" },
+          { "name": "afterDonate", "type": "bool", "internalType": "bool" },
+          { "name": "beforeSwapReturnDelta", "type": "bool", "internalType": "bool" },
+          { "name": "afterSwapReturnDelta", "type": "bool", "internalType": "bool" },
+          { "name": "afterAddLiquidityReturnDelta", "type": "bool", "internalType": "bool" },
+          { "name": "afterRemoveLiquidityReturnDelta", "type": "bool", "internalType": "bool" }
+        ]
+      }
+    ],
+    "stateMutability": "pure"
+  }
+]`
@@ -0,0 +1,69 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Currency is a pool currency: native ETH, which v4 represents as the zero
+// address, or an ERC-20 token.
+type Currency interface {
+	// Address returns the currency as it appears in a PoolKey.
+	Address() common.Address
+	// IsNative reports whether the currency is ETH, which is paid with
+	// msg.value and needs no approval.
+	IsNative() bool
+	// BalanceOf returns the balance of owner.
+	BalanceOf(owner common.Address) (*big.Int, error)
+}
+
+// NativeCurrency is ETH.
+type NativeCurrency struct{}
+
+// Address returns the zero address.
+func (NativeCurrency) Address() common.Address { return common.Address{} }
+
+// IsNative returns true.
+func (NativeCurrency) IsNative() bool { return true }
+
+// BalanceOf returns the ETH balance of owner.
+func (NativeCurrency) BalanceOf(owner common.Address) (*big.Int, error) {
+	return Client.BalanceAt(context.Background(), owner, nil)
+}
+
+// TokenCurrency is an ERC-20 token.
+type TokenCurrency struct {
+	address common.Address
+}
+
+// Address returns the token address.
+func (t TokenCurrency) Address() common.Address { return t.address }
+
+// IsNative returns false.
+func (TokenCurrency) IsNative() bool { return false }
+
+// BalanceOf returns the token balance of owner.
+func (t TokenCurrency) BalanceOf(owner common.Address) (*big.Int, error) {
+	token, err := NewERC20(t.address)
+	if err != nil {
+		return nil, err
+	}
+	return token.BalanceOf(&bind.CallOpts{}, owner)
+}
+
+// NewCurrency returns the currency at address, native ETH for the zero
+// address.
+func NewCurrency(address common.Address) Currency {
+	if address == (common.Address{}) {
+		return NativeCurrency{}
+	}
+	return TokenCurrency{address: address}
+}
+
+// IsNative reports whether address is the native currency.
+func IsNative(address common.Address) bool {
+	return NewCurrency(address).IsNative()
+}
@@ -0,0 +1,23 @@
+package ethereum
+
+import "math/big"
+
+// DecodeBalanceDelta splits a v4 BalanceDelta into its currency0 (upper 128
+// bits) and currency1 (lower 128 bits) amounts. Negative amounts are owed to
+// the pool by the caller, positive amounts are owed to the caller.
+func DecodeBalanceDelta(delta *big.Int) (amount0, amount1 *big.Int) {
+	// Work on the two's complement bits of the int256
+	raw := new(big.Int).Set(delta)
+	if raw.Sign() < 0 {
+		raw.Add(raw, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	return toInt128(new(big.Int).Rsh(raw, 128)), toInt128(new(big.Int).And(raw, mask))
+}
+
+func toInt128(x *big.Int) *big.Int {
+	if x.Bit(127) == 1 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return x
+}
@@ -0,0 +1,70 @@
+package ethereum
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	DonateRouterAddress common.Address
+	DonateRouterABI     abi.ABI
+)
+
+func initDonateRouter(router string) error {
+	var err error
+	DonateRouterABI, err = abi.JSON(strings.NewReader(DonateRouterABIJSON))
+	if err != nil {
+		return err
+	}
+	DonateRouterAddress = common.HexToAddress(router)
+	return nil
+}
+
+// HasDonateRouter reports whether a donate router is configured.
+func HasDonateRouter() bool {
+	return DonateRouterAddress != (common.Address{})
+}
+
+// DonationFeeGrowth is the increase of a pool's global fee growth from
+// donating amount to liquidity, as Pool.donate computes it:
+// FullMath.mulDiv(amount, FixedPoint128.Q128, liquidity). It is nil when the
+// pool has no in-range liquidity to receive the donation.
+func DonationFeeGrowth(amount, liquidity *big.Int) *big.Int {
+	if liquidity.Sign() == 0 {
+		return nil
+	}
+	growth := new(big.Int).Lsh(amount, 128)
+	return growth.Div(growth, liquidity)
+}
+
+// DonateRouterABIJSON is v4-core's PoolDonateTest. It pulls both currencies
+// from msg.sender with transferFrom, takes native ETH from msg.value and
+// refunds what is left.
+const DonateRouterABIJSON = `[
+  {
+    "type": "function",
+    "name": "donate",
+    "inputs": [
+      {
+        "name": "key",
+        "type": "tuple",
+        "internalType": "struct PoolKey",
+        "components": [
+          { "name": "currency0", "type": "address", "internalType": "Currency" },
+          { "name": "currency1", "type": "address", "internalType": "Currency" },
+          { "name": "fee", "type": "uint24", "internalType": "uint24" },
+          { "name": "tickSpacing", "type": "int24", "internalType": "int24" },
+          { "name": "hooks", "type": "address", "internalType": "contract IHooks" }
+        ]
+      },
+      { "name": "amount0", "type": "uint256", "internalType": "uint256" },
+      { "name": "amount1", "type": "uint256", "internalType": "uint256" },
+      { "name": "hookData", "type": "bytes", "internalType": "bytes" }
+    ],
+    "outputs": [{ "name": "delta", "type": "int256", "internalType": "BalanceDelta" }],
+    "stateMutability": "payable"
+  }
+]`
@@ -20,6 +20,11 @@ func init() {
 	}
 }
 
+// PackERC20 packs calldata for an ERC-20 (with ERC-2612) method.
+func PackERC20(method string, args ...interface{}) ([]byte, error) {
+	return erc20ABI.Pack(method, args...)
+}
+
 type ERC20 struct {
 	address  common.Address
 	contract *bind.BoundContract
@@ -70,6 +75,21 @@ func (e *ERC20) Decimals(opts *bind.CallOpts) (uint8, error) {
 	return *abi.ConvertType(out[0], new(uint8)).(*uint8), nil
 }
 
+// EIP712Domain returns the ERC-5267 domain fields of the token. Tokens that
+// predate ERC-5267 revert, so callers should fall back to name() and version "1".
+func (e *ERC20) EIP712Domain(opts *bind.CallOpts) (string, string, *big.Int, common.Address, error) {
+	var out []interface{}
+	err := e.contract.Call(opts, &out, "eip712Domain")
+	if err != nil {
+		return "", "", nil, common.Address{}, err
+	}
+	name := *abi.ConvertType(out[1], new(string)).(*string)
+	version := *abi.ConvertType(out[2], new(string)).(*string)
+	chainID := *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+	verifyingContract := *abi.ConvertType(out[4], new(common.Address)).(*common.Address)
+	return name, version, chainID, verifyingContract, nil
+}
+
 func (e *ERC20) Name(opts *bind.CallOpts) (string, error) {
 	var out []interface{}
 	err := e.contract.Call(opts, &out, "name")
@@ -170,6 +190,21 @@ const erc20ABIJson = `[
       "outputs": [{ "name": "", "type": "uint8", "internalType": "uint8" }],
       "stateMutability": "view"
     },
+    {
+      "type": "function",
+      "name": "eip712Domain",
+      "inputs": [],
+      "outputs": [
+        { "name": "fields", "type": "bytes1", "internalType": "bytes1" },
+        { "name": "name", "type": "string", "internalType": "string" },
+        { "name": "version", "type": "string", "internalType": "string" },
+        { "name": "chainId", "type": "uint256", "internalType": "uint256" },
+        { "name": "verifyingContract", "type": "address", "internalType": "address" },
+        { "name": "salt", "type": "bytes32", "internalType": "bytes32" },
+        { "name": "extensions", "type": "uint256[]", "internalType": "uint256[]" }
+      ],
+      "stateMutability": "view"
+    },
     {
       "type": "function",
       "name": "name",
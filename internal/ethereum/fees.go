@@ -0,0 +1,17 @@
+package ethereum
+
+import (
+	"math/big"
+)
+
+// DynamicFeeFlag in PoolKey.fee marks a pool whose LP fee is set by its hook
+// instead of fixed in the key, see LPFeeLibrary in v4-core.
+const DynamicFeeFlag = 0x800000
+
+// MaxLPFee is the highest LP fee, 100% in hundredths of a bip.
+const MaxLPFee = 1000000
+
+// IsDynamicFee reports whether a PoolKey fee is the dynamic fee flag.
+func IsDynamicFee(fee *big.Int) bool {
+	return fee.Cmp(big.NewInt(DynamicFeeFlag)) == 0
+}
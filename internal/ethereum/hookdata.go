@@ -0,0 +1,16 @@
+package ethereum
+
+import (
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/hookdata"
+)
+
+// HookData maps hook addresses to the schemas structured hookData is
+// encoded against
+var HookData *hookdata.Registry
+
+func InitHookData(cfg *config.Config) error {
+	var err error
+	HookData, err = hookdata.NewRegistry(cfg.HookDataSchemas, cfg.HookDataSchemasPath)
+	return err
+}
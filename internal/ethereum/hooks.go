@@ -0,0 +1,74 @@
+package ethereum
+
+import (
+	"context"
+	"strings"
+
+	"uniswap-v4-rpc/pkg/hooks"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// HookABI is BaseHook.getHookPermissions, which hooks built on v4-periphery
+// expose to declare the permissions their address must carry.
+var HookABI abi.ABI
+
+func init() {
+	var err error
+	HookABI, err = abi.JSON(strings.NewReader(HookABIJSON))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetHookPermissions calls getHookPermissions on a hook contract.
+func GetHookPermissions(hook common.Address) (*hooks.Permissions, error) {
+	data, err := HookABI.Pack("getHookPermissions")
+	if err != nil {
+		return nil, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &hook, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := HookABI.Unpack("getHookPermissions", out)
+	if err != nil {
+		return nil, err
+	}
+	permissions := *abi.ConvertType(values[0], new(hooks.Permissions)).(*hooks.Permissions)
+	return &permissions, nil
+}
+
+const HookABIJSON = `[
+  {
+    "type": "function",
+    "name": "getHookPermissions",
+    "inputs": [],
+    "outputs": [
+      {
+        "name": "",
+        "type": "tuple",
+        "internalType": "struct Hooks.Permissions",
+        "components": [
+          { "name": "beforeInitialize", "type": "bool", "internalType": "bool" },
+          { "name": "afterInitialize", "type": "bool", "internalType": "bool" },
+          { "name": "beforeAddLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "afterAddLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "beforeRemoveLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "afterRemoveLiquidity", "type": "bool", "internalType": "bool" },
+          { "name": "beforeSwap", "type": "bool", "internalType": "bool" },
+          { "name": "afterSwap", "type": "bool", "internalType": "bool" },
+          { "name": "beforeDonate", "type": "bool", "internalType": "bool" },
+          { "name": "afterDonate", "type": "bool", "internalType": "bool" },
+          { "name": "beforeSwapReturnDelta", "type": "bool", "internalType": "bool" },
+          { "name": "afterSwapReturnDelta", "type": "bool", "internalType": "bool" },
+          { "name": "afterAddLiquidityReturnDelta", "type": "bool", "internalType": "bool" },
+          { "name": "afterRemoveLiquidityReturnDelta", "type": "bool", "internalType": "bool" }
+        ]
+      }
+    ],
+    "stateMutability": "pure"
+  }
+]`
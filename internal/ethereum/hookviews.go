@@ -0,0 +1,37 @@
+package ethereum
+
+import (
+	"context"
+
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/hookviews"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// HookViews maps hook addresses to the ABIs their view functions are read
+// with
+var HookViews *hookviews.Registry
+
+// InitHookViews loads the configured hook ABIs. Hooks with an adapter are
+// also read with the adapter's ABI, registered by hookadapters.Init.
+func InitHookViews(cfg *config.Config) error {
+	var err error
+	HookViews, err = hookviews.NewRegistry(cfg.HookABIs)
+	return err
+}
+
+// ReadHookView calls the view function name of hook with args, as decoded
+// by abijson.Decode, and returns its outputs by name.
+func ReadHookView(hook common.Address, name string, args []interface{}) (map[string]interface{}, error) {
+	call, err := HookViews.Pack(hook, name, args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &hook, Data: call.Data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return call.Decode(out)
+}
@@ -0,0 +1,30 @@
+package ethereum
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PoolKey mirrors the v4-core PoolKey struct. Field names match the ABI so it
+// can be passed straight to Pack.
+type PoolKey struct {
+	Currency0   common.Address
+	Currency1   common.Address
+	Fee         *big.Int
+	TickSpacing *big.Int
+	Hooks       common.Address
+}
+
+// ID returns the PoolId, keccak256(abi.encode(key)).
+func (k PoolKey) ID() common.Hash {
+	return crypto.Keccak256Hash(
+		common.LeftPadBytes(k.Currency0.Bytes(), 32),
+		common.LeftPadBytes(k.Currency1.Bytes(), 32),
+		math.U256Bytes(new(big.Int).Set(k.Fee)),
+		math.U256Bytes(new(big.Int).Set(k.TickSpacing)),
+		common.LeftPadBytes(k.Hooks.Bytes(), 32),
+	)
+}
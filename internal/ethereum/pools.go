@@ -0,0 +1,206 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/pools"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// poolSyncBlockRange is the most blocks read with one eth_getLogs call, which
+// node providers cap.
+const poolSyncBlockRange = 10000
+
+// ErrPoolsNotSynced is returned by reads of the pool registry before its
+// first sync.
+var ErrPoolsNotSynced = errors.New("the pool registry has not synced yet")
+
+var (
+	// Pools holds every pool the PoolManager has initialized, the source of
+	// truth for which pools exist
+	Pools *pools.Registry
+
+	poolsFromBlock uint64
+	poolSyncMu     sync.Mutex
+)
+
+// InitPools starts the pool registry, which backfills Initialize events from
+// pools_from_block and then follows new blocks.
+func InitPools(cfg *config.Config) error {
+	Pools = pools.NewRegistry()
+	poolsFromBlock = cfg.PoolsFromBlock
+
+	interval := time.Duration(cfg.PoolSyncSecs) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			if err := SyncPools(); err != nil {
+				log.Printf("Pool registry: failed to sync: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// SyncPools reads the Initialize events since the registry's last synced
+// block. A chain whose head is behind that block, such as a restarted dev
+// node, or whose block there has another hash, as after a reorg, is synced
+// again from the start.
+func SyncPools() error {
+	poolSyncMu.Lock()
+	defer poolSyncMu.Unlock()
+
+	ctx := context.Background()
+	headHeader, err := Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	head := headHeader.Number.Uint64()
+	from := poolsFromBlock
+	if synced, hash, started := Pools.Synced(); started {
+		if head < synced {
+			log.Printf("Pool registry: head %d is behind synced block %d, syncing again", head, synced)
+			Pools.Reset()
+		} else if header, err := Client.HeaderByNumber(ctx, new(big.Int).SetUint64(synced)); err != nil {
+			return err
+		} else if header.Hash() != hash {
+			log.Printf("Pool registry: synced block %d changed from %s to %s, syncing again", synced, hash.Hex(), header.Hash().Hex())
+			Pools.Reset()
+		} else if synced+1 > from {
+			from = synced + 1
+		}
+	}
+	// Nothing to read yet still counts as synced, so reads are served
+	if _, _, started := Pools.Synced(); !started && from > head {
+		Pools.Add(head, headHeader.Hash())
+	}
+
+	event := ManagerABI.Events["Initialize"]
+	for start := from; start <= head; start += poolSyncBlockRange {
+		end := start + poolSyncBlockRange - 1
+		endHash := headHeader.Hash()
+		if end >= head {
+			end = head
+		} else if header, err := Client.HeaderByNumber(ctx, new(big.Int).SetUint64(end)); err != nil {
+			return err
+		} else {
+			endHash = header.Hash()
+		}
+		logs, err := Client.FilterLogs(context.Background(), goethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{ManagerAddress},
+			Topics:    [][]common.Hash{{event.ID}},
+		})
+		if err != nil {
+			return err
+		}
+		found := make([]pools.Pool, 0, len(logs))
+		for _, log := range logs {
+			pool, err := decodeInitialize(log)
+			if err != nil {
+				return err
+			}
+			if pool != nil {
+				found = append(found, *pool)
+			}
+		}
+		Pools.Add(end, endHash, found...)
+	}
+	return nil
+}
+
+// decodeInitialize decodes an Initialize event, nil for a malformed log.
+func decodeInitialize(log types.Log) (*pools.Pool, error) {
+	if len(log.Topics) < 4 {
+		return nil, nil
+	}
+	values, err := ManagerABI.Unpack("Initialize", log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Initialize: %v", err)
+	}
+	return &pools.Pool{
+		ID:           log.Topics[1],
+		Currency0:    common.BytesToAddress(log.Topics[2].Bytes()),
+		Currency1:    common.BytesToAddress(log.Topics[3].Bytes()),
+		Fee:          values[0].(*big.Int),
+		TickSpacing:  values[1].(*big.Int),
+		Hooks:        values[2].(common.Address),
+		SqrtPriceX96: values[3].(*big.Int),
+		Tick:         int(values[4].(*big.Int).Int64()),
+		Block:        log.BlockNumber,
+		TxHash:       log.TxHash,
+		LogIndex:     log.Index,
+	}, nil
+}
+
+// PoolKeyOf returns the key of a registered pool.
+func PoolKeyOf(pool pools.Pool) PoolKey {
+	return PoolKey{
+		Currency0:   pool.Currency0,
+		Currency1:   pool.Currency1,
+		Fee:         pool.Fee,
+		TickSpacing: pool.TickSpacing,
+		Hooks:       pool.Hooks,
+	}
+}
+
+// ListPools returns the pools matching filter from the pool registry, as of
+// its last sync.
+func ListPools(filter pools.Filter) ([]pools.Pool, error) {
+	if _, _, started := Pools.Synced(); !started {
+		return nil, ErrPoolsNotSynced
+	}
+	return Pools.List(filter), nil
+}
+
+// FindPool returns the pool with id from the pool registry, as of its last
+// sync.
+func FindPool(id common.Hash) (pools.Pool, bool, error) {
+	if _, _, started := Pools.Synced(); !started {
+		return pools.Pool{}, false, ErrPoolsNotSynced
+	}
+	pool, ok := Pools.Get(id)
+	return pool, ok, nil
+}
+
+// InitializedPool is a pool announced by a PoolManager Initialize event.
+type InitializedPool struct {
+	Key          PoolKey
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Block        uint64
+}
+
+// GetInitializedPools returns every pool the PoolManager has initialized,
+// from the pool registry.
+func GetInitializedPools() ([]InitializedPool, error) {
+	list, err := ListPools(pools.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	initialized := make([]InitializedPool, len(list))
+	for i, pool := range list {
+		initialized[i] = InitializedPool{
+			Key:          PoolKeyOf(pool),
+			SqrtPriceX96: pool.SqrtPriceX96,
+			Tick:         pool.Tick,
+			Block:        pool.Block,
+		}
+	}
+	return initialized, nil
+}
@@ -0,0 +1,15 @@
+package ethereum
+
+import (
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/positions"
+)
+
+// Positions maps client labels to the router positions the server opened
+var Positions *positions.Registry
+
+func InitPositions(cfg *config.Config) error {
+	var err error
+	Positions, err = positions.NewRegistry(cfg.PositionsPath)
+	return err
+}
@@ -0,0 +1,229 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	PositionManagerAddress common.Address
+	Permit2Address         common.Address
+	PositionManagerABI     abi.ABI
+	Permit2ABI             abi.ABI
+)
+
+func initPositionManager(positionManager, permit2 string) error {
+	var err error
+	PositionManagerABI, err = abi.JSON(strings.NewReader(PositionManagerABIJSON))
+	if err != nil {
+		return err
+	}
+	Permit2ABI, err = abi.JSON(strings.NewReader(Permit2ABIJSON))
+	if err != nil {
+		return err
+	}
+	PositionManagerAddress = common.HexToAddress(positionManager)
+	Permit2Address = common.HexToAddress(permit2)
+	return nil
+}
+
+// HasPositionManager reports whether a PositionManager is configured.
+func HasPositionManager() bool {
+	return PositionManagerAddress != (common.Address{}) && Permit2Address != (common.Address{})
+}
+
+// PositionConfig mirrors the PositionManager PositionConfig struct, which
+// identifies the pool and range of a position NFT.
+type PositionConfig struct {
+	PoolKey   PoolKey
+	TickLower *big.Int
+	TickUpper *big.Int
+}
+
+// MintedTokenIDs returns the ids of the positions the PositionManager minted
+// in a transaction, from its MintPosition events.
+func MintedTokenIDs(receipt *types.Receipt) []*big.Int {
+	event := PositionManagerABI.Events["MintPosition"]
+	var ids []*big.Int
+	for _, log := range receipt.Logs {
+		if log.Address != PositionManagerAddress || len(log.Topics) < 2 || log.Topics[0] != event.ID {
+			continue
+		}
+		ids = append(ids, log.Topics[1].Big())
+	}
+	return ids
+}
+
+// GetPositionConfig looks up the config a position was minted with from its
+// MintPosition event, since the PositionManager only stores its hash.
+func GetPositionConfig(tokenID *big.Int) (*PositionConfig, error) {
+	event := PositionManagerABI.Events["MintPosition"]
+	logs, err := Client.FilterLogs(context.Background(), goethereum.FilterQuery{
+		Addresses: []common.Address{PositionManagerAddress},
+		Topics:    [][]common.Hash{{event.ID}, {common.BigToHash(tokenID)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("position %s was not minted by %s", tokenID.String(), PositionManagerAddress.Hex())
+	}
+
+	var decoded struct {
+		Config PositionConfig
+	}
+	if err := PositionManagerABI.UnpackIntoInterface(&decoded, "MintPosition", logs[0].Data); err != nil {
+		return nil, fmt.Errorf("failed to decode MintPosition: %v", err)
+	}
+	return &decoded.Config, nil
+}
+
+func callPositionManager(method string, args ...interface{}) ([]interface{}, error) {
+	data, err := PositionManagerABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &PositionManagerAddress, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return PositionManagerABI.Unpack(method, out)
+}
+
+// GetPositionOwner returns the holder of a position NFT.
+func GetPositionOwner(tokenID *big.Int) (common.Address, error) {
+	values, err := callPositionManager("ownerOf", tokenID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return values[0].(common.Address), nil
+}
+
+// GetPositionLiquidity returns the liquidity of a position NFT.
+func GetPositionLiquidity(tokenID *big.Int, config *PositionConfig) (*big.Int, error) {
+	values, err := callPositionManager("getPositionLiquidity", tokenID, config)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+const positionConfigABIJSON = `{
+      "name": "config",
+      "type": "tuple",
+      "internalType": "struct PositionConfig",
+      "components": [
+        {
+          "name": "poolKey",
+          "type": "tuple",
+          "internalType": "struct PoolKey",
+          "components": [
+            { "name": "currency0", "type": "address", "internalType": "Currency" },
+            { "name": "currency1", "type": "address", "internalType": "Currency" },
+            { "name": "fee", "type": "uint24", "internalType": "uint24" },
+            { "name": "tickSpacing", "type": "int24", "internalType": "int24" },
+            { "name": "hooks", "type": "address", "internalType": "contract IHooks" }
+          ]
+        },
+        { "name": "tickLower", "type": "int24", "internalType": "int24" },
+        { "name": "tickUpper", "type": "int24", "internalType": "int24" }
+      ]
+    }`
+
+// PositionManagerABIJSON is the subset of the v4-periphery PositionManager
+// (and its ERC-721) the server uses.
+const PositionManagerABIJSON = `[
+  {
+    "type": "function",
+    "name": "modifyLiquidities",
+    "inputs": [
+      { "name": "unlockData", "type": "bytes", "internalType": "bytes" },
+      { "name": "deadline", "type": "uint256", "internalType": "uint256" }
+    ],
+    "outputs": [],
+    "stateMutability": "payable"
+  },
+  {
+    "type": "function",
+    "name": "nextTokenId",
+    "inputs": [],
+    "outputs": [{ "name": "", "type": "uint256", "internalType": "uint256" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "getPositionLiquidity",
+    "inputs": [
+      { "name": "tokenId", "type": "uint256", "internalType": "uint256" },
+      ` + positionConfigABIJSON + `
+    ],
+    "outputs": [{ "name": "liquidity", "type": "uint128", "internalType": "uint128" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "ownerOf",
+    "inputs": [{ "name": "id", "type": "uint256", "internalType": "uint256" }],
+    "outputs": [{ "name": "owner", "type": "address", "internalType": "address" }],
+    "stateMutability": "view"
+  },
+  {
+    "type": "function",
+    "name": "transferFrom",
+    "inputs": [
+      { "name": "from", "type": "address", "internalType": "address" },
+      { "name": "to", "type": "address", "internalType": "address" },
+      { "name": "id", "type": "uint256", "internalType": "uint256" }
+    ],
+    "outputs": [],
+    "stateMutability": "nonpayable"
+  },
+  {
+    "type": "event",
+    "name": "MintPosition",
+    "inputs": [
+      { "name": "tokenId", "type": "uint256", "indexed": true, "internalType": "uint256" },
+      ` + positionConfigABIJSON + `
+    ],
+    "anonymous": false
+  }
+]`
+
+// Permit2ABIJSON is the AllowanceTransfer subset of Permit2, which the
+// PositionManager pulls tokens through.
+const Permit2ABIJSON = `[
+  {
+    "type": "function",
+    "name": "approve",
+    "inputs": [
+      { "name": "token", "type": "address", "internalType": "address" },
+      { "name": "spender", "type": "address", "internalType": "address" },
+      { "name": "amount", "type": "uint160", "internalType": "uint160" },
+      { "name": "expiration", "type": "uint48", "internalType": "uint48" }
+    ],
+    "outputs": [],
+    "stateMutability": "nonpayable"
+  },
+  {
+    "type": "function",
+    "name": "allowance",
+    "inputs": [
+      { "name": "user", "type": "address", "internalType": "address" },
+      { "name": "token", "type": "address", "internalType": "address" },
+      { "name": "spender", "type": "address", "internalType": "address" }
+    ],
+    "outputs": [
+      { "name": "amount", "type": "uint160", "internalType": "uint160" },
+      { "name": "expiration", "type": "uint48", "internalType": "uint48" },
+      { "name": "nonce", "type": "uint48", "internalType": "uint48" }
+    ],
+    "stateMutability": "view"
+  }
+]`
@@ -0,0 +1,96 @@
+package ethereum
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// MaxProtocolFee is the highest protocol fee of one swap direction, 0.1% in
+// hundredths of a bip, see ProtocolFeeLibrary in v4-core.
+const MaxProtocolFee = 1000
+
+// PackProtocolFee packs the fees of both swap directions the way the
+// PoolManager stores them: zeroForOne in the lower and oneForZero in the
+// upper 12 bits.
+func PackProtocolFee(zeroForOne, oneForZero uint32) uint32 {
+	return oneForZero<<12 | zeroForOne
+}
+
+// UnpackProtocolFee splits a packed protocol fee into its two directions.
+func UnpackProtocolFee(fee uint32) (zeroForOne, oneForZero uint32) {
+	return fee & 0xfff, fee >> 12
+}
+
+// ValidateProtocolFee checks a packed protocol fee as setProtocolFee does,
+// failing where the PoolManager would revert with ProtocolFeeTooLarge.
+func ValidateProtocolFee(fee uint32) error {
+	zeroForOne, oneForZero := UnpackProtocolFee(fee)
+	if fee > 0xffffff || zeroForOne > MaxProtocolFee || oneForZero > MaxProtocolFee {
+		return fmt.Errorf("ProtocolFeeTooLarge(%d): each direction is at most %d", fee, MaxProtocolFee)
+	}
+	return nil
+}
+
+// GetManagerOwner returns the PoolManager owner, who sets the protocol fee
+// controller.
+func GetManagerOwner() (common.Address, error) {
+	values, err := callManager("owner")
+	if err != nil {
+		return common.Address{}, err
+	}
+	return values[0].(common.Address), nil
+}
+
+// GetProtocolFeeController returns the account allowed to set protocol fees
+// and collect them.
+func GetProtocolFeeController() (common.Address, error) {
+	values, err := callManager("protocolFeeController")
+	if err != nil {
+		return common.Address{}, err
+	}
+	return values[0].(common.Address), nil
+}
+
+// GetProtocolFeesAccrued returns the protocol fees of currency the
+// controller can collect.
+func GetProtocolFeesAccrued(currency common.Address) (*big.Int, error) {
+	values, err := callManager("protocolFeesAccrued", currency)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// ProtocolFeeEvent is a decoded ProtocolFeeUpdated or
+// ProtocolFeeControllerUpdated event.
+type ProtocolFeeEvent struct {
+	Event       string          `json:"event"`
+	PoolID      *common.Hash    `json:"poolId,omitempty"`
+	ProtocolFee *uint32         `json:"protocolFee,omitempty"`
+	Controller  *common.Address `json:"controller,omitempty"`
+}
+
+// ProtocolFeeEvents decodes the PoolManager's protocol fee events in receipt.
+func ProtocolFeeEvents(receipt *types.Receipt) []ProtocolFeeEvent {
+	feeUpdated := ManagerABI.Events["ProtocolFeeUpdated"]
+	controllerUpdated := ManagerABI.Events["ProtocolFeeControllerUpdated"]
+	var events []ProtocolFeeEvent
+	for _, log := range receipt.Logs {
+		if log.Address != ManagerAddress || len(log.Topics) < 2 {
+			continue
+		}
+		switch log.Topics[0] {
+		case feeUpdated.ID:
+			poolID := log.Topics[1]
+			fee := uint32(new(big.Int).SetBytes(log.Data).Uint64())
+			events = append(events, ProtocolFeeEvent{Event: feeUpdated.Name, PoolID: &poolID, ProtocolFee: &fee})
+		case controllerUpdated.ID:
+			controller := common.BytesToAddress(log.Topics[1].Bytes())
+			events = append(events, ProtocolFeeEvent{Event: controllerUpdated.Name, Controller: &controller})
+		}
+	}
+	return events
+}
@@ -0,0 +1,53 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/relayer"
+	"uniswap-v4-rpc/internal/signer"
+)
+
+// Relayers submits the gasless permit transactions
+var Relayers *relayer.Pool
+
+// InitRelayers builds the relayer pool from config and starts balance
+// monitoring. Relayer accounts must be dedicated: the server signer sends
+// with nonces read from the node, which would collide with the nonces the
+// pool hands out locally.
+func InitRelayers(cfg *config.Config) error {
+	if len(cfg.Relayers) == 0 {
+		return errors.New("no relayers configured, the permit routes need at least one dedicated relayer account")
+	}
+	var signers []signer.Signer
+	for i := range cfg.Relayers {
+		s, err := signer.FromConfig(&cfg.Relayers[i])
+		if err != nil {
+			return err
+		}
+		if Signer != nil && s.Address() == Signer.Address() {
+			return fmt.Errorf("relayer %s is the server signer, relayers need dedicated accounts", s.Address().Hex())
+		}
+		signers = append(signers, s)
+	}
+
+	minBalance, err := relayer.ParseMinBalance(cfg.RelayerMinBalance)
+	if err != nil {
+		return err
+	}
+
+	Relayers, err = relayer.NewPool(Client, signers, cfg.RelayerSelection, minBalance)
+	if err != nil {
+		return err
+	}
+
+	interval := time.Duration(cfg.RelayerBalanceCheckSecs) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	Relayers.Start(context.Background(), interval)
+	return nil
+}
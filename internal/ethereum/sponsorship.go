@@ -0,0 +1,15 @@
+package ethereum
+
+import (
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/sponsorship"
+)
+
+// Sponsorship decides which permit relays the server pays gas for
+var Sponsorship *sponsorship.Policy
+
+func InitSponsorship(cfg *config.Config) error {
+	var err error
+	Sponsorship, err = sponsorship.NewPolicy(cfg.Sponsorship)
+	return err
+}
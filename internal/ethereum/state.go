@@ -0,0 +1,234 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PoolsSlot is the storage slot of the PoolManager's pools mapping, see
+// StateLibrary.POOLS_SLOT in v4-core.
+const PoolsSlot = 6
+
+// Slot0 is the packed first word of a pool's state.
+type Slot0 struct {
+	SqrtPriceX96 *big.Int
+	Tick         int
+	ProtocolFee  uint32
+	LPFee        uint32
+}
+
+// PoolStateSlot returns the storage slot of pools[poolID] in the PoolManager.
+func PoolStateSlot(poolID common.Hash) common.Hash {
+	return crypto.Keccak256Hash(poolID.Bytes(), common.LeftPadBytes(big.NewInt(PoolsSlot).Bytes(), 32))
+}
+
+// Extsload reads one storage word of the PoolManager.
+func Extsload(slot common.Hash) (common.Hash, error) {
+	data, err := ManagerABI.Pack("extsload", slot)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &ManagerAddress, Data: data}, nil)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("extsload failed: %v", err)
+	}
+	values, err := ManagerABI.Unpack("extsload", out)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to decode extsload result: %v", err)
+	}
+	return common.Hash(values[0].([32]byte)), nil
+}
+
+// GetSlot0 reads the price, tick and fees of a pool, as StateLibrary.getSlot0.
+// SqrtPriceX96 is zero for a pool that has not been initialized.
+func GetSlot0(poolID common.Hash) (*Slot0, error) {
+	word, err := Extsload(PoolStateSlot(poolID))
+	if err != nil {
+		return nil, err
+	}
+	data := word.Big()
+
+	mask160 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+	tick := int(new(big.Int).Rsh(data, 160).Uint64() & 0xFFFFFF)
+	if tick&0x800000 != 0 {
+		tick -= 1 << 24
+	}
+
+	return &Slot0{
+		SqrtPriceX96: new(big.Int).And(data, mask160),
+		Tick:         tick,
+		ProtocolFee:  uint32(new(big.Int).Rsh(data, 184).Uint64() & 0xFFFFFF),
+		LPFee:        uint32(new(big.Int).Rsh(data, 208).Uint64() & 0xFFFFFF),
+	}, nil
+}
+
+// PositionsOffset is the index of the positions mapping in Pool.State.
+const PositionsOffset = 6
+
+// PositionInfo is a position's liquidity and the fee growth inside its range
+// when it was last touched.
+type PositionInfo struct {
+	Liquidity                *big.Int
+	FeeGrowthInside0LastX128 *big.Int
+	FeeGrowthInside1LastX128 *big.Int
+}
+
+// PositionKey returns the key of a position in the PoolManager,
+// keccak256(abi.encodePacked(owner, int24 tickLower, int24 tickUpper, salt)).
+func PositionKey(owner common.Address, tickLower, tickUpper int, salt common.Hash) common.Hash {
+	packed := make([]byte, 0, 58)
+	packed = append(packed, owner.Bytes()...)
+	packed = append(packed, int24Bytes(tickLower)...)
+	packed = append(packed, int24Bytes(tickUpper)...)
+	packed = append(packed, salt.Bytes()...)
+	return crypto.Keccak256Hash(packed)
+}
+
+func int24Bytes(v int) []byte {
+	u := uint32(v) & 0xFFFFFF
+	return []byte{byte(u >> 16), byte(u >> 8), byte(u)}
+}
+
+// ExtsloadRange reads n consecutive storage words of the PoolManager.
+func ExtsloadRange(slot common.Hash, n int) ([]common.Hash, error) {
+	// extsload0 is the (bytes32 startSlot, uint256 nSlots) overload
+	data, err := ManagerABI.Pack("extsload0", slot, big.NewInt(int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	out, err := Client.CallContract(context.Background(), goethereum.CallMsg{To: &ManagerAddress, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("extsload failed: %v", err)
+	}
+	values, err := ManagerABI.Unpack("extsload0", out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode extsload result: %v", err)
+	}
+	words := values[0].([][32]byte)
+	hashes := make([]common.Hash, len(words))
+	for i, word := range words {
+		hashes[i] = common.Hash(word)
+	}
+	return hashes, nil
+}
+
+// GetPositionInfo reads a position, as StateLibrary.getPositionInfo.
+func GetPositionInfo(poolID, positionKey common.Hash) (*PositionInfo, error) {
+	positionsSlot := new(big.Int).Add(PoolStateSlot(poolID).Big(), big.NewInt(PositionsOffset))
+	slot := crypto.Keccak256Hash(positionKey.Bytes(), common.BigToHash(positionsSlot).Bytes())
+
+	words, err := ExtsloadRange(slot, 3)
+	if err != nil {
+		return nil, err
+	}
+	return &PositionInfo{
+		Liquidity:                words[0].Big(),
+		FeeGrowthInside0LastX128: words[1].Big(),
+		FeeGrowthInside1LastX128: words[2].Big(),
+	}, nil
+}
+
+// Offsets of fields in Pool.State, see StateLibrary in v4-core.
+const (
+	FeeGrowthGlobal0Offset = 1
+	LiquidityOffset        = 3
+	TicksOffset            = 4
+)
+
+// TickInfo is the state of an initialized tick.
+type TickInfo struct {
+	LiquidityGross        *big.Int
+	LiquidityNet          *big.Int
+	FeeGrowthOutside0X128 *big.Int
+	FeeGrowthOutside1X128 *big.Int
+}
+
+// GetFeeGrowthGlobals reads the pool's global fee growth per unit of
+// liquidity for both currencies.
+func GetFeeGrowthGlobals(poolID common.Hash) (*big.Int, *big.Int, error) {
+	slot := new(big.Int).Add(PoolStateSlot(poolID).Big(), big.NewInt(FeeGrowthGlobal0Offset))
+	words, err := ExtsloadRange(common.BigToHash(slot), 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return words[0].Big(), words[1].Big(), nil
+}
+
+// GetLiquidity reads the pool's in-range liquidity, as
+// StateLibrary.getLiquidity.
+func GetLiquidity(poolID common.Hash) (*big.Int, error) {
+	slot := new(big.Int).Add(PoolStateSlot(poolID).Big(), big.NewInt(LiquidityOffset))
+	word, err := Extsload(common.BigToHash(slot))
+	if err != nil {
+		return nil, err
+	}
+	// liquidity is a uint128 in the low half of the word
+	return new(big.Int).And(word.Big(), new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))), nil
+}
+
+// GetTickInfo reads a tick, as StateLibrary.getTickInfo.
+func GetTickInfo(poolID common.Hash, tick int) (*TickInfo, error) {
+	ticksSlot := new(big.Int).Add(PoolStateSlot(poolID).Big(), big.NewInt(TicksOffset))
+	slot := crypto.Keccak256Hash(math.U256Bytes(big.NewInt(int64(tick))), common.BigToHash(ticksSlot).Bytes())
+
+	words, err := ExtsloadRange(slot, 3)
+	if err != nil {
+		return nil, err
+	}
+	// liquidityGross is the lower and liquidityNet the upper 128 bits
+	packed := words[0].Big()
+	liquidityNet := new(big.Int).Rsh(packed, 128)
+	if liquidityNet.Bit(127) == 1 {
+		liquidityNet.Sub(liquidityNet, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return &TickInfo{
+		LiquidityGross:        new(big.Int).And(packed, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))),
+		LiquidityNet:          liquidityNet,
+		FeeGrowthOutside0X128: words[1].Big(),
+		FeeGrowthOutside1X128: words[2].Big(),
+	}, nil
+}
+
+// GetFeeGrowthInside returns the fee growth per unit of liquidity inside a
+// tick range, as StateLibrary.getFeeGrowthInside. Values wrap modulo 2^256
+// like the unchecked Solidity arithmetic.
+func GetFeeGrowthInside(poolID common.Hash, tickLower, tickUpper int) (*big.Int, *big.Int, error) {
+	global0, global1, err := GetFeeGrowthGlobals(poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lower, err := GetTickInfo(poolID, tickLower)
+	if err != nil {
+		return nil, nil, err
+	}
+	upper, err := GetTickInfo(poolID, tickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+	slot0, err := GetSlot0(poolID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inside := func(global, lowerOutside, upperOutside *big.Int) *big.Int {
+		var result *big.Int
+		switch {
+		case slot0.Tick < tickLower:
+			result = new(big.Int).Sub(lowerOutside, upperOutside)
+		case slot0.Tick >= tickUpper:
+			result = new(big.Int).Sub(upperOutside, lowerOutside)
+		default:
+			result = new(big.Int).Sub(global, lowerOutside)
+			result.Sub(result, upperOutside)
+		}
+		return math.U256(result)
+	}
+	return inside(global0, lower.FeeGrowthOutside0X128, upper.FeeGrowthOutside0X128),
+		inside(global1, lower.FeeGrowthOutside1X128, upper.FeeGrowthOutside1X128), nil
+}
@@ -0,0 +1,62 @@
+package ethereum
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Swap router modes, selecting the contract the swap routes go through.
+const (
+	// SwapRouterTest is the PoolSwapTest router at swap_router_address
+	SwapRouterTest = "test"
+	// SwapRouterV4 is the v4-periphery V4Router at v4_router_address
+	SwapRouterV4 = "v4router"
+)
+
+var (
+	V4RouterAddress common.Address
+	V4RouterABI     abi.ABI
+	// SwapRouterMode is the router swaps use unless a request picks one
+	SwapRouterMode = SwapRouterTest
+)
+
+func initV4Router(router, mode string) error {
+	var err error
+	V4RouterABI, err = abi.JSON(strings.NewReader(V4RouterABIJSON))
+	if err != nil {
+		return err
+	}
+	V4RouterAddress = common.HexToAddress(router)
+	if mode != "" {
+		SwapRouterMode = mode
+	}
+	return nil
+}
+
+// HasV4Router reports whether a V4Router is configured.
+func HasV4Router() bool {
+	return V4RouterAddress != (common.Address{})
+}
+
+// V4RouterABIJSON is the entry point of a deployed V4Router. V4Router itself
+// is abstract; deployments expose its action plans through executeActions
+// and pull input tokens from the caller with transferFrom. Native ETH is paid
+// from msg.value, and executeActionsAndSweepExcessETH refunds what is left.
+const V4RouterABIJSON = `[
+  {
+    "type": "function",
+    "name": "executeActions",
+    "inputs": [{ "name": "params", "type": "bytes", "internalType": "bytes" }],
+    "outputs": [],
+    "stateMutability": "payable"
+  },
+  {
+    "type": "function",
+    "name": "executeActionsAndSweepExcessETH",
+    "inputs": [{ "name": "params", "type": "bytes", "internalType": "bytes" }],
+    "outputs": [],
+    "stateMutability": "payable"
+  }
+]`
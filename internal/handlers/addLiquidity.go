@@ -7,8 +7,10 @@ import (
 	"math/big"
 
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
 	"uniswap-v4-rpc/pkg/utils"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gin-gonic/gin"
@@ -18,25 +20,125 @@ func AddLiquidity(c *gin.Context) {
 	var req struct {
 		Currency0 common.Address `json:"currency0" binding:"required"`
 		Currency1 common.Address `json:"currency1" binding:"required"`
+		// Range as ticks, or as prices of currency0 in currency1 raw units
+		// which are widened to the enclosing usable ticks. Full range when
+		// omitted.
+		TickLower  *int   `json:"tickLower"`
+		TickUpper  *int   `json:"tickUpper"`
+		PriceLower string `json:"priceLower"`
+		PriceUpper string `json:"priceUpper"`
+		// Amounts the liquidity is sized from, and the least of each that
+		// must be deposited
+		Amount0Desired string `json:"amount0Desired"`
+		Amount1Desired string `json:"amount1Desired"`
+		Amount0Min     string `json:"amount0Min"`
+		Amount1Min     string `json:"amount1Min"`
+		// Salt or label of the position, so a tick range can hold several.
+		// A registered label defaults to its range.
+		PositionSalt
+		PoolFee
+		HookDataOption
+		ClaimOptions
+		TxOptions
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
 
 	currency0 := req.Currency0
 	currency1 := req.Currency1
 
-	minTick := big.NewInt(-887220)
-	maxTick := big.NewInt(887220)
-	liquidityAmount, _ := new(big.Int).SetString("100000000000000000000", 10) // 100 ether
+	amounts, err := parseAmounts(
+		amountField{req.Amount0Desired, "amount0Desired"},
+		amountField{req.Amount1Desired, "amount1Desired"},
+		amountField{req.Amount0Min, "amount0Min"},
+		amountField{req.Amount1Min, "amount1Min"},
+	)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	amount0Desired, amount1Desired, amount0Min, amount1Min := amounts[0], amounts[1], amounts[2], amounts[3]
+
+	poolKey := req.poolKey(currency0, currency1)
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.AddLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if entry, ok := req.registered(); ok && req.TickLower == nil && req.TickUpper == nil && req.PriceLower == "" && req.PriceUpper == "" {
+		req.TickLower, req.TickUpper = &entry.TickLower, &entry.TickUpper
+	}
+	tickLower, tickUpper, err := resolveTickRange(req.TickLower, req.TickUpper, req.PriceLower, req.PriceUpper, int(poolKey.TickSpacing.Int64()))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	salt, entry, status, err := req.position(poolKey, tickLower, tickUpper, ethereum.Signer.Address())
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Size the liquidity from the desired amounts at the current price
+	sized, status, err := sizeLiquidity(poolKey, tickLower, tickUpper, amount0Desired, amount1Desired)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	liquidityAmount, amount0, amount1 := sized.liquidity, sized.amount0, sized.amount1
+	// Native ETH is always currency0 and is paid with msg.value; the router
+	// refunds what the pool does not take
+	value := nativeValue(currency0, amount0)
+	if req.SettleUsingBurn {
+		value = big.NewInt(0)
+	}
 
 	log.Printf("Adding liquidity with the following parameters:")
 	log.Printf("Currency0: %s", currency0.Hex())
 	log.Printf("Currency1: %s", currency1.Hex())
+	log.Printf("Ticks: [%d, %d], current tick %d", tickLower, tickUpper, sized.slot0.Tick)
 	log.Printf("LiquidityAmount: %s", liquidityAmount.String())
 
+	params := struct {
+		TickLower      *big.Int
+		TickUpper      *big.Int
+		LiquidityDelta *big.Int
+		Salt           [32]byte
+	}{
+		TickLower:      big.NewInt(int64(tickLower)),
+		TickUpper:      big.NewInt(int64(tickUpper)),
+		LiquidityDelta: liquidityAmount,
+		Salt:           salt,
+	}
+
+	data, err := ethereum.LPRouterABI.Pack("modifyLiquidity", poolKey, params, hookData, req.SettleUsingBurn, req.TakeClaims)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	log.Printf("data: 0x%x", data)
+
+	if exported {
+		if err := checkMinAmounts(amount0, amount1, amount0Min, amount1Min); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if err := registerPending(entry); err != nil {
+			c.JSON(positionStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.LPRouterAddress, value: value, gasLimit: 500000, data: data}})
+		return
+	}
+
 	auth, err := createTransactor()
 	if err != nil {
 		log.Printf("Failed to create transactor: %v", err)
@@ -54,12 +156,12 @@ func AddLiquidity(c *gin.Context) {
 
 	log.Printf("Transactor created with address: %s", auth.From.Hex())
 
-	poolKey := createPoolKey(currency0, currency1, ethereum.HookAddress)
-
-	if err := utils.CheckContractDeployment(currency0); err != nil {
-		log.Printf("Error with currency0 contract: %v", err)
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Currency0 contract issue: %v", err)})
-		return
+	if !ethereum.IsNative(currency0) {
+		if err := utils.CheckContractDeployment(currency0); err != nil {
+			log.Printf("Error with currency0 contract: %v", err)
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Currency0 contract issue: %v", err)})
+			return
+		}
 	}
 	if err := utils.CheckContractDeployment(currency1); err != nil {
 		log.Printf("Error with currency1 contract: %v", err)
@@ -67,6 +169,19 @@ func AddLiquidity(c *gin.Context) {
 		return
 	}
 
+	// Simulate to get the exact amounts, including hook adjustments, and
+	// enforce the minimums on them before sending
+	delta0, delta1, err := simulateModifyLiquidity(auth.From, "modifyLiquidity", data, value)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("modifyLiquidity simulation failed: %v", err)})
+		return
+	}
+	amount0, amount1 = new(big.Int).Neg(delta0), new(big.Int).Neg(delta1)
+	if err := checkMinAmounts(amount0, amount1, amount0Min, amount1Min); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Check balances before adding liquidity
 	balance0Before, err := utils.GetBalance(currency0, auth.From)
 	if err != nil {
@@ -84,40 +199,44 @@ func AddLiquidity(c *gin.Context) {
 	}
 	log.Printf("Balance of currency1 before: %s", balance1Before.String())
 
-	params := struct {
-		TickLower      *big.Int
-		TickUpper      *big.Int
-		LiquidityDelta *big.Int
-		Salt           [32]byte
-	}{
-		TickLower:      minTick,
-		TickUpper:      maxTick,
-		LiquidityDelta: liquidityAmount,
-		Salt:           [32]byte{},
-	}
-	log.Printf("data: %s", auth.Nonce.Uint64(), ethereum.LPRouterAddress, big.NewInt(0), 500000, auth.GasPrice)
+	log.Printf("Sending modifyLiquidity to %s with nonce %d and gas price %s", ethereum.LPRouterAddress.Hex(), auth.Nonce.Uint64(), auth.GasPrice.String())
 
-	data, err := ethereum.LPRouterABI.Pack("modifyLiquidity", poolKey, params, []byte{}, false, false)
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
-		return
-	}
-	log.Printf("data: %s", data, auth.Nonce.Uint64(), ethereum.LPRouterAddress, big.NewInt(0), 500000, auth.GasPrice, data)
-
-	tx := types.NewTransaction(auth.Nonce.Uint64(), ethereum.LPRouterAddress, big.NewInt(0), 500000, auth.GasPrice, data)
+	tx := types.NewTransaction(auth.Nonce.Uint64(), ethereum.LPRouterAddress, value, 500000, auth.GasPrice, data)
 	signedTx, err := auth.Signer(auth.From, tx)
 	if err != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to sign transaction: %v", err)})
 		return
 	}
-	log.Printf("signedTx: %s", signedTx)
+	log.Printf("signedTx: %s", signedTx.Hash().Hex())
 
 	err = ethereum.Client.SendTransaction(context.Background(), signedTx)
 	if err != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to send transaction: %v", err)})
 		return
 	}
-	log.Printf("data: %s", data)
+
+	// The label is only recorded once the deposit is mined
+	receipt, err := bind.WaitMined(context.Background(), ethereum.Client, signedTx)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to wait for transaction: %v", err)})
+		return
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("modifyLiquidity transaction %s reverted", signedTx.Hash().Hex())})
+		return
+	}
+	if err := register(entry); err != nil {
+		log.Printf("Failed to register position %q: %v", req.Label, err)
+	}
+
+	// With ETH attached, account for what the router refunded
+	var native gin.H
+	if value.Sign() > 0 {
+		if native, err = nativeRefund(auth.From, balance0Before, value, receipt); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to account for the ETH sent: %v", err)})
+			return
+		}
+	}
 
 	// Check balances after adding liquidity
 	balance0After, err := utils.GetBalance(currency0, auth.From)
@@ -133,19 +252,29 @@ func AddLiquidity(c *gin.Context) {
 		return
 	}
 
-	delta0 := new(big.Int).Sub(balance0After, balance0Before)
-	delta1 := new(big.Int).Sub(balance1After, balance1Before)
+	balanceDelta0 := new(big.Int).Sub(balance0After, balance0Before)
+	balanceDelta1 := new(big.Int).Sub(balance1After, balance1Before)
 
-	c.JSON(200, gin.H{
+	response := gin.H{
 		"status":         "Liquidity added successfully",
 		"txHash":         signedTx.Hash().Hex(),
 		"balancesBefore": gin.H{"currency0": balance0Before.String(), "currency1": balance1Before.String()},
 		"balancesAfter":  gin.H{"currency0": balance0After.String(), "currency1": balance1After.String()},
-		"deltaBalances":  gin.H{"currency0": delta0.String(), "currency1": delta1.String()},
+		"deltaBalances":  gin.H{"currency0": balanceDelta0.String(), "currency1": balanceDelta1.String()},
 		"params": gin.H{
 			"currency0":       currency0.Hex(),
 			"currency1":       currency1.Hex(),
+			"tickLower":       tickLower,
+			"tickUpper":       tickUpper,
+			"salt":            salt.Hex(),
+			"label":           req.Label,
 			"liquidityAmount": liquidityAmount.String(),
+			"amount0":         amount0.String(),
+			"amount1":         amount1.String(),
 		},
-	})
+	}
+	if native != nil {
+		response["native"] = native
+	}
+	c.JSON(200, response)
 }
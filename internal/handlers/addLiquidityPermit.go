@@ -1,18 +1,17 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"math/big"
 	"time"
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/positions"
+	"uniswap-v4-rpc/internal/sponsorship"
 	"uniswap-v4-rpc/pkg/utils"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,7 +20,17 @@ type AddLiquidityRequest struct {
 	Currency1   string `json:"currency1" binding:"required"`
 	Amount      string `json:"amount" binding:"required"`
 	UserAddress string `json:"userAddress" binding:"required"`
-	PrivateKey  string `json:"privateKey" binding:"required"`
+	// Permit0Signature and Permit1Signature are the owner's signatures over
+	// the currency0 and currency1 permits, used instead of privateKey
+	Permit0Signature string `json:"permit0Signature"`
+	Permit1Signature string `json:"permit1Signature"`
+	// Salt or label of the full range position
+	PositionSalt
+	PoolFee
+	HookDataOption
+	ClaimOptions
+	PermitAuth
+	TxOptions
 }
 
 func AddLiquidityPermit(c *gin.Context) {
@@ -30,20 +39,27 @@ func AddLiquidityPermit(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
 		return
 	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
 
 	// Convert string inputs to appropriate types
 	currency0 := common.HexToAddress(req.Currency0)
 	currency1 := common.HexToAddress(req.Currency1)
+	if err := rejectNative(currency0, currency1); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	amount, success := new(big.Int).SetString(req.Amount, 10)
 	if !success {
 		c.JSON(400, gin.H{"error": "Invalid amount value"})
 		return
 	}
 	userAddress := common.HexToAddress(req.UserAddress)
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(req.PrivateKey)
+	userSigner, err := req.permitSigner(req.Permit0Signature, req.Permit1Signature)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid private key: " + err.Error()})
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -52,7 +68,24 @@ func AddLiquidityPermit(c *gin.Context) {
 	maxTick := big.NewInt(887220)
 
 	// Create the pool key
-	poolKey := createPoolKey(currency0, currency1, ethereum.HookAddress)
+	poolKey := req.poolKey(currency0, currency1)
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.AddLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	salt, entry, status, err := req.position(poolKey, int(minTick.Int64()), int(maxTick.Int64()), userAddress)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	// Only the depositor can name an unlabelled position again, and the
+	// server account's routes refuse it
+	positionSalt := salt
+	if entry == nil {
+		positionSalt = positions.DepositorSalt(userAddress, salt)
+		ethereum.Positions.RecordDeposit(positionSalt, userAddress)
+	}
 
 	// Prepare modifyLiquidity parameters
 	params := struct {
@@ -64,23 +97,50 @@ func AddLiquidityPermit(c *gin.Context) {
 		TickLower:      minTick,
 		TickUpper:      maxTick,
 		LiquidityDelta: amount,
-		Salt:           [32]byte{},
+		Salt:           positionSalt,
 	}
 
 	// Prepare permit data
-	deadline := big.NewInt(time.Now().Unix() + 3600) // 1 hour from now
-	value := new(big.Int).Mul(amount, big.NewInt(10))
+	deadline, err := req.deadline(big.NewInt(time.Now().Unix() + 3600)) // 1 hour from now
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// The router checks the permits against |liquidityDelta| for both tokens
+	value := new(big.Int).Abs(amount)
 
-	// Generate permit signatures for both tokens
-	v0, r0, s0, err := utils.GeneratePermitSignature(currency0, userAddress, ethereum.LPRouterAddress, value, deadline, privateKey)
+	// Generate permit signatures for both tokens, or take the owner's
+	permit0, err := ownerPermit(currency0, userAddress, ethereum.LPRouterAddress, value, deadline, userSigner, req.Permit0Signature)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Error generating permit signature for currency0: " + err.Error()})
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Error generating permit signature for currency0: " + err.Error()})
 		return
 	}
 
-	v1, r1, s1, err := utils.GeneratePermitSignature(currency1, userAddress, ethereum.LPRouterAddress, value, deadline, privateKey)
+	permit1, err := ownerPermit(currency1, userAddress, ethereum.LPRouterAddress, value, deadline, userSigner, req.Permit1Signature)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Error generating permit signature for currency1: " + err.Error()})
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Error generating permit signature for currency1: " + err.Error()})
+		return
+	}
+
+	// Check both permits against chain state before paying gas to relay them
+	if err := utils.ValidatePermit(permit0); err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Invalid permit for currency0: " + err.Error()})
+		return
+	}
+	if err := utils.ValidatePermit(permit1); err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Invalid permit for currency1: " + err.Error()})
+		return
+	}
+
+	// The router takes the permits as v, r and s
+	v0, r0, s0, err := permit0.VRS()
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Permit for currency0: " + err.Error()})
+		return
+	}
+	v1, r1, s1, err := permit1.VRS()
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Permit for currency1: " + err.Error()})
 		return
 	}
 
@@ -89,9 +149,9 @@ func AddLiquidityPermit(c *gin.Context) {
 		userAddress,
 		poolKey,
 		params,
-		[]byte{}, // hookData
-		false,    // settleUsingBurn
-		false,    // takeClaims
+		hookData,
+		req.SettleUsingBurn,
+		req.TakeClaims,
 		deadline,
 		v0, r0, s0,
 		v1, r1, s1,
@@ -101,14 +161,14 @@ func AddLiquidityPermit(c *gin.Context) {
 		return
 	}
 
-	chainID, err := ethereum.Client.ChainID(context.Background())
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Error getting chain ID: " + err.Error()})
-		return
-	}
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Error creating transactor: " + err.Error()})
+	// The permits authorize the router, so the caller can submit the
+	// transaction from any account without the relayer pool or sponsorship
+	if exported {
+		if err := registerPending(entry); err != nil {
+			c.JSON(positionStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.LPRouterAddress, gasLimit: 1000000, data: data}})
 		return
 	}
 
@@ -125,32 +185,32 @@ func AddLiquidityPermit(c *gin.Context) {
 		return
 	}
 
-	// Create and send the transaction
-	nonce, err := ethereum.Client.PendingNonceAt(context.Background(), auth.From)
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error fetching nonce: %v", err)})
-		return
-	}
-
-	gasPrice, err := ethereum.Client.SuggestGasPrice(context.Background())
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error fetching gas price: %v", err)})
-		return
+	// Relay the transaction from a pool account, which pays for gas
+	sponsorReq := sponsorship.Request{
+		User:    userAddress,
+		PoolID:  poolKey.ID(),
+		Tokens:  []common.Address{currency0, currency1},
+		Amounts: []*big.Int{value, value},
 	}
-
-	tx := types.NewTransaction(nonce, ethereum.LPRouterAddress, big.NewInt(0), 1000000, gasPrice, data)
-
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error signing transaction: %v", err)})
-		return
+	// The label is held while the relay is pending and confirmed or
+	// released once its receipt arrives
+	call := txCall{to: ethereum.LPRouterAddress, gasLimit: 1000000, data: data}
+	if entry != nil {
+		if err := registerPending(entry); err != nil {
+			c.JSON(positionStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		call.onMined = settleRegistration(entry.Label)
 	}
-
-	err = ethereum.Client.SendTransaction(context.Background(), signedTx)
+	sent, relayerAddress, err := sponsoredRelay(sponsorReq, []txCall{call}, nil, common.Address{})
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error sending transaction: %v", err)})
+		if entry != nil {
+			ethereum.Positions.Release(entry.Label)
+		}
+		c.JSON(relayErrorStatus(err), gin.H{"error": fmt.Sprintf("Error relaying transaction: %v", err)})
 		return
 	}
+	signedTx := sent[0]
 
 	balance0After, err := utils.GetBalance(currency0, userAddress)
 	if err != nil {
@@ -170,7 +230,10 @@ func AddLiquidityPermit(c *gin.Context) {
 
 	c.JSON(200, gin.H{
 		"txHash":         signedTx.Hash().Hex(),
+		"relayer":        relayerAddress.Hex(),
 		"message":        "Add liquidity with permit initiated successfully",
+		"salt":           salt.Hex(),
+		"positionSalt":   positionSalt.Hex(),
 		"balancesBefore": gin.H{"currency0": balance0Before.String(), "currency1": balance1Before.String()},
 		"balancesAfter":  gin.H{"currency0": balance0After.String(), "currency1": balance1After.String()},
 		"deltaBalances":  gin.H{"currency0": delta0.String(), "currency1": delta1.String()},
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin only lets requests carrying the configured admin token in the
+// X-Admin-Token header through. Admin routes are disabled without a token.
+func RequireAdmin(c *gin.Context) {
+	if ethereum.AdminToken == "" {
+		c.AbortWithStatusJSON(403, gin.H{"error": "Admin routes are disabled, set admin_token to enable them"})
+		return
+	}
+	token := c.GetHeader("X-Admin-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(ethereum.AdminToken)) != 1 {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Invalid admin token"})
+		return
+	}
+	c.Next()
+}
+
+// SponsorshipLedger returns the gas sponsored per user, optionally filtered
+// by the user, pool and day (YYYY-MM-DD, UTC) query parameters.
+func SponsorshipLedger(c *gin.Context) {
+	var user *common.Address
+	if value := c.Query("user"); value != "" {
+		if !common.IsHexAddress(value) {
+			c.JSON(400, gin.H{"error": "Invalid user address"})
+			return
+		}
+		address := common.HexToAddress(value)
+		user = &address
+	}
+	var poolID *common.Hash
+	if value := c.Query("pool"); value != "" {
+		id := common.HexToHash(value)
+		poolID = &id
+	}
+
+	entries, totals := ethereum.Sponsorship.Ledger().Query(user, poolID, c.Query("day"))
+	c.JSON(200, gin.H{
+		"entries": entries,
+		"totals":  totals,
+	})
+}
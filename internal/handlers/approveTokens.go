@@ -3,18 +3,23 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"math/big"
 
+	"uniswap-v4-rpc/internal/ethereum"
 	"uniswap-v4-rpc/pkg/utils"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 )
 
-// ApproveTokens handles the approval of both tokens for the SwapRouter and LPRouter
+// ApproveTokens handles the approval of both tokens for the SwapRouter and LPRouter,
+// and for the V4Router, the donate router and (through Permit2) the
+// PositionManager when configured
 func ApproveTokens(c *gin.Context) {
 	var req struct {
 		Currency0 string `json:"currency0" binding:"required"`
 		Currency1 string `json:"currency1" binding:"required"`
+		TxOptions
 	}
 
 	log.Println("eeeeee", req.Currency0, req.Currency1)
@@ -27,6 +32,45 @@ func ApproveTokens(c *gin.Context) {
 	currency1 := common.HexToAddress(req.Currency1)
 	log.Println(currency0, currency1)
 
+	if exported, handled := req.exported(c); handled {
+		return
+	} else if exported {
+		spenders := []common.Address{ethereum.SwapRouterAddress, ethereum.LPRouterAddress}
+		if ethereum.HasPositionManager() {
+			spenders = append(spenders, ethereum.Permit2Address)
+		}
+		if ethereum.HasV4Router() {
+			spenders = append(spenders, ethereum.V4RouterAddress)
+		}
+		if ethereum.HasDonateRouter() {
+			spenders = append(spenders, ethereum.DonateRouterAddress)
+		}
+		var calls []txCall
+		for _, currency := range []common.Address{currency0, currency1} {
+			if ethereum.IsNative(currency) {
+				continue
+			}
+			for _, router := range spenders {
+				data, err := ethereum.PackERC20("approve", router, maxApproval())
+				if err != nil {
+					c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack approve data: %v", err)})
+					return
+				}
+				calls = append(calls, txCall{to: currency, gasLimit: 100000, data: data})
+			}
+			if ethereum.HasPositionManager() {
+				data, err := ethereum.Permit2ABI.Pack("approve", currency, ethereum.PositionManagerAddress, utils.MaxPermit2Amount(), utils.MaxPermit2Expiration())
+				if err != nil {
+					c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack approve data: %v", err)})
+					return
+				}
+				calls = append(calls, txCall{to: ethereum.Permit2Address, gasLimit: 100000, data: data})
+			}
+		}
+		respondExported(c, req.TxOptions, calls)
+		return
+	}
+
 	// Create transactor
 	auth, err := createTransactor()
 	if err != nil {
@@ -58,3 +102,7 @@ func ApproveTokens(c *gin.Context) {
 
 	c.JSON(200, results)
 }
+
+func maxApproval() *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimOptions is embedded in swap and liquidity requests. With TakeClaims
+// the router mints ERC-6909 claims on the PoolManager for what the caller
+// receives instead of transferring tokens. With SettleUsingBurn it pays by
+// burning the caller's claims, which needs the router set as the caller's
+// operator with /claims/setOperator.
+type ClaimOptions struct {
+	TakeClaims      bool `json:"takeClaims"`
+	SettleUsingBurn bool `json:"settleUsingBurn"`
+}
+
+// testSettings mirrors the PoolSwapTest TestSettings struct.
+type testSettings struct {
+	TakeClaims      bool
+	SettleUsingBurn bool
+}
+
+func (o ClaimOptions) testSettings() testSettings {
+	return testSettings{TakeClaims: o.TakeClaims, SettleUsingBurn: o.SettleUsingBurn}
+}
+
+func (o ClaimOptions) used() bool {
+	return o.TakeClaims || o.SettleUsingBurn
+}
+
+// parseAddressField parses a required address field. For a currency the
+// zero address is native ETH.
+func parseAddressField(value, name string) (common.Address, error) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return common.HexToAddress(value), nil
+}
+
+// GetClaimBalance returns an owner's ERC-6909 claims of a currency held in
+// the PoolManager.
+func GetClaimBalance(c *gin.Context) {
+	owner, err := parseAddressField(c.Query("owner"), "owner")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	currency, err := parseAddressField(c.Query("currency"), "currency")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	balance, err := ethereum.GetClaimBalance(owner, currency)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read claim balance: %v", err)})
+		return
+	}
+	c.JSON(200, gin.H{
+		"owner":    owner.Hex(),
+		"currency": currency.Hex(),
+		"id":       ethereum.ClaimID(currency).String(),
+		"balance":  balance.String(),
+	})
+}
+
+// GetClaimAllowance returns how many of an owner's claims of a currency a
+// spender may transfer, and whether it is the owner's operator.
+func GetClaimAllowance(c *gin.Context) {
+	owner, err := parseAddressField(c.Query("owner"), "owner")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	spender, err := parseAddressField(c.Query("spender"), "spender")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	currency, err := parseAddressField(c.Query("currency"), "currency")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	allowance, err := ethereum.GetClaimAllowance(owner, spender, currency)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read claim allowance: %v", err)})
+		return
+	}
+	operator, err := ethereum.IsClaimOperator(owner, spender)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read operator: %v", err)})
+		return
+	}
+	c.JSON(200, gin.H{
+		"owner":      owner.Hex(),
+		"spender":    spender.Hex(),
+		"currency":   currency.Hex(),
+		"id":         ethereum.ClaimID(currency).String(),
+		"allowance":  allowance.String(),
+		"isOperator": operator,
+	})
+}
+
+// sendClaimCall sends or exports a PoolManager ERC-6909 call.
+func sendClaimCall(c *gin.Context, opts TxOptions, exported bool, method string, args ...interface{}) {
+	data, err := ethereum.ManagerABI.Pack(method, args...)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.ManagerAddress, gasLimit: 100000, data: data}
+	if exported {
+		respondExported(c, opts, []txCall{call})
+		return
+	}
+	signedTx, _, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": method + " sent successfully", "txHash": signedTx.Hash().Hex()})
+}
+
+// claimTransfer is the body of the claim transfer and approve routes.
+type claimTransfer struct {
+	Currency string `json:"currency" binding:"required"`
+	Amount   string `json:"amount" binding:"required"`
+	TxOptions
+}
+
+func (t claimTransfer) parse() (*big.Int, *big.Int, error) {
+	currency, err := parseAddressField(t.Currency, "currency")
+	if err != nil {
+		return nil, nil, err
+	}
+	amount, ok := new(big.Int).SetString(t.Amount, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid amount %q", t.Amount)
+	}
+	return ethereum.ClaimID(currency), amount, nil
+}
+
+// TransferClaims transfers the caller's claims of a currency to receiver.
+func TransferClaims(c *gin.Context) {
+	var req struct {
+		Receiver string `json:"receiver" binding:"required"`
+		claimTransfer
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	receiver, err := parseAddressField(req.Receiver, "receiver")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	id, amount, err := req.parse()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sendClaimCall(c, req.TxOptions, exported, "transfer", receiver, id, amount)
+}
+
+// TransferClaimsFrom transfers sender's claims of a currency to receiver,
+// spending the caller's allowance unless it is sender's operator.
+func TransferClaimsFrom(c *gin.Context) {
+	var req struct {
+		Sender   string `json:"sender" binding:"required"`
+		Receiver string `json:"receiver" binding:"required"`
+		claimTransfer
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	sender, err := parseAddressField(req.Sender, "sender")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	receiver, err := parseAddressField(req.Receiver, "receiver")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	id, amount, err := req.parse()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sendClaimCall(c, req.TxOptions, exported, "transferFrom", sender, receiver, id, amount)
+}
+
+// ApproveClaims lets spender transfer amount of the caller's claims of a
+// currency.
+func ApproveClaims(c *gin.Context) {
+	var req struct {
+		Spender string `json:"spender" binding:"required"`
+		claimTransfer
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	spender, err := parseAddressField(req.Spender, "spender")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	id, amount, err := req.parse()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sendClaimCall(c, req.TxOptions, exported, "approve", spender, id, amount)
+}
+
+// SetClaimOperator sets or clears operator as the caller's operator for all
+// claims. Routers need it to settle with settleUsingBurn.
+func SetClaimOperator(c *gin.Context) {
+	var req struct {
+		Operator string `json:"operator" binding:"required"`
+		Approved bool   `json:"approved"`
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	operator, err := parseAddressField(req.Operator, "operator")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sendClaimCall(c, req.TxOptions, exported, "setOperator", operator, req.Approved)
+}
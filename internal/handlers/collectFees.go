@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// expectedFees returns the fees the position has earned since it was last
+// touched, from the pool's fee growth inside its range.
+func expectedFees(p *position) (*big.Int, *big.Int, error) {
+	inside0, inside1, err := ethereum.GetFeeGrowthInside(p.poolKey.ID(), p.tickLower, p.tickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v4math.FeesOwed(inside0, p.info.FeeGrowthInside0LastX128, p.info.Liquidity),
+		v4math.FeesOwed(inside1, p.info.FeeGrowthInside1LastX128, p.info.Liquidity), nil
+}
+
+// CollectFees credits the fees of a router owned position to the server
+// account with a zero liquidityDelta modifyLiquidity. Only positions the
+// server deposited can be collected from.
+func CollectFees(c *gin.Context) {
+	var req struct {
+		PositionRef
+		HookDataOption
+		ClaimOptions
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	// The fees are paid to the server account, so permit deposits are
+	// refused
+	if status, err := req.depositedByServer(); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	position, status, err := req.resolve()
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	fee0, fee1, err := expectedFees(position)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read fee growth: %v", err)})
+		return
+	}
+	log.Printf("Collecting fees from [%d, %d], expecting %s and %s", position.tickLower, position.tickUpper, fee0.String(), fee1.String())
+
+	hookData, status, err := req.hookData(position.poolKey.Hooks, hookadapters.RemoveLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := ethereum.LPRouterABI.Pack("modifyLiquidity", position.poolKey, position.modifyParams(big.NewInt(0)), hookData, req.SettleUsingBurn, req.TakeClaims)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+
+	response := gin.H{
+		"expectedFees": gin.H{"currency0": fee0.String(), "currency1": fee1.String()},
+		"params": gin.H{
+			"currency0": position.poolKey.Currency0.Hex(),
+			"currency1": position.poolKey.Currency1.Hex(),
+			"tickLower": position.tickLower,
+			"tickUpper": position.tickUpper,
+			"salt":      position.salt.Hex(),
+			"label":     position.label,
+			"liquidity": position.info.Liquidity.String(),
+		},
+	}
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.LPRouterAddress, gasLimit: 300000, data: data}})
+		return
+	}
+
+	auth, err := createTransactor()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create transactor: %v", err)})
+		return
+	}
+
+	collected0, collected1, err := simulateModifyLiquidity(auth.From, "modifyLiquidity", data, nil)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("modifyLiquidity simulation failed: %v", err)})
+		return
+	}
+
+	tx := types.NewTransaction(auth.Nonce.Uint64(), ethereum.LPRouterAddress, big.NewInt(0), 300000, auth.GasPrice, data)
+	signedTx, err := auth.Signer(auth.From, tx)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to sign transaction: %v", err)})
+		return
+	}
+	if err := ethereum.Client.SendTransaction(context.Background(), signedTx); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to send transaction: %v", err)})
+		return
+	}
+
+	response["status"] = "Fees collected successfully"
+	response["txHash"] = signedTx.Hash().Hex()
+	response["recipient"] = auth.From.Hex()
+	response["collected"] = gin.H{"currency0": collected0.String(), "currency1": collected1.String()}
+	c.JSON(200, response)
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/relayer"
+	"uniswap-v4-rpc/internal/signer"
+	"uniswap-v4-rpc/internal/sponsorship"
+	"uniswap-v4-rpc/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/gin-gonic/gin"
+)
+
+// requireDonateRouter writes a 503 unless the donate router is configured
+// and reports whether the request can go on.
+func requireDonateRouter(c *gin.Context) bool {
+	if !ethereum.HasDonateRouter() {
+		c.JSON(503, gin.H{"error": "donate router is not configured, set donate_router_address"})
+		return false
+	}
+	return true
+}
+
+// donation is a donation checked against the pool it goes to.
+type donation struct {
+	poolKey   ethereum.PoolKey
+	amount0   *big.Int
+	amount1   *big.Int
+	liquidity *big.Int
+	hookData  []byte
+	// growth0 and growth1 are the expected increases of the pool's global
+	// fee growth, in X128 fixed point per unit of liquidity
+	growth0 *big.Int
+	growth1 *big.Int
+}
+
+// checkDonation validates a donation of amount0 and amount1 to the pool.
+// The PoolManager reverts a donation to a pool without in-range liquidity,
+// since no position would earn it. The status is the HTTP status to report
+// with the error.
+func checkDonation(poolKey ethereum.PoolKey, amount0, amount1 *big.Int) (*donation, int, error) {
+	if amount0.Sign() == 0 && amount1.Sign() == 0 {
+		return nil, 400, fmt.Errorf("amount0 or amount1 is required")
+	}
+	slot0, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		return nil, 500, fmt.Errorf("failed to read pool state: %v", err)
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		return nil, 400, fmt.Errorf("pool is not initialized")
+	}
+	liquidity, err := ethereum.GetLiquidity(poolKey.ID())
+	if err != nil {
+		return nil, 500, fmt.Errorf("failed to read pool liquidity: %v", err)
+	}
+	if liquidity.Sign() == 0 {
+		return nil, 400, fmt.Errorf("pool has no in-range liquidity at tick %d to receive the donation", slot0.Tick)
+	}
+	return &donation{
+		poolKey:   poolKey,
+		amount0:   amount0,
+		amount1:   amount1,
+		liquidity: liquidity,
+		growth0:   ethereum.DonationFeeGrowth(amount0, liquidity),
+		growth1:   ethereum.DonationFeeGrowth(amount1, liquidity),
+	}, 200, nil
+}
+
+// pack encodes the donate router call.
+func (d *donation) pack() ([]byte, error) {
+	return ethereum.DonateRouterABI.Pack("donate", d.poolKey, d.amount0, d.amount1, d.hookData)
+}
+
+// json describes the donation and the fee growth it is expected to produce.
+func (d *donation) json() gin.H {
+	return gin.H{
+		"poolId":    d.poolKey.ID().Hex(),
+		"amount0":   d.amount0.String(),
+		"amount1":   d.amount1.String(),
+		"liquidity": d.liquidity.String(),
+		"expectedFeeGrowthIncreaseX128": gin.H{
+			"currency0": d.growth0.String(),
+			"currency1": d.growth1.String(),
+		},
+	}
+}
+
+// feeGrowthIncrease is the change of the pool's global fee growth between
+// two reads, wrapping modulo 2^256 like the pool's unchecked arithmetic.
+func feeGrowthIncrease(before0, before1, after0, after1 *big.Int) gin.H {
+	return gin.H{
+		"currency0": math.U256(new(big.Int).Sub(after0, before0)).String(),
+		"currency1": math.U256(new(big.Int).Sub(after1, before1)).String(),
+	}
+}
+
+// Donate donates to the in-range liquidity of a pool through the donate
+// router, paid by the server account. It reports the increase of the pool's
+// fee growth, which is what each unit of in-range liquidity earns.
+func Donate(c *gin.Context) {
+	var req struct {
+		Currency0 common.Address `json:"currency0" binding:"required"`
+		Currency1 common.Address `json:"currency1" binding:"required"`
+		Amount0   string         `json:"amount0"`
+		Amount1   string         `json:"amount1"`
+		PoolFee
+		HookDataOption
+		TxOptions
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	if !requireDonateRouter(c) {
+		return
+	}
+
+	amounts, err := parseAmounts(amountField{req.Amount0, "amount0"}, amountField{req.Amount1, "amount1"})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	d, status, err := checkDonation(req.poolKey(req.Currency0, req.Currency1), amounts[0], amounts[1])
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if d.hookData, status, err = req.hookData(d.poolKey.Hooks, hookadapters.Donate); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := d.pack()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	// Native ETH is currency0 and is paid with msg.value
+	call := txCall{to: ethereum.DonateRouterAddress, value: nativeValue(req.Currency0, d.amount0), gasLimit: 300000, data: data}
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	before0, before1, err := ethereum.GetFeeGrowthGlobals(d.poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read fee growth: %v", err)})
+		return
+	}
+	tx, _, native, err := sendAndWaitNative(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	after0, after1, err := ethereum.GetFeeGrowthGlobals(d.poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read fee growth: %v", err)})
+		return
+	}
+
+	response := gin.H{
+		"status":                "Donation sent successfully",
+		"txHash":                tx.Hash().Hex(),
+		"donation":              d.json(),
+		"feeGrowthIncreaseX128": feeGrowthIncrease(before0, before1, after0, after1),
+	}
+	if native != nil {
+		response["native"] = native
+	}
+	c.JSON(200, response)
+}
+
+// donatePermitCalls moves amount of token from user to the account sending
+// the calls and lets the donate router pull it. The donate router pays from
+// msg.sender and takes no permit, so the user's permit names the sender,
+// which is only known once the calls are built. A relayed pull is reported
+// to refund, nil for calls the caller sends.
+func donatePermitCalls(token, user common.Address, amount, deadline *big.Int, userSigner signer.Signer, signature string, refund *relayer.Refund) []txCall {
+	pull := txCall{
+		to:       token,
+		gasLimit: 100000,
+		buildData: func(sender common.Address) ([]byte, error) {
+			if refund != nil {
+				refund.From(sender)
+			}
+			return ethereum.PackERC20("transferFrom", user, sender, amount)
+		},
+	}
+	if refund != nil {
+		pull.onMined = refund.Pulled(token, amount)
+	}
+	return []txCall{
+		{
+			to:       token,
+			gasLimit: 100000,
+			buildData: func(sender common.Address) ([]byte, error) {
+				permit, err := ownerPermit(token, user, sender, amount, deadline, userSigner, signature)
+				if err != nil {
+					return nil, fmt.Errorf("failed to generate permit signature: %v", err)
+				}
+				if err := utils.ValidatePermit(permit); err != nil {
+					return nil, fmt.Errorf("invalid permit: %v", err)
+				}
+				v, r, s, err := permit.VRS()
+				if err != nil {
+					return nil, err
+				}
+				return ethereum.PackERC20("permit", user, sender, amount, deadline, v, r, s)
+			},
+		},
+		pull,
+		{
+			to:       token,
+			gasLimit: 100000,
+			buildData: func(common.Address) ([]byte, error) {
+				return ethereum.PackERC20("approve", ethereum.DonateRouterAddress, amount)
+			},
+		},
+	}
+}
+
+// DonatePermit donates a user's tokens, authorized with ERC-2612 permits.
+// A relayer pays for gas, submitting each permit to the token, pulling the
+// amount and then donating it.
+func DonatePermit(c *gin.Context) {
+	var req struct {
+		Currency0   string `json:"currency0" binding:"required"`
+		Currency1   string `json:"currency1" binding:"required"`
+		Amount0     string `json:"amount0"`
+		Amount1     string `json:"amount1"`
+		UserAddress string `json:"userAddress" binding:"required"`
+		// Permit0Signature and Permit1Signature are the owner's signatures
+		// over the permits, used instead of privateKey. They name the
+		// account submitting the donation, so they need mode "unsigned" with
+		// from, or mode "safe".
+		Permit0Signature string `json:"permit0Signature"`
+		Permit1Signature string `json:"permit1Signature"`
+		PoolFee
+		HookDataOption
+		PermitAuth
+		TxOptions
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	if !requireDonateRouter(c) {
+		return
+	}
+
+	currency0 := common.HexToAddress(req.Currency0)
+	currency1 := common.HexToAddress(req.Currency1)
+	if err := rejectNative(currency0, currency1); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	amounts, err := parseAmounts(amountField{req.Amount0, "amount0"}, amountField{req.Amount1, "amount1"})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	userAddress := common.HexToAddress(req.UserAddress)
+
+	// Only the currencies donated need a permit
+	tokens := []common.Address{currency0, currency1}
+	signatures := []string{req.Permit0Signature, req.Permit1Signature}
+	var needed []string
+	for i, amount := range amounts {
+		if amount.Sign() > 0 {
+			needed = append(needed, signatures[i])
+		}
+	}
+	userSigner, err := req.permitSigner(needed...)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if userSigner == nil {
+		if mode, _ := req.mode(); mode == ModeSend || (mode == ModeUnsigned && req.From == "") {
+			c.JSON(400, gin.H{"error": "permit signatures name the account submitting the donation, use privateKey or mode unsigned with from, or mode safe"})
+			return
+		}
+	}
+
+	// The permits are only built during the relay, so a contract wallet
+	// owner is refused before anything is sent
+	if err := requireEOAOwner(userAddress); err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	d, status, err := checkDonation(req.poolKey(currency0, currency1), amounts[0], amounts[1])
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	deadline, err := req.deadline(big.NewInt(time.Now().Unix() + 3600))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if d.hookData, status, err = req.hookData(d.poolKey.Hooks, hookadapters.Donate); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := d.pack()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+
+	var donated []common.Address
+	var donatedAmounts []*big.Int
+	var donatedSignatures []string
+	for i, amount := range amounts {
+		if amount.Sign() > 0 {
+			donated = append(donated, tokens[i])
+			donatedAmounts = append(donatedAmounts, amount)
+			donatedSignatures = append(donatedSignatures, signatures[i])
+		}
+	}
+	// A relayer holds the pulled tokens until the donation, so they are
+	// sent back to the user if it reverts
+	var refund *relayer.Refund
+	if !exported {
+		refund = ethereum.Relayers.NewRefund(len(donated), func(token common.Address, amount *big.Int) ([]byte, uint64, error) {
+			data, err := ethereum.PackERC20("transfer", userAddress, amount)
+			return data, 100000, err
+		})
+	}
+	var calls []txCall
+	for i, token := range donated {
+		calls = append(calls, donatePermitCalls(token, userAddress, donatedAmounts[i], deadline, userSigner, donatedSignatures[i], refund)...)
+	}
+	donate := txCall{to: ethereum.DonateRouterAddress, gasLimit: 300000, data: data}
+	if refund != nil {
+		donate.onMined = refund.Spent
+	}
+	calls = append(calls, donate)
+
+	if exported {
+		respondExported(c, req.TxOptions, calls)
+		return
+	}
+
+	sponsorReq := sponsorship.Request{
+		User:    userAddress,
+		PoolID:  d.poolKey.ID(),
+		Tokens:  donated,
+		Amounts: donatedAmounts,
+	}
+	sent, relayerAddress, err := sponsoredRelay(sponsorReq, calls, nil, common.Address{})
+	if err != nil {
+		// Each token takes a permit, a pull and an approval, so the pull of
+		// token i is call 3i+1
+		unsent := 0
+		for i := range donated {
+			if 3*i+1 >= len(sent) {
+				unsent++
+			}
+		}
+		refund.Abort(unsent)
+		c.JSON(relayErrorStatus(err), gin.H{"error": fmt.Sprintf("Error relaying transaction: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "Donation with permit initiated successfully",
+		"txHash":   sent[len(sent)-1].Hash().Hex(),
+		"relayer":  relayerAddress.Hex(),
+		"donation": d.json(),
+	})
+}
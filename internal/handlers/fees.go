@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// PoolFee is embedded in requests that name a pool by its currencies. With
+// DynamicFee the pool key carries the dynamic fee flag instead of the static
+// 3000 fee, so the hook sets the pool's LP fee.
+type PoolFee struct {
+	DynamicFee bool `json:"dynamicFee"`
+}
+
+// poolKey returns the key of the pool between currency0 and currency1 with
+// the configured hook.
+func (f PoolFee) poolKey(currency0, currency1 common.Address) ethereum.PoolKey {
+	key := createPoolKey(currency0, currency1, ethereum.HookAddress)
+	if f.DynamicFee {
+		key.Fee = big.NewInt(ethereum.DynamicFeeFlag)
+	}
+	return key
+}
+
+// UpdateDynamicLPFee sets the LP fee of a dynamic fee pool. Only the pool's
+// hook may call PoolManager.updateDynamicLPFee, so when the sending account
+// is the hook it calls the PoolManager directly. Otherwise the call goes to
+// the hook, which must expose updateDynamicLPFee(PoolKey,uint24) and forward
+// it to the PoolManager after its own access control.
+func UpdateDynamicLPFee(c *gin.Context) {
+	var req struct {
+		Currency0 common.Address `json:"currency0" binding:"required"`
+		Currency1 common.Address `json:"currency1" binding:"required"`
+		// LPFee is in hundredths of a bip, 3000 = 0.3%
+		LPFee *uint32 `json:"lpFee" binding:"required"`
+		TxOptions
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	if *req.LPFee > ethereum.MaxLPFee {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("lpFee %d is above the maximum of %d", *req.LPFee, ethereum.MaxLPFee)})
+		return
+	}
+
+	poolKey := PoolFee{DynamicFee: true}.poolKey(req.Currency0, req.Currency1)
+	if poolKey.Hooks == (common.Address{}) {
+		c.JSON(400, gin.H{"error": "dynamic fee pools need a hook, set hook_address"})
+		return
+	}
+	slot0, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		c.JSON(400, gin.H{"error": "dynamic fee pool is not initialized"})
+		return
+	}
+
+	data, err := ethereum.ManagerABI.Pack("updateDynamicLPFee", poolKey, big.NewInt(int64(*req.LPFee)))
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	via := "hook"
+	to := ethereum.HookAddress
+	if quoteSender(req.TxOptions) == ethereum.HookAddress {
+		via = "manager"
+		to = ethereum.ManagerAddress
+	}
+	call := txCall{to: to, gasLimit: 200000, data: data}
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, _, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	after, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status":        "Dynamic LP fee updated successfully",
+		"txHash":        signedTx.Hash().Hex(),
+		"via":           via,
+		"poolId":        poolKey.ID().Hex(),
+		"previousLPFee": slot0.LPFee,
+		"lpFee":         after.LPFee,
+	})
+}
+
+// GetLPFee returns the current LP fee of a pool from its slot0. Pass
+// dynamicFee=true for the dynamic fee pool of the currencies.
+func GetLPFee(c *gin.Context) {
+	currency0, err := parseAddressField(c.Query("currency0"), "currency0")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	currency1, err := parseAddressField(c.Query("currency1"), "currency1")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	poolKey := PoolFee{DynamicFee: c.Query("dynamicFee") == "true"}.poolKey(currency0, currency1)
+
+	slot0, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		c.JSON(404, gin.H{"error": "pool is not initialized"})
+		return
+	}
+	c.JSON(200, gin.H{
+		"poolId":      poolKey.ID().Hex(),
+		"fee":         poolKey.Fee.String(),
+		"dynamicFee":  ethereum.IsDynamicFee(poolKey.Fee),
+		"lpFee":       slot0.LPFee,
+		"protocolFee": slot0.ProtocolFee,
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// hookAdapter writes an error unless the hook in the address path parameter
+// has an adapter, and returns both.
+func hookAdapter(c *gin.Context) (common.Address, hookadapters.Adapter, bool) {
+	hook, err := hookParam(c.Param("address"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return hook, nil, false
+	}
+	adapter, err := hookadapters.Default.Lookup(hook)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return hook, nil, false
+	}
+	if adapter == nil {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("no hook adapter handles %s", hook.Hex())})
+		return hook, nil, false
+	}
+	return hook, adapter, true
+}
+
+// ListHookAdapters returns the registered hook adapters and the hooks bound
+// to them.
+func ListHookAdapters(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"adapters":   hookadapters.Default.Adapters(),
+		"operations": hookadapters.Operations,
+	})
+}
+
+// GetHookState returns what a hook keeps for the pool of currency0 and
+// currency1, read by the hook's adapter.
+func GetHookState(c *gin.Context) {
+	hook, adapter, ok := hookAdapter(c)
+	if !ok {
+		return
+	}
+	currency0, err := parseAddressField(c.Query("currency0"), "currency0")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	currency1, err := parseAddressField(c.Query("currency1"), "currency1")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	poolKey := PoolFee{DynamicFee: c.Query("dynamicFee") == "true"}.poolKey(currency0, currency1)
+	poolKey.Hooks = hook
+
+	state, err := adapter.State(hook, poolKey)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read %s hook state: %v", adapter.Name(), err)})
+		return
+	}
+	c.JSON(200, gin.H{
+		"hook":    hook.Hex(),
+		"adapter": adapter.Name(),
+		"poolId":  poolKey.ID().Hex(),
+		"state":   state,
+	})
+}
+
+// GetHookEvents returns the events a hook emitted in a transaction, decoded
+// by the hook's adapter.
+func GetHookEvents(c *gin.Context) {
+	hook, adapter, ok := hookAdapter(c)
+	if !ok {
+		return
+	}
+	txHash := c.Query("txHash")
+	if len(common.FromHex(txHash)) != common.HashLength {
+		c.JSON(400, gin.H{"error": "txHash must be a 32-byte hex string"})
+		return
+	}
+	receipt, err := ethereum.Client.TransactionReceipt(context.Background(), common.HexToHash(txHash))
+	if err != nil {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("Failed to read the receipt of %s: %v", txHash, err)})
+		return
+	}
+	events, err := adapter.DecodeEvents(hook, receipt.Logs)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if events == nil {
+		events = []hookadapters.Event{}
+	}
+	c.JSON(200, gin.H{
+		"hook":    hook.Hex(),
+		"adapter": adapter.Name(),
+		"txHash":  receipt.TxHash.Hex(),
+		"events":  events,
+	})
+}
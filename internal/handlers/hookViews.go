@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookviews"
+	"uniswap-v4-rpc/internal/pools"
+	"uniswap-v4-rpc/pkg/abijson"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// hookParam parses the hook named by value, the configured hook when empty.
+func hookParam(value string) (common.Address, error) {
+	if value == "" {
+		return ethereum.HookAddress, nil
+	}
+	return parseAddressField(value, "hook")
+}
+
+// hookViewStatus is the HTTP status to report with an error reading a hook
+// view.
+func hookViewStatus(err error) int {
+	switch {
+	case errors.Is(err, hookviews.ErrNoABI), errors.Is(err, hookviews.ErrUnknownView):
+		return 404
+	case errors.Is(err, hookviews.ErrInvalidArguments):
+		return 400
+	default:
+		return 500
+	}
+}
+
+func counterStatsJSON(poolKey ethereum.PoolKey, stats *ethereum.CounterStats) gin.H {
+	return gin.H{
+		"poolId":                     poolKey.ID().Hex(),
+		"currency0":                  poolKey.Currency0.Hex(),
+		"currency1":                  poolKey.Currency1.Hex(),
+		"fee":                        poolKey.Fee.String(),
+		"tickSpacing":                poolKey.TickSpacing.String(),
+		"beforeSwapCount":            stats.BeforeSwap.String(),
+		"afterSwapCount":             stats.AfterSwap.String(),
+		"beforeAddLiquidityCount":    stats.BeforeAddLiquidity.String(),
+		"beforeRemoveLiquidityCount": stats.BeforeRemoveLiquidity.String(),
+	}
+}
+
+// GetHookStats returns the callback counters a Counter hook keeps per pool,
+// for the pool of currency0 and currency1 when given, or else for every
+// initialized pool of the hook.
+func GetHookStats(c *gin.Context) {
+	hook, err := hookParam(c.Query("hook"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if deployed, err := ethereum.IsContract(hook); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read hook code: %v", err)})
+		return
+	} else if !deployed {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("hook %s is not a deployed contract", hook.Hex())})
+		return
+	}
+
+	var poolKeys []ethereum.PoolKey
+	if c.Query("currency0") != "" || c.Query("currency1") != "" {
+		currency0, err := parseAddressField(c.Query("currency0"), "currency0")
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		currency1, err := parseAddressField(c.Query("currency1"), "currency1")
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		poolKey := PoolFee{DynamicFee: c.Query("dynamicFee") == "true"}.poolKey(currency0, currency1)
+		poolKey.Hooks = hook
+		poolKeys = append(poolKeys, poolKey)
+	} else {
+		list, err := ethereum.ListPools(pools.Filter{Hooks: &hook})
+		if err != nil {
+			c.JSON(poolsErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to read initialized pools: %v", err)})
+			return
+		}
+		for _, pool := range list {
+			poolKeys = append(poolKeys, ethereum.PoolKeyOf(pool))
+		}
+	}
+
+	stats := make([]gin.H, 0, len(poolKeys))
+	for _, poolKey := range poolKeys {
+		counters, err := ethereum.GetHookStats(hook, poolKey.ID())
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read Counter stats of %s: %v", hook.Hex(), err)})
+			return
+		}
+		stats = append(stats, counterStatsJSON(poolKey, counters))
+	}
+	c.JSON(200, gin.H{"hook": hook.Hex(), "pools": stats})
+}
+
+// ListHookViews returns the view functions of a hook with a registered ABI,
+// or the hooks with one when no address is given.
+func ListHookViews(c *gin.Context) {
+	if c.Param("address") == "" {
+		c.JSON(200, gin.H{"hooks": ethereum.HookViews.Hooks()})
+		return
+	}
+	hook, err := hookParam(c.Param("address"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	views, err := ethereum.HookViews.Views(hook)
+	if err != nil {
+		c.JSON(hookViewStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"hook": hook.Hex(), "views": views})
+}
+
+// ReadHookView calls a view function of a hook with a registered ABI. Args
+// are positional and typed as for structured hookData.
+func ReadHookView(c *gin.Context) {
+	var req struct {
+		Args json.RawMessage `json:"args"`
+	}
+
+	// An empty body calls the view without arguments
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	hook, err := hookParam(c.Param("address"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	args := []interface{}{}
+	if len(req.Args) > 0 {
+		if err := abijson.Decode(req.Args, &args); err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("args must be an array: %v", err)})
+			return
+		}
+	}
+
+	method := c.Param("method")
+	outputs, err := ethereum.ReadHookView(hook, method, args)
+	if err != nil {
+		c.JSON(hookViewStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"hook": hook.Hex(), "method": method, "outputs": outputs})
+}
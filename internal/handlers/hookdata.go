@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/hookdata"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+)
+
+// HookDataOption is embedded in write requests whose pool calls its hook.
+// HookData is raw 0x-prefixed hex, or an object of the fields of the hook's
+// registered schema, which the server ABI encodes.
+type HookDataOption struct {
+	HookData json.RawMessage `json:"hookData"`
+}
+
+// hookData encodes the request's hookData for op on a pool of hook, through
+// the hook's adapter when it has one and otherwise as raw hex or against the
+// hook's registered schema. The status is the HTTP status to report with
+// the error.
+func (o HookDataOption) hookData(hook common.Address, op hookadapters.Operation) ([]byte, int, error) {
+	adapter, err := hookadapters.Default.Lookup(hook)
+	if err != nil {
+		return nil, 500, err
+	}
+	if adapter != nil {
+		data, err := adapter.EncodeHookData(op, hook, o.HookData)
+		if err == nil {
+			return data, 200, nil
+		}
+		if !errors.Is(err, hookadapters.ErrNotHandled) {
+			return nil, 400, fmt.Errorf("%w: %s adapter: %v", hookdata.ErrInvalidHookData, adapter.Name(), err)
+		}
+	}
+	data, err := ethereum.HookData.Encode(hook, o.HookData)
+	if err != nil {
+		if hookdata.IsRequestError(err) {
+			return nil, 400, err
+		}
+		return nil, 500, fmt.Errorf("failed to encode hookData: %v", err)
+	}
+	return data, 200, nil
+}
+
+// HookDataSchemas returns the registered hookData schemas by hook address.
+func HookDataSchemas(c *gin.Context) {
+	c.JSON(200, gin.H{"schemas": ethereum.HookData.All()})
+}
+
+// EncodeHookData returns the bytes a write route would send as hookData for
+// a hook, the configured hook when none is given, and an operation, swap
+// when none is given.
+func EncodeHookData(c *gin.Context) {
+	var req struct {
+		Hook      string `json:"hook"`
+		Operation string `json:"operation"`
+		HookDataOption
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	hook := ethereum.HookAddress
+	if req.Hook != "" {
+		address, err := parseAddressField(req.Hook, "hook")
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		hook = address
+	}
+	op := hookadapters.Swap
+	if req.Operation != "" {
+		parsed, err := hookadapters.ParseOperation(req.Operation)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		op = parsed
+	}
+	data, status, err := req.hookData(hook, op)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"hook": hook.Hex(), "operation": op, "hookData": hexutil.Encode(data)})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/hooks"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// checkPoolKeyHook validates the hook of a pool key before initialize, as
+// the PoolManager would, and that a hook with callbacks has code to call.
+// The status is the HTTP status to report with the error.
+func checkPoolKeyHook(poolKey ethereum.PoolKey) (int, error) {
+	if err := hooks.ValidatePoolKey(poolKey.Hooks, poolKey.Fee); err != nil {
+		return 400, err
+	}
+	if hooks.Flags(poolKey.Hooks) == 0 {
+		return 200, nil
+	}
+	isContract, err := ethereum.IsContract(poolKey.Hooks)
+	if err != nil {
+		return 500, fmt.Errorf("failed to read hook code: %v", err)
+	}
+	if !isContract {
+		return 400, fmt.Errorf("%w %s: no contract is deployed there to receive its callbacks", hooks.ErrInvalidHook, poolKey.Hooks.Hex())
+	}
+	return 200, nil
+}
+
+// hookJSON describes the callbacks a hook address receives.
+func hookJSON(hook common.Address) gin.H {
+	permissions := hooks.FromAddress(hook)
+	return gin.H{
+		"address":     hook.Hex(),
+		"flags":       fmt.Sprintf("0x%04x", hooks.Flags(hook)),
+		"permissions": permissions,
+		"callbacks":   permissions.Callbacks(),
+	}
+}
+
+// poolKeyValidity reports whether a hook is valid in a pool key with fee.
+func poolKeyValidity(hook common.Address, fee int64) gin.H {
+	if err := hooks.ValidatePoolKey(hook, big.NewInt(fee)); err != nil {
+		return gin.H{"valid": false, "error": err.Error()}
+	}
+	return gin.H{"valid": true}
+}
+
+// DescribeHook decodes the permissions of a hook address, the configured
+// hook when none is given. For a deployed hook that declares its
+// permissions with getHookPermissions, it also reports whether the address
+// matches them.
+func DescribeHook(c *gin.Context) {
+	hook := ethereum.HookAddress
+	if value := c.Query("address"); value != "" {
+		address, err := parseAddressField(value, "address")
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		hook = address
+	}
+
+	response := hookJSON(hook)
+	response["staticFee"] = poolKeyValidity(hook, 3000)
+	response["dynamicFee"] = poolKeyValidity(hook, ethereum.DynamicFeeFlag)
+
+	isContract, err := ethereum.IsContract(hook)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read hook code: %v", err)})
+		return
+	}
+	response["contract"] = isContract
+	if isContract {
+		// Hooks that are not BaseHooks need not declare their permissions
+		if declared, err := ethereum.GetHookPermissions(hook); err == nil {
+			response["declared"] = declared
+			response["declaredMatchesAddress"] = declared.Flags() == hooks.Flags(hook)
+		}
+	}
+	c.JSON(200, response)
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/signer"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -18,16 +21,17 @@ func Initialize(c *gin.Context) {
 	var req struct {
 		Currency0 common.Address `json:"currency0" binding:"required"`
 		Currency1 common.Address `json:"currency1" binding:"required"`
+		PoolFee
+		HookDataOption
+		TxOptions
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-
-	auth, err := createTransactor()
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create transactor: %v", err)})
+	exported, handled := req.exported(c)
+	if handled {
 		return
 	}
 
@@ -35,19 +39,50 @@ func Initialize(c *gin.Context) {
 	sqrtPrice1To1, _ := new(big.Int).SetString("79228162514264337593543950336", 10)
 	currency0 := req.Currency0
 	currency1 := req.Currency1
-	poolKey := createPoolKey(req.Currency0, req.Currency1, ethereum.HookAddress)
+	poolKey := req.poolKey(req.Currency0, req.Currency1)
+	// Catch keys the PoolManager rejects before paying for the revert
+	if status, err := checkPoolKeyHook(poolKey); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	// The PoolManager reverts with PoolAlreadyInitialized. Pools the
+	// registry has yet to sync are left to that revert.
+	if pool, ok, err := ethereum.FindPool(poolKey.ID()); err != nil && !errors.Is(err, ethereum.ErrPoolsNotSynced) {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pools: %v", err)})
+		return
+	} else if ok {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("pool %s was already initialized in block %d", pool.ID.Hex(), pool.Block)})
+		return
+	}
 
 	log.Printf("Adding liquidity with the following parameters:")
 	log.Printf("Currency0: %s", currency0.Hex())
 	log.Printf("Currency1: %s", currency1.Hex())
 	log.Printf("poolKey: %s", poolKey)
 
-	initData, err := ethereum.ManagerABI.Pack("initialize", poolKey, sqrtPrice1To1, []byte{})
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.Initialize)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	initData, err := ethereum.ManagerABI.Pack("initialize", poolKey, sqrtPrice1To1, hookData)
 	if err != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack initialize data: %v", err)})
 		return
 	}
 
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.ManagerAddress, gasLimit: 500000, data: initData}})
+		return
+	}
+
+	auth, err := createTransactor()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create transactor: %v", err)})
+		return
+	}
+
 	tx := types.NewTransaction(auth.Nonce.Uint64(), ethereum.ManagerAddress, big.NewInt(0), 500000, auth.GasPrice, initData)
 	signedTx, err := auth.Signer(auth.From, tx)
 	if err != nil {
@@ -64,6 +99,9 @@ func Initialize(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"initializeTxHash": signedTx.Hash().Hex(),
 		"status":           "Pool initialized successfully",
+		"poolId":           poolKey.ID().Hex(),
+		"fee":              poolKey.Fee.String(),
+		"hook":             hookJSON(poolKey.Hooks),
 	})
 }
 
@@ -73,10 +111,7 @@ func createTransactor() (*bind.TransactOpts, error) {
 		return nil, err
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(ethereum.PrivateKey, chainID)
-	if err != nil {
-		return nil, err
-	}
+	auth := signer.NewTransactor(ethereum.Signer, chainID)
 
 	nonce, err := ethereum.Client.PendingNonceAt(context.Background(), auth.From)
 	if err != nil {
@@ -93,20 +128,8 @@ func createTransactor() (*bind.TransactOpts, error) {
 	return auth, nil
 }
 
-func createPoolKey(token0, token1 common.Address, hook common.Address) struct {
-	Currency0   common.Address
-	Currency1   common.Address
-	Fee         *big.Int
-	TickSpacing *big.Int
-	Hooks       common.Address
-} {
-	return struct {
-		Currency0   common.Address
-		Currency1   common.Address
-		Fee         *big.Int
-		TickSpacing *big.Int
-		Hooks       common.Address
-	}{
+func createPoolKey(token0, token1 common.Address, hook common.Address) ethereum.PoolKey {
+	return ethereum.PoolKey{
 		Currency0:   token0,
 		Currency1:   token1,
 		Fee:         big.NewInt(3000), // harcoded fee, need to adjust as needed
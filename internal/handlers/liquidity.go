@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// parseAmount parses a non-negative raw token amount, treating "" as zero.
+func parseAmount(value, name string) (*big.Int, error) {
+	if value == "" {
+		return new(big.Int), nil
+	}
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return amount, nil
+}
+
+// amountField is a named raw amount from a request.
+type amountField struct{ value, name string }
+
+// parseAmounts parses each field with parseAmount.
+func parseAmounts(fields ...amountField) ([]*big.Int, error) {
+	amounts := make([]*big.Int, len(fields))
+	for i, field := range fields {
+		amount, err := parseAmount(field.value, field.name)
+		if err != nil {
+			return nil, err
+		}
+		amounts[i] = amount
+	}
+	return amounts, nil
+}
+
+// sizing is the liquidity desired amounts buy in a range at the current
+// price, with the amounts it costs rounded up.
+type sizing struct {
+	liquidity *big.Int
+	amount0   *big.Int
+	amount1   *big.Int
+	slot0     *ethereum.Slot0
+}
+
+// sizeLiquidity sizes a deposit of up to amount0Desired and amount1Desired
+// over [tickLower, tickUpper]. The status is the HTTP status to report with
+// the error.
+func sizeLiquidity(poolKey ethereum.PoolKey, tickLower, tickUpper int, amount0Desired, amount1Desired *big.Int) (*sizing, int, error) {
+	if amount0Desired.Sign() == 0 && amount1Desired.Sign() == 0 {
+		return nil, 400, fmt.Errorf("amount0Desired or amount1Desired is required")
+	}
+	slot0, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		return nil, 500, fmt.Errorf("failed to read pool state: %v", err)
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		return nil, 400, fmt.Errorf("pool is not initialized")
+	}
+
+	sqrtPriceLower, _ := v4math.GetSqrtPriceAtTick(tickLower)
+	sqrtPriceUpper, _ := v4math.GetSqrtPriceAtTick(tickUpper)
+	liquidity, err := v4math.GetLiquidityForAmounts(slot0.SqrtPriceX96, sqrtPriceLower, sqrtPriceUpper, amount0Desired, amount1Desired)
+	if err != nil {
+		return nil, 400, err
+	}
+	if liquidity.Sign() == 0 {
+		return nil, 400, fmt.Errorf("desired amounts are too small to add liquidity in this range at the current price")
+	}
+	amount0, amount1 := v4math.GetAmountsForLiquidity(slot0.SqrtPriceX96, sqrtPriceLower, sqrtPriceUpper, liquidity, true)
+	return &sizing{liquidity: liquidity, amount0: amount0, amount1: amount1, slot0: slot0}, 200, nil
+}
+
+// resolveTickRange picks the position's ticks from explicit ticks, which must
+// be multiples of tickSpacing, or from price bounds, which are widened to the
+// enclosing usable ticks. Missing bounds default to the full range.
+func resolveTickRange(tickLower, tickUpper *int, priceLower, priceUpper string, tickSpacing int) (int, int, error) {
+	if tickLower != nil && priceLower != "" {
+		return 0, 0, fmt.Errorf("provide tickLower or priceLower, not both")
+	}
+	if tickUpper != nil && priceUpper != "" {
+		return 0, 0, fmt.Errorf("provide tickUpper or priceUpper, not both")
+	}
+	minTick, maxTick := v4math.MinUsableTick(tickSpacing), v4math.MaxUsableTick(tickSpacing)
+
+	lower := minTick
+	switch {
+	case tickLower != nil:
+		lower = *tickLower
+	case priceLower != "":
+		tick, err := v4math.TickFromPrice(priceLower)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid priceLower: %v", err)
+		}
+		lower = max(v4math.FloorTick(tick, tickSpacing), minTick)
+	}
+
+	upper := maxTick
+	switch {
+	case tickUpper != nil:
+		upper = *tickUpper
+	case priceUpper != "":
+		tick, err := v4math.TickFromPrice(priceUpper)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid priceUpper: %v", err)
+		}
+		upper = min(v4math.CeilTick(tick, tickSpacing), maxTick)
+	}
+
+	for _, tick := range []int{lower, upper} {
+		if tick%tickSpacing != 0 {
+			return 0, 0, fmt.Errorf("tick %d is not a multiple of tickSpacing %d", tick, tickSpacing)
+		}
+		if tick < minTick || tick > maxTick {
+			return 0, 0, fmt.Errorf("tick %d is outside the usable range [%d, %d]", tick, minTick, maxTick)
+		}
+	}
+	if lower >= upper {
+		return 0, 0, fmt.Errorf("tickLower %d must be below tickUpper %d", lower, upper)
+	}
+	return lower, upper, nil
+}
+
+// checkMinAmounts enforces the caller's slippage limits on the amounts a
+// liquidity change moves.
+func checkMinAmounts(amount0, amount1, amount0Min, amount1Min *big.Int) error {
+	if amount0.Cmp(amount0Min) < 0 {
+		return fmt.Errorf("amount0 %s is below amount0Min %s", amount0.String(), amount0Min.String())
+	}
+	if amount1.Cmp(amount1Min) < 0 {
+		return fmt.Errorf("amount1 %s is below amount1Min %s", amount1.String(), amount1Min.String())
+	}
+	return nil
+}
+
+// simulateModifyLiquidity runs a router call to method with eth_call from
+// sender and returns the balance delta it produces, including any hook
+// adjustments.
+func simulateModifyLiquidity(sender common.Address, method string, data []byte, value *big.Int) (*big.Int, *big.Int, error) {
+	out, err := ethereum.Client.CallContract(context.Background(), goethereum.CallMsg{From: sender, To: &ethereum.LPRouterAddress, Value: value, Data: data}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := ethereum.LPRouterABI.Unpack(method, out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s result: %v", method, err)
+	}
+	amount0, amount1 := ethereum.DecodeBalanceDelta(values[0].(*big.Int))
+	return amount0, amount1, nil
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4actions"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// nativeValue returns the msg.value to attach when paying amount of
+// currency: amount for native ETH, zero for a token.
+func nativeValue(currency common.Address, amount *big.Int) *big.Int {
+	if !ethereum.IsNative(currency) {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(amount)
+}
+
+// rejectNative fails for native currencies on routes that pay with ERC-2612
+// permits, which ETH does not have.
+func rejectNative(currencies ...common.Address) error {
+	for _, currency := range currencies {
+		if ethereum.IsNative(currency) {
+			return fmt.Errorf("native ETH has no permit, use the route without a permit")
+		}
+	}
+	return nil
+}
+
+// nativeRefund accounts for the ETH sent with a mined transaction: how much
+// the pool kept and how much the router refunded. before is the sender's ETH
+// balance before the transaction, and the gas it paid is left out.
+func nativeRefund(sender common.Address, before, value *big.Int, receipt *types.Receipt) (gin.H, error) {
+	after, err := ethereum.NativeCurrency{}.BalanceOf(sender)
+	if err != nil {
+		return nil, err
+	}
+	gasFee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	spent := new(big.Int).Sub(before, after)
+	spent.Sub(spent, gasFee)
+	return gin.H{
+		"value":    value.String(),
+		"spent":    spent.String(),
+		"refunded": new(big.Int).Sub(value, spent).String(),
+		"gasFee":   gasFee.String(),
+	}, nil
+}
+
+// swapValue returns the msg.value of a swap paying in currencyIn: the exact
+// input, or for an exact output its input limit, which is required.
+func swapValue(currencyIn common.Address, amountSpecified, limit *big.Int) (*big.Int, error) {
+	if !ethereum.IsNative(currencyIn) {
+		return big.NewInt(0), nil
+	}
+	if amountSpecified.Sign() < 0 {
+		return new(big.Int).Neg(amountSpecified), nil
+	}
+	if limit == nil {
+		return nil, fmt.Errorf("amountLimit is required to pay an exact output in native ETH")
+	}
+	return new(big.Int).Set(limit), nil
+}
+
+// sendAndWaitNative is sendAndWait for a call that may carry ETH. When it
+// does, it also returns the nativeRefund accounting of the server account.
+func sendAndWaitNative(call txCall) (*types.Transaction, *types.Receipt, gin.H, error) {
+	if call.value == nil || call.value.Sign() == 0 {
+		tx, receipt, err := sendAndWait(call)
+		return tx, receipt, nil, err
+	}
+	sender := ethereum.Signer.Address()
+	before, err := ethereum.NativeCurrency{}.BalanceOf(sender)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read ETH balance: %v", err)
+	}
+	tx, receipt, err := sendAndWait(call)
+	if err != nil {
+		return tx, receipt, nil, err
+	}
+	native, err := nativeRefund(sender, before, call.value, receipt)
+	if err != nil {
+		return tx, receipt, nil, fmt.Errorf("failed to account for the ETH sent: %v", err)
+	}
+	return tx, receipt, native, nil
+}
+
+// sweepNative returns the msg.value of a PositionManager plan paying up to
+// amount0Max of currency0. For native ETH it also sweeps what the plan
+// leaves back to the sender, since the PositionManager keeps excess ETH.
+func sweepNative(plan *v4actions.Plan, currency0 common.Address, amount0Max *big.Int) *big.Int {
+	value := nativeValue(currency0, amount0Max)
+	if value.Sign() > 0 {
+		plan.Sweep(currency0, v4actions.MsgSender)
+	}
+	return value
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/signer"
+	"uniswap-v4-rpc/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// PermitAuth is how the permit routes get the owner's permit signatures:
+// either the owner's key, which the server signs with, or signatures made by
+// the owner over the permit the server describes.
+type PermitAuth struct {
+	PrivateKey string `json:"privateKey"`
+	// Deadline is the unix time the caller signed; required with signatures
+	Deadline string `json:"deadline"`
+}
+
+// permitSigner returns the signer for the owner's key, or nil when the
+// caller supplies signatures instead.
+func (a PermitAuth) permitSigner(signatures ...string) (signer.Signer, error) {
+	supplied := false
+	for _, sig := range signatures {
+		if sig != "" {
+			supplied = true
+		}
+	}
+	switch {
+	case a.PrivateKey != "" && supplied:
+		return nil, fmt.Errorf("provide either privateKey or permit signatures, not both")
+	case a.PrivateKey != "":
+		userSigner, err := signer.NewLocalSigner(a.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		return userSigner, nil
+	case !supplied:
+		return nil, fmt.Errorf("privateKey or permit signatures are required")
+	}
+	for _, sig := range signatures {
+		if sig == "" {
+			return nil, fmt.Errorf("a permit signature is required for every token")
+		}
+	}
+	if a.Deadline == "" {
+		return nil, fmt.Errorf("deadline is required with permit signatures")
+	}
+	return nil, nil
+}
+
+// deadline returns the caller's deadline, or fallback when none was given.
+func (a PermitAuth) deadline(fallback *big.Int) (*big.Int, error) {
+	if a.Deadline == "" {
+		return fallback, nil
+	}
+	deadline, ok := new(big.Int).SetString(a.Deadline, 10)
+	if !ok || deadline.Sign() < 0 {
+		return nil, fmt.Errorf("invalid deadline %q", a.Deadline)
+	}
+	return deadline, nil
+}
+
+// requireEOAOwner refuses permits of contract wallets, which are not
+// supported: the routers and tokens check permits with ecrecover.
+func requireEOAOwner(owner common.Address) error {
+	isContract, err := ethereum.IsContract(owner)
+	if err != nil {
+		return fmt.Errorf("failed to fetch owner code: %v", err)
+	}
+	if isContract {
+		return fmt.Errorf("%w: %s is a contract wallet, and the routers only accept permits signed by an EOA", utils.ErrPermitNotRelayable, owner.Hex())
+	}
+	return nil
+}
+
+// ownerPermit signs the permit with userSigner, or when it is nil attaches
+// the caller's hex signature. Contract wallet owners are refused.
+func ownerPermit(token, owner, spender common.Address, value, deadline *big.Int, userSigner signer.Signer, signature string) (*utils.Permit, error) {
+	if err := requireEOAOwner(owner); err != nil {
+		return nil, err
+	}
+	if userSigner != nil {
+		return utils.GeneratePermitSignature(token, owner, spender, value, deadline, userSigner)
+	}
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature hex: %v", utils.ErrPermitSignerMismatch, err)
+	}
+	return utils.PermitWithSignature(token, owner, spender, value, deadline, sig)
+}
+
+// permitErrorStatus maps permit validation failures to 400 since they are
+// caused by the caller's input; anything else is a server side failure.
+func permitErrorStatus(err error) int {
+	if utils.IsPermitError(err) {
+		return 400
+	}
+	return 500
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/pools"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+func poolJSON(pool pools.Pool) gin.H {
+	return gin.H{
+		"poolId":       pool.ID.Hex(),
+		"currency0":    pool.Currency0.Hex(),
+		"currency1":    pool.Currency1.Hex(),
+		"fee":          pool.Fee.String(),
+		"dynamicFee":   ethereum.IsDynamicFee(pool.Fee),
+		"tickSpacing":  pool.TickSpacing.String(),
+		"hooks":        pool.Hooks.Hex(),
+		"sqrtPriceX96": pool.SqrtPriceX96.String(),
+		"tick":         pool.Tick,
+		"block":        pool.Block,
+		"txHash":       pool.TxHash.Hex(),
+	}
+}
+
+// poolsErrorStatus maps a pool registry read before its first sync to 503
+// and anything else to 500.
+func poolsErrorStatus(err error) int {
+	if errors.Is(err, ethereum.ErrPoolsNotSynced) {
+		return 503
+	}
+	return 500
+}
+
+// poolFilter parses the optional address query parameters of ListPools.
+func poolFilter(c *gin.Context) (pools.Filter, error) {
+	var filter pools.Filter
+	for _, field := range []struct {
+		name  string
+		value *common.Address
+	}{
+		{"currency", &filter.Currency},
+		{"currency0", &filter.Currency0},
+		{"currency1", &filter.Currency1},
+	} {
+		if value := c.Query(field.name); value != "" {
+			address, err := parseAddressField(value, field.name)
+			if err != nil {
+				return filter, err
+			}
+			*field.value = address
+		}
+	}
+	// hook=0x0000000000000000000000000000000000000000 selects pools without
+	// a hook, so it is told apart from no filter
+	if value, ok := c.GetQuery("hook"); ok {
+		hook, err := parseAddressField(value, "hook")
+		if err != nil {
+			return filter, err
+		}
+		filter.Hooks = &hook
+	}
+	return filter, nil
+}
+
+// ListPools returns the pools the PoolManager has initialized, optionally
+// those with currency on either side, currency0, currency1 or hook.
+func ListPools(c *gin.Context) {
+	filter, err := poolFilter(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := ethereum.ListPools(filter)
+	if err != nil {
+		c.JSON(poolsErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to read pools: %v", err)})
+		return
+	}
+	response := make([]gin.H, len(list))
+	for i, pool := range list {
+		response[i] = poolJSON(pool)
+	}
+	synced, hash, _ := ethereum.Pools.Synced()
+	c.JSON(200, gin.H{"pools": response, "count": len(response), "syncedBlock": synced, "syncedBlockHash": hash.Hex()})
+}
+
+// GetPool returns a pool by PoolId, as it was initialized and as it is now.
+func GetPool(c *gin.Context) {
+	value := c.Param("poolId")
+	if len(common.FromHex(value)) != common.HashLength {
+		c.JSON(400, gin.H{"error": "poolId must be a 32-byte hex string"})
+		return
+	}
+	pool, ok, err := ethereum.FindPool(common.HexToHash(value))
+	if err != nil {
+		c.JSON(poolsErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to read pools: %v", err)})
+		return
+	}
+	if !ok {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("pool %s is not initialized", value)})
+		return
+	}
+
+	slot0, err := ethereum.GetSlot0(pool.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	liquidity, err := ethereum.GetLiquidity(pool.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool liquidity: %v", err)})
+		return
+	}
+	response := poolJSON(pool)
+	response["current"] = gin.H{
+		"sqrtPriceX96": slot0.SqrtPriceX96.String(),
+		"tick":         slot0.Tick,
+		"lpFee":        slot0.LPFee,
+		"protocolFee":  slot0.ProtocolFee,
+		"liquidity":    liquidity.String(),
+	}
+	c.JSON(200, response)
+}
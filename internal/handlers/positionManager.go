@@ -0,0 +1,602 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/pkg/v4actions"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// requirePositionManager writes an error when no PositionManager is
+// configured and reports whether the request can go on.
+func requirePositionManager(c *gin.Context) bool {
+	if !ethereum.HasPositionManager() {
+		c.JSON(503, gin.H{"error": "PositionManager is not configured, set position_manager_address and permit2_address"})
+		return false
+	}
+	return true
+}
+
+// parseTokenID parses a position NFT id.
+func parseTokenID(value string) (*big.Int, error) {
+	tokenID, ok := new(big.Int).SetString(value, 10)
+	if !ok || tokenID.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid tokenId %q", value)
+	}
+	return tokenID, nil
+}
+
+// actionDeadline parses the unix deadline for modifyLiquidities, defaulting
+// to an hour from now.
+func actionDeadline(value string) (*big.Int, error) {
+	if value == "" {
+		return big.NewInt(time.Now().Unix() + 3600), nil
+	}
+	deadline, ok := new(big.Int).SetString(value, 10)
+	if !ok || deadline.Sign() < 0 {
+		return nil, fmt.Errorf("invalid deadline %q", value)
+	}
+	return deadline, nil
+}
+
+// recipientOrSender returns the given recipient, or the address the
+// PositionManager maps to the transaction sender.
+func recipientOrSender(value string) (common.Address, error) {
+	if value == "" {
+		return v4actions.MsgSender, nil
+	}
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("invalid recipient %q", value)
+	}
+	return common.HexToAddress(value), nil
+}
+
+// packModifyLiquidities encodes plan as a modifyLiquidities call.
+func packModifyLiquidities(plan *v4actions.Plan, deadline *big.Int) ([]byte, error) {
+	unlockData, err := plan.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return ethereum.PositionManagerABI.Pack("modifyLiquidities", unlockData, deadline)
+}
+
+// sendAndWait simulates call from the server account, sends it and waits
+// for the receipt, so the caller can read its events.
+func sendAndWait(call txCall) (*types.Transaction, *types.Receipt, error) {
+	ctx := context.Background()
+	auth, err := createTransactor()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create transactor: %v", err)
+	}
+	value := call.value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	// Simulate first so a revert is reported instead of paying for it
+	if _, err := ethereum.Client.CallContract(ctx, goethereum.CallMsg{From: auth.From, To: &call.to, Value: value, Data: call.data}, nil); err != nil {
+		return nil, nil, fmt.Errorf("simulation failed: %v", err)
+	}
+
+	tx := types.NewTransaction(auth.Nonce.Uint64(), call.to, value, call.gasLimit, auth.GasPrice, call.data)
+	signedTx, err := auth.Signer(auth.From, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := ethereum.Client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, nil, fmt.Errorf("failed to send transaction: %v", err)
+	}
+	receipt, err := bind.WaitMined(ctx, ethereum.Client, signedTx)
+	if err != nil {
+		return signedTx, nil, fmt.Errorf("failed to wait for transaction: %v", err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return signedTx, receipt, fmt.Errorf("transaction %s reverted", signedTx.Hash().Hex())
+	}
+	return signedTx, receipt, nil
+}
+
+// positionNFT is a position held by the PositionManager.
+type positionNFT struct {
+	tokenID   *big.Int
+	config    *ethereum.PositionConfig
+	liquidity *big.Int
+}
+
+// loadPositionNFT reads a position NFT's config and liquidity.
+func loadPositionNFT(value string) (*positionNFT, int, error) {
+	tokenID, err := parseTokenID(value)
+	if err != nil {
+		return nil, 400, err
+	}
+	config, err := ethereum.GetPositionConfig(tokenID)
+	if err != nil {
+		return nil, 404, err
+	}
+	liquidity, err := ethereum.GetPositionLiquidity(tokenID, config)
+	if err != nil {
+		return nil, 500, fmt.Errorf("failed to read position liquidity: %v", err)
+	}
+	return &positionNFT{tokenID: tokenID, config: config, liquidity: liquidity}, 200, nil
+}
+
+func (p *positionNFT) ticks() (int, int) {
+	return int(p.config.TickLower.Int64()), int(p.config.TickUpper.Int64())
+}
+
+// MintPosition mints a PositionManager position NFT to recipient, the
+// sender by default. The sender pays through Permit2.
+func MintPosition(c *gin.Context) {
+	var req struct {
+		Currency0      common.Address `json:"currency0" binding:"required"`
+		Currency1      common.Address `json:"currency1" binding:"required"`
+		TickLower      *int           `json:"tickLower"`
+		TickUpper      *int           `json:"tickUpper"`
+		PriceLower     string         `json:"priceLower"`
+		PriceUpper     string         `json:"priceUpper"`
+		Amount0Desired string         `json:"amount0Desired"`
+		Amount1Desired string         `json:"amount1Desired"`
+		// Most of each currency the mint may take, the desired amounts by
+		// default
+		Amount0Max string `json:"amount0Max"`
+		Amount1Max string `json:"amount1Max"`
+		Recipient  string `json:"recipient"`
+		Deadline   string `json:"deadline"`
+		PoolFee
+		HookDataOption
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !requirePositionManager(c) {
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	amounts, err := parseAmounts(
+		amountField{req.Amount0Desired, "amount0Desired"},
+		amountField{req.Amount1Desired, "amount1Desired"},
+		amountField{req.Amount0Max, "amount0Max"},
+		amountField{req.Amount1Max, "amount1Max"},
+	)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	amount0Max, amount1Max := amounts[2], amounts[3]
+	if req.Amount0Max == "" {
+		amount0Max = amounts[0]
+	}
+	if req.Amount1Max == "" {
+		amount1Max = amounts[1]
+	}
+	recipient, err := recipientOrSender(req.Recipient)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	deadline, err := actionDeadline(req.Deadline)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	poolKey := req.poolKey(req.Currency0, req.Currency1)
+	tickLower, tickUpper, err := resolveTickRange(req.TickLower, req.TickUpper, req.PriceLower, req.PriceUpper, int(poolKey.TickSpacing.Int64()))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sized, status, err := sizeLiquidity(poolKey, tickLower, tickUpper, amounts[0], amounts[1])
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.AddLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	config := ethereum.PositionConfig{PoolKey: poolKey, TickLower: big.NewInt(int64(tickLower)), TickUpper: big.NewInt(int64(tickUpper))}
+	plan := new(v4actions.Plan).
+		MintPosition(config, sized.liquidity, amount0Max, amount1Max, recipient, hookData).
+		SettlePair(poolKey.Currency0, poolKey.Currency1)
+	value := sweepNative(plan, poolKey.Currency0, amount0Max)
+	data, err := packModifyLiquidities(plan, deadline)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.PositionManagerAddress, value: value, gasLimit: 1000000, data: data}
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, receipt, native, err := sendAndWaitNative(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	tokenIDs := ethereum.MintedTokenIDs(receipt)
+	if len(tokenIDs) == 0 {
+		c.JSON(500, gin.H{"error": "No MintPosition event in the receipt"})
+		return
+	}
+	owner, err := ethereum.GetPositionOwner(tokenIDs[0])
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read position owner: %v", err)})
+		return
+	}
+
+	response := gin.H{
+		"status":  "Position minted successfully",
+		"txHash":  signedTx.Hash().Hex(),
+		"tokenId": tokenIDs[0].String(),
+		"owner":   owner.Hex(),
+		"params": gin.H{
+			"currency0": poolKey.Currency0.Hex(),
+			"currency1": poolKey.Currency1.Hex(),
+			"tickLower": tickLower,
+			"tickUpper": tickUpper,
+			"liquidity": sized.liquidity.String(),
+			"amount0":   sized.amount0.String(),
+			"amount1":   sized.amount1.String(),
+		},
+	}
+	if native != nil {
+		response["native"] = native
+	}
+	c.JSON(200, response)
+}
+
+// IncreasePosition adds liquidity to a position NFT. Fees the position has
+// earned are used first, so currencies are closed rather than settled.
+func IncreasePosition(c *gin.Context) {
+	var req struct {
+		TokenID        string `json:"tokenId" binding:"required"`
+		Amount0Desired string `json:"amount0Desired"`
+		Amount1Desired string `json:"amount1Desired"`
+		Amount0Max     string `json:"amount0Max"`
+		Amount1Max     string `json:"amount1Max"`
+		Deadline       string `json:"deadline"`
+		HookDataOption
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !requirePositionManager(c) {
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	amounts, err := parseAmounts(
+		amountField{req.Amount0Desired, "amount0Desired"},
+		amountField{req.Amount1Desired, "amount1Desired"},
+		amountField{req.Amount0Max, "amount0Max"},
+		amountField{req.Amount1Max, "amount1Max"},
+	)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	amount0Max, amount1Max := amounts[2], amounts[3]
+	if req.Amount0Max == "" {
+		amount0Max = amounts[0]
+	}
+	if req.Amount1Max == "" {
+		amount1Max = amounts[1]
+	}
+	deadline, err := actionDeadline(req.Deadline)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	nft, status, err := loadPositionNFT(req.TokenID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	tickLower, tickUpper := nft.ticks()
+	sized, status, err := sizeLiquidity(nft.config.PoolKey, tickLower, tickUpper, amounts[0], amounts[1])
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	poolKey := nft.config.PoolKey
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.AddLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	plan := new(v4actions.Plan).
+		IncreaseLiquidity(nft.tokenID, *nft.config, sized.liquidity, amount0Max, amount1Max, hookData).
+		CloseCurrency(poolKey.Currency0).
+		CloseCurrency(poolKey.Currency1)
+	value := sweepNative(plan, poolKey.Currency0, amount0Max)
+	data, err := packModifyLiquidities(plan, deadline)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.PositionManagerAddress, value: value, gasLimit: 1000000, data: data}
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, _, native, err := sendAndWaitNative(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	response := gin.H{
+		"status":  "Position increased successfully",
+		"txHash":  signedTx.Hash().Hex(),
+		"tokenId": nft.tokenID.String(),
+		"params": gin.H{
+			"liquidityAdded": sized.liquidity.String(),
+			"amount0":        sized.amount0.String(),
+			"amount1":        sized.amount1.String(),
+		},
+	}
+	if native != nil {
+		response["native"] = native
+	}
+	c.JSON(200, response)
+}
+
+// DecreasePosition removes liquidity from a position NFT and sends the
+// proceeds, with its fees, to recipient. With burn the position is emptied
+// and its NFT burned.
+func DecreasePosition(c *gin.Context) {
+	var req struct {
+		TokenID string `json:"tokenId" binding:"required"`
+		// Either an amount of liquidity or a percentage of the position.
+		// Zero liquidity only collects fees.
+		Liquidity  string  `json:"liquidity"`
+		Percentage float64 `json:"percentage"`
+		Burn       bool    `json:"burn"`
+		Amount0Min string  `json:"amount0Min"`
+		Amount1Min string  `json:"amount1Min"`
+		Recipient  string  `json:"recipient"`
+		Deadline   string  `json:"deadline"`
+		HookDataOption
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !requirePositionManager(c) {
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	if req.Burn && (req.Liquidity != "" || req.Percentage != 0) {
+		c.JSON(400, gin.H{"error": "burn removes all liquidity, omit liquidity and percentage"})
+		return
+	}
+	if !req.Burn && req.Liquidity != "" && req.Percentage != 0 {
+		c.JSON(400, gin.H{"error": "provide either liquidity or percentage"})
+		return
+	}
+	if req.Percentage < 0 || req.Percentage > 100 {
+		c.JSON(400, gin.H{"error": "percentage must be between 0 and 100"})
+		return
+	}
+	amounts, err := parseAmounts(
+		amountField{req.Liquidity, "liquidity"},
+		amountField{req.Amount0Min, "amount0Min"},
+		amountField{req.Amount1Min, "amount1Min"},
+	)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	amount0Min, amount1Min := amounts[1], amounts[2]
+	recipient, err := recipientOrSender(req.Recipient)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	deadline, err := actionDeadline(req.Deadline)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	nft, status, err := loadPositionNFT(req.TokenID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	liquidity := amounts[0]
+	switch {
+	case req.Burn:
+		liquidity = nft.liquidity
+	case req.Percentage != 0:
+		// Percentage to basis points so the split is exact integer math
+		liquidity = new(big.Int).Mul(nft.liquidity, big.NewInt(int64(req.Percentage*100+0.5)))
+		liquidity.Div(liquidity, big.NewInt(10000))
+	}
+	if liquidity.Cmp(nft.liquidity) > 0 {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("liquidity %s exceeds the position's %s", liquidity.String(), nft.liquidity.String())})
+		return
+	}
+
+	poolKey := nft.config.PoolKey
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.RemoveLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	plan := new(v4actions.Plan)
+	if req.Burn {
+		plan.BurnPosition(nft.tokenID, *nft.config, amount0Min, amount1Min, hookData)
+	} else {
+		plan.DecreaseLiquidity(nft.tokenID, *nft.config, liquidity, amount0Min, amount1Min, hookData)
+	}
+	plan.TakePair(poolKey.Currency0, poolKey.Currency1, recipient)
+	data, err := packModifyLiquidities(plan, deadline)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.PositionManagerAddress, gasLimit: 1000000, data: data}
+
+	// Principal at the current price, fees come on top
+	slot0, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	tickLower, tickUpper := nft.ticks()
+	sqrtPriceLower, _ := v4math.GetSqrtPriceAtTick(tickLower)
+	sqrtPriceUpper, _ := v4math.GetSqrtPriceAtTick(tickUpper)
+	principal0, principal1 := v4math.GetAmountsForLiquidity(slot0.SqrtPriceX96, sqrtPriceLower, sqrtPriceUpper, liquidity, false)
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, _, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status":           "Position decreased successfully",
+		"txHash":           signedTx.Hash().Hex(),
+		"tokenId":          nft.tokenID.String(),
+		"burned":           req.Burn,
+		"liquidityRemoved": liquidity.String(),
+		"principal":        gin.H{"currency0": principal0.String(), "currency1": principal1.String()},
+	})
+}
+
+// GetPositionNFT returns the owner, pool, range and liquidity of a position
+// NFT.
+func GetPositionNFT(c *gin.Context) {
+	if !requirePositionManager(c) {
+		return
+	}
+	nft, status, err := loadPositionNFT(c.Param("tokenId"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	owner, err := ethereum.GetPositionOwner(nft.tokenID)
+	if err != nil {
+		// Burned positions have no owner
+		c.JSON(404, gin.H{"error": fmt.Sprintf("position %s has no owner: %v", nft.tokenID.String(), err)})
+		return
+	}
+	tickLower, tickUpper := nft.ticks()
+	c.JSON(200, gin.H{
+		"tokenId":   nft.tokenID.String(),
+		"owner":     owner.Hex(),
+		"poolId":    nft.config.PoolKey.ID().Hex(),
+		"currency0": nft.config.PoolKey.Currency0.Hex(),
+		"currency1": nft.config.PoolKey.Currency1.Hex(),
+		"tickLower": tickLower,
+		"tickUpper": tickUpper,
+		"liquidity": nft.liquidity.String(),
+	})
+}
+
+// TransferPosition transfers a position NFT from the sender to another
+// account.
+func TransferPosition(c *gin.Context) {
+	var req struct {
+		TokenID string `json:"tokenId" binding:"required"`
+		To      string `json:"to" binding:"required"`
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !requirePositionManager(c) {
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	tokenID, err := parseTokenID(req.TokenID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(req.To) {
+		c.JSON(400, gin.H{"error": "Invalid to address"})
+		return
+	}
+	to := common.HexToAddress(req.To)
+
+	call := txCall{
+		to:       ethereum.PositionManagerAddress,
+		gasLimit: 150000,
+		buildData: func(sender common.Address) ([]byte, error) {
+			return ethereum.PositionManagerABI.Pack("transferFrom", sender, to, tokenID)
+		},
+	}
+	if exported {
+		// The NFT moves from the signer, which must be known
+		if mode, _ := req.mode(); mode == ModeUnsigned && req.From == "" {
+			c.JSON(400, gin.H{"error": "from is required to transfer a position in unsigned mode"})
+			return
+		}
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	call.data, err = call.buildData(ethereum.Signer.Address())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	signedTx, _, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status":  "Position transferred successfully",
+		"txHash":  signedTx.Hash().Hex(),
+		"tokenId": tokenID.String(),
+		"to":      to.Hex(),
+	})
+}
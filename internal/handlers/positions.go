@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/positions"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// PositionSalt picks the position liquidity is added to. A label records
+// the position in the registry, with a salt derived from the label unless
+// one is given.
+type PositionSalt struct {
+	Salt  string `json:"salt"`
+	Label string `json:"label"`
+}
+
+// registered returns the registry entry for the label, if any.
+func (p PositionSalt) registered() (positions.Position, bool) {
+	if p.Label == "" {
+		return positions.Position{}, false
+	}
+	return ethereum.Positions.Get(p.Label)
+}
+
+// position returns the salt for a position over [tickLower, tickUpper]
+// funded by depositor and the registry entry to record for it, nil without a
+// label. A labelled position only takes more liquidity from its depositor.
+func (p PositionSalt) position(poolKey ethereum.PoolKey, tickLower, tickUpper int, depositor common.Address) (common.Hash, *positions.Position, int, error) {
+	salt := common.HexToHash(p.Salt)
+	if p.Label == "" {
+		return salt, nil, 200, nil
+	}
+	if err := releaseStale(p.Label); err != nil {
+		return salt, nil, 500, err
+	}
+	if p.Salt == "" {
+		salt = positions.DeriveSalt(p.Label)
+		if existing, ok := p.registered(); ok {
+			salt = existing.Salt
+		}
+	}
+
+	entry := &positions.Position{
+		Label:     p.Label,
+		PoolID:    poolKey.ID(),
+		Currency0: poolKey.Currency0,
+		Currency1: poolKey.Currency1,
+		Fee:       uint32(poolKey.Fee.Uint64()),
+		TickLower: tickLower,
+		TickUpper: tickUpper,
+		Salt:      salt,
+		Depositor: depositor,
+	}
+	if err := ethereum.Positions.Check(*entry); err != nil {
+		return salt, nil, 409, err
+	}
+	if existing, ok := p.registered(); ok && existing.Depositor != depositor {
+		return salt, nil, 403, fmt.Errorf("position %q was deposited by %s", p.Label, existing.Depositor.Hex())
+	}
+	return salt, entry, 200, nil
+}
+
+// register records entry after liquidity was added to it.
+func register(entry *positions.Position) error {
+	if entry == nil {
+		return nil
+	}
+	return ethereum.Positions.Register(*entry)
+}
+
+// registerPending records entry before the deposit is mined, for
+// transactions the caller signs or a relayer has yet to mine. The entry is
+// confirmed once its liquidity is seen.
+func registerPending(entry *positions.Position) error {
+	if entry == nil {
+		return nil
+	}
+	pending := *entry
+	pending.Pending = true
+	return ethereum.Positions.Register(pending)
+}
+
+// settleRegistration confirms or releases a pending entry once its deposit
+// has a receipt, or nil when none arrived.
+func settleRegistration(label string) func(*types.Receipt) {
+	return func(receipt *types.Receipt) {
+		if receipt != nil && receipt.Status == types.ReceiptStatusSuccessful {
+			ethereum.Positions.Confirm(label)
+		} else {
+			ethereum.Positions.Release(label)
+		}
+	}
+}
+
+// releaseStale drops a pending entry under label whose deposit never added
+// liquidity, so an unsigned request that was never sent does not hold the
+// label.
+func releaseStale(label string) error {
+	entry, ok := ethereum.Positions.Get(label)
+	if !ok || !entry.Pending {
+		return nil
+	}
+	p, _, err := PositionRef{Label: label}.locate()
+	if err == nil {
+		err = p.read()
+	}
+	if err != nil {
+		return err
+	}
+	if p.info.Liquidity.Sign() == 0 {
+		ethereum.Positions.Release(label)
+	}
+	return nil
+}
+
+// PositionRef identifies a router owned position, either by label or by
+// currencies, fee, ticks and salt. A label carries its pool's fee.
+type PositionRef struct {
+	Label     string         `json:"label"`
+	Currency0 common.Address `json:"currency0"`
+	Currency1 common.Address `json:"currency1"`
+	PoolFee
+	// Owner of the position in the PoolManager. Positions added through the
+	// router are owned by the router, which is the default.
+	Owner     string `json:"owner"`
+	TickLower *int   `json:"tickLower"`
+	TickUpper *int   `json:"tickUpper"`
+	Salt      string `json:"salt"`
+}
+
+// depositedBy returns the reference as depositor sees it through the permit
+// routes: a labelled position must have been deposited by them, and a salt
+// is bound to them as it was on deposit. The status is the HTTP status to
+// report with the error.
+func (ref PositionRef) depositedBy(depositor common.Address) (PositionRef, int, error) {
+	if ref.Label == "" {
+		ref.Salt = positions.DepositorSalt(depositor, common.HexToHash(ref.Salt)).Hex()
+		return ref, 200, nil
+	}
+	entry, ok := ethereum.Positions.Get(ref.Label)
+	if !ok {
+		return ref, 404, fmt.Errorf("no position is registered as %q", ref.Label)
+	}
+	if entry.Depositor != depositor {
+		return ref, 403, fmt.Errorf("position %q was not deposited by %s", ref.Label, depositor.Hex())
+	}
+	return ref, 200, nil
+}
+
+// depositedByServer checks that the reference is not a permit deposit, so
+// the routes that pay out to the server account only reach what it
+// deposited. A labelled position must have been deposited by the server
+// signer, and a salt must not be one recorded for a permit deposit. The
+// status is the HTTP status to report with the error.
+func (ref PositionRef) depositedByServer() (int, error) {
+	server := ethereum.Signer.Address()
+	if ref.Label == "" {
+		salt := common.HexToHash(ref.Salt)
+		if depositor, ok := ethereum.Positions.Depositor(salt); ok {
+			return 403, fmt.Errorf("position with salt %s holds a permit deposit of %s", salt.Hex(), depositor.Hex())
+		}
+		return 200, nil
+	}
+	entry, ok := ethereum.Positions.Get(ref.Label)
+	if !ok {
+		return 404, fmt.Errorf("no position is registered as %q", ref.Label)
+	}
+	if entry.Depositor != server {
+		return 403, fmt.Errorf("position %q was not deposited by %s", ref.Label, server.Hex())
+	}
+	return 200, nil
+}
+
+// position is a resolved PositionRef with its current state.
+type position struct {
+	label     string
+	poolKey   ethereum.PoolKey
+	tickLower int
+	tickUpper int
+	salt      common.Hash
+	info      *ethereum.PositionInfo
+}
+
+// locate resolves the reference without reading chain state.
+func (ref PositionRef) locate() (*position, int, error) {
+	if ref.Owner != "" && common.HexToAddress(ref.Owner) != ethereum.LPRouterAddress {
+		return nil, 400, fmt.Errorf("only positions owned by the router %s can be modified through it", ethereum.LPRouterAddress.Hex())
+	}
+
+	if ref.Label != "" {
+		entry, ok := ethereum.Positions.Get(ref.Label)
+		if !ok {
+			return nil, 404, fmt.Errorf("no position is registered as %q", ref.Label)
+		}
+		poolKey := createPoolKey(entry.Currency0, entry.Currency1, ethereum.HookAddress)
+		if entry.Fee != 0 {
+			poolKey.Fee = big.NewInt(int64(entry.Fee))
+		}
+		return &position{
+			label:     entry.Label,
+			poolKey:   poolKey,
+			tickLower: entry.TickLower,
+			tickUpper: entry.TickUpper,
+			salt:      entry.Salt,
+		}, 200, nil
+	}
+
+	if ref.Currency1 == (common.Address{}) {
+		return nil, 400, fmt.Errorf("currency0 and currency1 are required without a label")
+	}
+	poolKey := ref.poolKey(ref.Currency0, ref.Currency1)
+	tickLower, tickUpper, err := resolveTickRange(ref.TickLower, ref.TickUpper, "", "", int(poolKey.TickSpacing.Int64()))
+	if err != nil {
+		return nil, 400, err
+	}
+	return &position{poolKey: poolKey, tickLower: tickLower, tickUpper: tickUpper, salt: common.HexToHash(ref.Salt)}, 200, nil
+}
+
+// resolve reads the referenced position, which must hold liquidity. The
+// status is the HTTP status to report with the error.
+func (ref PositionRef) resolve() (*position, int, error) {
+	p, status, err := ref.locate()
+	if err != nil {
+		return nil, status, err
+	}
+	if err := p.read(); err != nil {
+		return nil, 500, err
+	}
+	if p.info.Liquidity.Sign() == 0 {
+		return nil, 400, fmt.Errorf("position [%d, %d] with salt %s has no liquidity", p.tickLower, p.tickUpper, p.salt.Hex())
+	}
+	return p, 200, nil
+}
+
+// read loads the position's state from the PoolManager.
+func (p *position) read() error {
+	info, err := ethereum.GetPositionInfo(p.poolKey.ID(), ethereum.PositionKey(ethereum.LPRouterAddress, p.tickLower, p.tickUpper, p.salt))
+	if err != nil {
+		return fmt.Errorf("failed to read position: %v", err)
+	}
+	p.info = info
+	if p.label != "" && info.Liquidity.Sign() > 0 {
+		ethereum.Positions.Confirm(p.label)
+	}
+	return nil
+}
+
+// modifyParams returns modifyLiquidity params for the position.
+func (p *position) modifyParams(liquidityDelta *big.Int) interface{} {
+	return struct {
+		TickLower      *big.Int
+		TickUpper      *big.Int
+		LiquidityDelta *big.Int
+		Salt           [32]byte
+	}{
+		TickLower:      big.NewInt(int64(p.tickLower)),
+		TickUpper:      big.NewInt(int64(p.tickUpper)),
+		LiquidityDelta: liquidityDelta,
+		Salt:           p.salt,
+	}
+}
+
+// positionStatus maps registry conflicts to 409 and anything else to 500.
+func positionStatus(err error) int {
+	if errors.Is(err, positions.ErrLabelConflict) {
+		return 409
+	}
+	return 500
+}
+
+// ListPositions returns the labelled positions, optionally only those in the
+// pool given by the pool query parameter (PoolId), with their liquidity.
+func ListPositions(c *gin.Context) {
+	var poolID *common.Hash
+	if value := c.Query("pool"); value != "" {
+		id := common.HexToHash(value)
+		poolID = &id
+	}
+
+	list := []gin.H{}
+	for _, entry := range ethereum.Positions.List(poolID) {
+		p, _, err := PositionRef{Label: entry.Label}.locate()
+		if err == nil {
+			err = p.read()
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		list = append(list, gin.H{"position": entry, "liquidity": p.info.Liquidity.String()})
+	}
+	c.JSON(200, gin.H{"positions": list})
+}
+
+// GetPosition returns a labelled position with its liquidity, the amounts
+// it holds at the current price and the fees it has not collected.
+func GetPosition(c *gin.Context) {
+	p, status, err := PositionRef{Label: c.Param("label")}.locate()
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err := p.read(); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	entry, _ := ethereum.Positions.Get(p.label)
+
+	slot0, err := ethereum.GetSlot0(p.poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	sqrtPriceLower, _ := v4math.GetSqrtPriceAtTick(p.tickLower)
+	sqrtPriceUpper, _ := v4math.GetSqrtPriceAtTick(p.tickUpper)
+	amount0, amount1 := v4math.GetAmountsForLiquidity(slot0.SqrtPriceX96, sqrtPriceLower, sqrtPriceUpper, p.info.Liquidity, false)
+
+	fee0, fee1 := new(big.Int), new(big.Int)
+	if p.info.Liquidity.Sign() > 0 {
+		fee0, fee1, err = expectedFees(p)
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read fee growth: %v", err)})
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"position":        entry,
+		"liquidity":       p.info.Liquidity.String(),
+		"currentTick":     slot0.Tick,
+		"inRange":         slot0.Tick >= p.tickLower && slot0.Tick < p.tickUpper,
+		"amounts":         gin.H{"currency0": amount0.String(), "currency1": amount1.String()},
+		"uncollectedFees": gin.H{"currency0": fee0.String(), "currency1": fee1.String()},
+	})
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// checkFeeCaller writes a 403 unless the account sending a protocol fee call
+// is the one the PoolManager requires, and reports whether the request can
+// go on. Unsigned requests without from are not checked, since the signer
+// is unknown.
+func checkFeeCaller(c *gin.Context, opts TxOptions, role string, lookup func() (common.Address, error)) bool {
+	if mode, _ := opts.mode(); mode == ModeUnsigned && opts.From == "" {
+		return true
+	}
+	required, err := lookup()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read the %s: %v", role, err)})
+		return false
+	}
+	if sender := quoteSender(opts); sender != required {
+		c.JSON(403, gin.H{"error": fmt.Sprintf("%s is not the PoolManager %s %s", sender.Hex(), role, required.Hex())})
+		return false
+	}
+	return true
+}
+
+// GetProtocolFees returns the PoolManager owner and protocol fee controller,
+// and the fees accrued in currency when it is given.
+func GetProtocolFees(c *gin.Context) {
+	owner, err := ethereum.GetManagerOwner()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read owner: %v", err)})
+		return
+	}
+	controller, err := ethereum.GetProtocolFeeController()
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read protocol fee controller: %v", err)})
+		return
+	}
+	response := gin.H{
+		"owner":      owner.Hex(),
+		"controller": controller.Hex(),
+	}
+	if value := c.Query("currency"); value != "" {
+		currency, err := parseAddressField(value, "currency")
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		accrued, err := ethereum.GetProtocolFeesAccrued(currency)
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read accrued protocol fees: %v", err)})
+			return
+		}
+		response["currency"] = currency.Hex()
+		response["accrued"] = accrued.String()
+	}
+	c.JSON(200, response)
+}
+
+// SetProtocolFeeController sets the account that sets and collects protocol
+// fees. Only the PoolManager owner may call it.
+func SetProtocolFeeController(c *gin.Context) {
+	var req struct {
+		Controller string `json:"controller" binding:"required"`
+		TxOptions
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	controller, err := parseAddressField(req.Controller, "controller")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkFeeCaller(c, req.TxOptions, "owner", ethereum.GetManagerOwner) {
+		return
+	}
+
+	data, err := ethereum.ManagerABI.Pack("setProtocolFeeController", controller)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.ManagerAddress, gasLimit: 100000, data: data}
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, receipt, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": "Protocol fee controller updated successfully",
+		"txHash": signedTx.Hash().Hex(),
+		"events": ethereum.ProtocolFeeEvents(receipt),
+	})
+}
+
+// SetProtocolFee sets a pool's protocol fee, given packed or per swap
+// direction. Only the protocol fee controller may call it.
+func SetProtocolFee(c *gin.Context) {
+	var req struct {
+		Currency0 common.Address `json:"currency0" binding:"required"`
+		Currency1 common.Address `json:"currency1" binding:"required"`
+		// ProtocolFee is the packed fee, or the fees of each direction in
+		// hundredths of a bip are given by ZeroForOneFee and OneForZeroFee
+		ProtocolFee   *uint32 `json:"protocolFee"`
+		ZeroForOneFee *uint32 `json:"zeroForOneFee"`
+		OneForZeroFee *uint32 `json:"oneForZeroFee"`
+		PoolFee
+		TxOptions
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	var fee uint32
+	switch {
+	case req.ProtocolFee != nil && (req.ZeroForOneFee != nil || req.OneForZeroFee != nil):
+		c.JSON(400, gin.H{"error": "provide either protocolFee or zeroForOneFee and oneForZeroFee, not both"})
+		return
+	case req.ProtocolFee != nil:
+		fee = *req.ProtocolFee
+	case req.ZeroForOneFee != nil && req.OneForZeroFee != nil:
+		if *req.ZeroForOneFee > 0xfff || *req.OneForZeroFee > 0xfff {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("ProtocolFeeTooLarge: each direction is at most %d", ethereum.MaxProtocolFee)})
+			return
+		}
+		fee = ethereum.PackProtocolFee(*req.ZeroForOneFee, *req.OneForZeroFee)
+	default:
+		c.JSON(400, gin.H{"error": "protocolFee, or zeroForOneFee and oneForZeroFee, is required"})
+		return
+	}
+	if err := ethereum.ValidateProtocolFee(fee); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	poolKey := req.poolKey(req.Currency0, req.Currency1)
+	slot0, err := ethereum.GetSlot0(poolKey.ID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read pool state: %v", err)})
+		return
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		c.JSON(400, gin.H{"error": "pool is not initialized"})
+		return
+	}
+	if !checkFeeCaller(c, req.TxOptions, "protocol fee controller", ethereum.GetProtocolFeeController) {
+		return
+	}
+
+	data, err := ethereum.ManagerABI.Pack("setProtocolFee", poolKey, big.NewInt(int64(fee)))
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.ManagerAddress, gasLimit: 100000, data: data}
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, receipt, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	zeroForOne, oneForZero := ethereum.UnpackProtocolFee(fee)
+	c.JSON(200, gin.H{
+		"status":              "Protocol fee updated successfully",
+		"txHash":              signedTx.Hash().Hex(),
+		"poolId":              poolKey.ID().Hex(),
+		"previousProtocolFee": slot0.ProtocolFee,
+		"protocolFee":         fee,
+		"zeroForOneFee":       zeroForOne,
+		"oneForZeroFee":       oneForZero,
+		"events":              ethereum.ProtocolFeeEvents(receipt),
+	})
+}
+
+// CollectProtocolFees sends accrued protocol fees of a currency to
+// recipient, all of them when amount is 0. Only the protocol fee controller
+// may call it.
+func CollectProtocolFees(c *gin.Context) {
+	var req struct {
+		Recipient string `json:"recipient" binding:"required"`
+		Currency  string `json:"currency" binding:"required"`
+		Amount    string `json:"amount"`
+		TxOptions
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+	recipient, err := parseAddressField(req.Recipient, "recipient")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	currency, err := parseAddressField(req.Currency, "currency")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	amount, err := parseAmount(req.Amount, "amount")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	accrued, err := ethereum.GetProtocolFeesAccrued(currency)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read accrued protocol fees: %v", err)})
+		return
+	}
+	if amount.Cmp(accrued) > 0 {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("amount %s is more than the %s accrued", amount, accrued)})
+		return
+	}
+	if !checkFeeCaller(c, req.TxOptions, "protocol fee controller", ethereum.GetProtocolFeeController) {
+		return
+	}
+
+	data, err := ethereum.ManagerABI.Pack("collectProtocolFees", recipient, currency, amount)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.ManagerAddress, gasLimit: 150000, data: data}
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, _, err := sendAndWait(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	remaining, err := ethereum.GetProtocolFeesAccrued(currency)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read accrued protocol fees: %v", err)})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status":    "Protocol fees collected successfully",
+		"txHash":    signedTx.Hash().Hex(),
+		"recipient": recipient.Hex(),
+		"currency":  currency.Hex(),
+		"collected": new(big.Int).Sub(accrued, remaining).String(),
+		"remaining": remaining.String(),
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/relayer"
+	"uniswap-v4-rpc/internal/signer"
+	"uniswap-v4-rpc/internal/sponsorship"
+	"uniswap-v4-rpc/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// sponsoredRelay checks the relay against the sponsorship policy and sends
+// calls in order from a single relayer account, which pays for gas on behalf
+// of the permit signer. The gas used is recorded in the sponsorship ledger.
+func sponsoredRelay(req sponsorship.Request, calls []txCall, relayerFee *big.Int, feeToken common.Address) ([]*types.Transaction, common.Address, error) {
+	ctx := context.Background()
+
+	chainID, err := ethereum.Client.ChainID(ctx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	gasPrice, err := ethereum.Client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to fetch gas price: %v", err)
+	}
+
+	var gasLimit uint64
+	for _, call := range calls {
+		gasLimit += call.gasLimit
+	}
+	req.MaxGasCost = new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice)
+
+	reservation, err := ethereum.Sponsorship.Authorize(req)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	defer reservation.Done()
+
+	lease, err := ethereum.Relayers.Acquire(ctx)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	relayerAddress := lease.Address()
+
+	var sent []*types.Transaction
+	for i, call := range calls {
+		if i > 0 {
+			lease = lease.Next()
+		}
+
+		data := call.data
+		if call.buildData != nil {
+			data, err = call.buildData(relayerAddress)
+			if err != nil {
+				lease.Failed()
+				return sent, relayerAddress, err
+			}
+		}
+		value := call.value
+		if value == nil {
+			value = big.NewInt(0)
+		}
+
+		auth := lease.Transactor(chainID, gasPrice)
+		tx := types.NewTransaction(auth.Nonce.Uint64(), call.to, value, call.gasLimit, auth.GasPrice, data)
+		signedTx, err := auth.Signer(auth.From, tx)
+		if err != nil {
+			lease.Failed()
+			return sent, relayerAddress, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+
+		if err := ethereum.Client.SendTransaction(ctx, signedTx); err != nil {
+			lease.Failed()
+			return sent, relayerAddress, fmt.Errorf("failed to send transaction: %v", err)
+		}
+		reservation.Sent(signedTx)
+		onMined := []func(*types.Receipt){reservation.Settle}
+		if call.collectsFee && relayerFee != nil && relayerFee.Sign() > 0 {
+			// Recorded before settling, which may close the entry
+			onMined = []func(*types.Receipt){func(receipt *types.Receipt) {
+				if receipt != nil && receipt.Status == types.ReceiptStatusSuccessful {
+					reservation.SetRelayerFee(feeToken, relayerFee)
+				}
+			}, reservation.Settle}
+		}
+		if call.onMined != nil {
+			onMined = append([]func(*types.Receipt){call.onMined}, onMined...)
+		}
+		lease.Sent(signedTx, onMined...)
+		sent = append(sent, signedTx)
+	}
+	return sent, relayerAddress, nil
+}
+
+// relayerFeeCalls charges fee of token from user to the relayer account. The
+// user signs a permit for the relayer queued behind `queued` earlier permits
+// from the same call, the first of which is at nonce, then the relayer
+// submits it followed by transferFrom. The nonce must be read before any call
+// is sent. If an earlier permit is never consumed both calls revert and no
+// fee is taken.
+func relayerFeeCalls(token, user common.Address, userSigner signer.Signer, fee, deadline, nonce *big.Int, queued int64) []txCall {
+	feeNonce := new(big.Int).Add(nonce, big.NewInt(queued))
+	return []txCall{
+		{
+			to:       token,
+			gasLimit: 100000,
+			buildData: func(relayerAddress common.Address) ([]byte, error) {
+				permit, err := utils.SignPermit(token, user, relayerAddress, fee, feeNonce, deadline, userSigner)
+				if err != nil {
+					return nil, fmt.Errorf("failed to sign relayer fee permit: %v", err)
+				}
+				v, r, s, err := permit.VRS()
+				if err != nil {
+					return nil, err
+				}
+				return ethereum.PackERC20("permit", user, relayerAddress, fee, deadline, v, r, s)
+			},
+		},
+		{
+			to:       token,
+			gasLimit: 100000,
+			buildData: func(relayerAddress common.Address) ([]byte, error) {
+				return ethereum.PackERC20("transferFrom", user, relayerAddress, fee)
+			},
+			collectsFee: true,
+		},
+	}
+}
+
+// relayErrorStatus maps sponsorship refusals to 403 and an exhausted relayer
+// pool to 503.
+func relayErrorStatus(err error) int {
+	if sponsorship.IsPolicyError(err) {
+		return 403
+	}
+	if errors.Is(err, relayer.ErrNoHealthyRelayer) {
+		return 503
+	}
+	return 500
+}
+
+// RelayerStatus reports nonce, pending count, balance and health for every
+// relayer account.
+func RelayerStatus(c *gin.Context) {
+	c.JSON(200, gin.H{"relayers": ethereum.Relayers.Status()})
+}
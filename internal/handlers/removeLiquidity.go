@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/sponsorship"
+	"uniswap-v4-rpc/pkg/utils"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// RemoveLiquidityPosition identifies the position to withdraw from and how
+// much of it to withdraw.
+type RemoveLiquidityPosition struct {
+	PositionRef
+	// Either an amount of liquidity or a percentage of the position
+	Liquidity  string  `json:"liquidity"`
+	Percentage float64 `json:"percentage"`
+	// Least of each currency that must be received. The LP router has no
+	// slippage check, so these are only checked against a simulation before
+	// sending and are not enforced on-chain.
+	Amount0Min string `json:"amount0Min"`
+	Amount1Min string `json:"amount1Min"`
+}
+
+// removal is a planned withdrawal with the principal it returns at the
+// current price.
+type removal struct {
+	*position
+	liquidity  *big.Int
+	principal0 *big.Int
+	principal1 *big.Int
+	amount0Min *big.Int
+	amount1Min *big.Int
+}
+
+// params returns the modifyLiquidity params with a negative liquidityDelta.
+func (r *removal) params() interface{} {
+	return r.modifyParams(new(big.Int).Neg(r.liquidity))
+}
+
+// fees splits what a withdrawal received into principal and accrued fees,
+// since the router only returns their sum.
+func (r *removal) fees(received0, received1 *big.Int) (*big.Int, *big.Int) {
+	fee0 := new(big.Int).Sub(received0, r.principal0)
+	fee1 := new(big.Int).Sub(received1, r.principal1)
+	if fee0.Sign() < 0 {
+		fee0.SetInt64(0)
+	}
+	if fee1.Sign() < 0 {
+		fee1.SetInt64(0)
+	}
+	return fee0, fee1
+}
+
+// planRemoval resolves the position and the liquidity to remove. The status
+// is the HTTP status to report with the error.
+func planRemoval(req RemoveLiquidityPosition) (*removal, int, error) {
+	if (req.Liquidity == "") == (req.Percentage == 0) {
+		return nil, 400, fmt.Errorf("provide either liquidity or percentage")
+	}
+	if req.Percentage < 0 || req.Percentage > 100 {
+		return nil, 400, fmt.Errorf("percentage must be between 0 and 100")
+	}
+	amount0Min, err := parseAmount(req.Amount0Min, "amount0Min")
+	if err != nil {
+		return nil, 400, err
+	}
+	amount1Min, err := parseAmount(req.Amount1Min, "amount1Min")
+	if err != nil {
+		return nil, 400, err
+	}
+
+	position, status, err := req.resolve()
+	if err != nil {
+		return nil, status, err
+	}
+
+	var liquidity *big.Int
+	if req.Liquidity != "" {
+		liquidity, err = parseAmount(req.Liquidity, "liquidity")
+		if err != nil {
+			return nil, 400, err
+		}
+	} else {
+		// Percentage to basis points so the split is exact integer math
+		bps := big.NewInt(int64(req.Percentage*100 + 0.5))
+		liquidity = new(big.Int).Mul(position.info.Liquidity, bps)
+		liquidity.Div(liquidity, big.NewInt(10000))
+	}
+	if liquidity.Sign() == 0 {
+		return nil, 400, fmt.Errorf("liquidity to remove is zero")
+	}
+	if liquidity.Cmp(position.info.Liquidity) > 0 {
+		return nil, 400, fmt.Errorf("liquidity %s exceeds the position's %s", liquidity.String(), position.info.Liquidity.String())
+	}
+
+	slot0, err := ethereum.GetSlot0(position.poolKey.ID())
+	if err != nil {
+		return nil, 500, fmt.Errorf("failed to read pool state: %v", err)
+	}
+	sqrtPriceLower, _ := v4math.GetSqrtPriceAtTick(position.tickLower)
+	sqrtPriceUpper, _ := v4math.GetSqrtPriceAtTick(position.tickUpper)
+	principal0, principal1 := v4math.GetAmountsForLiquidity(slot0.SqrtPriceX96, sqrtPriceLower, sqrtPriceUpper, liquidity, false)
+
+	return &removal{
+		position:   position,
+		liquidity:  liquidity,
+		principal0: principal0,
+		principal1: principal1,
+		amount0Min: amount0Min,
+		amount1Min: amount1Min,
+	}, 200, nil
+}
+
+// unregisterIfEmptied drops the label of a position the removal empties,
+// once receipt shows the withdrawal succeeded. It is nil when no receipt
+// arrived.
+func (r *removal) unregisterIfEmptied(receipt *types.Receipt) {
+	if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+		return
+	}
+	if r.label != "" && r.liquidity.Cmp(r.info.Liquidity) == 0 {
+		ethereum.Positions.Remove(r.label)
+	}
+}
+
+func removalResponse(plan *removal, received0, received1 *big.Int) gin.H {
+	fee0, fee1 := plan.fees(received0, received1)
+	return gin.H{
+		"liquidityRemoved": plan.liquidity.String(),
+		"received":         gin.H{"currency0": received0.String(), "currency1": received1.String()},
+		"principal":        gin.H{"currency0": plan.principal0.String(), "currency1": plan.principal1.String()},
+		"fees":             gin.H{"currency0": fee0.String(), "currency1": fee1.String()},
+		// The minimums were checked against a simulation only
+		"minAmounts": gin.H{
+			"currency0":       plan.amount0Min.String(),
+			"currency1":       plan.amount1Min.String(),
+			"enforcedOnChain": false,
+		},
+		"params": gin.H{
+			"currency0": plan.poolKey.Currency0.Hex(),
+			"currency1": plan.poolKey.Currency1.Hex(),
+			"tickLower": plan.tickLower,
+			"tickUpper": plan.tickUpper,
+			"salt":      plan.salt.Hex(),
+			"label":     plan.label,
+		},
+	}
+}
+
+// RemoveLiquidity withdraws liquidity from a router owned position to the
+// server account. Only positions the server deposited can be withdrawn.
+func RemoveLiquidity(c *gin.Context) {
+	var req struct {
+		RemoveLiquidityPosition
+		HookDataOption
+		ClaimOptions
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	// Router owned positions are shared, so permit deposits can only be
+	// withdrawn to their depositor through /removeLiquidityPermit
+	if status, err := req.depositedByServer(); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, status, err := planRemoval(req.RemoveLiquidityPosition)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Removing liquidity %s from [%d, %d]", plan.liquidity.String(), plan.tickLower, plan.tickUpper)
+
+	hookData, status, err := req.hookData(plan.poolKey.Hooks, hookadapters.RemoveLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := ethereum.LPRouterABI.Pack("modifyLiquidity", plan.poolKey, plan.params(), hookData, req.SettleUsingBurn, req.TakeClaims)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+
+	if exported {
+		if err := checkMinAmounts(plan.principal0, plan.principal1, plan.amount0Min, plan.amount1Min); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.LPRouterAddress, gasLimit: 500000, data: data}})
+		return
+	}
+
+	server := ethereum.Signer.Address()
+	received0, received1, err := simulateModifyLiquidity(server, "modifyLiquidity", data, nil)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("modifyLiquidity simulation failed: %v", err)})
+		return
+	}
+	if err := checkMinAmounts(received0, received1, plan.amount0Min, plan.amount1Min); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	signedTx, receipt, err := sendAndWait(txCall{to: ethereum.LPRouterAddress, gasLimit: 500000, data: data})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan.unregisterIfEmptied(receipt)
+	response := removalResponse(plan, received0, received1)
+	response["status"] = "Liquidity removed successfully"
+	response["txHash"] = signedTx.Hash().Hex()
+	response["recipient"] = server.Hex()
+	c.JSON(200, response)
+}
+
+// RemoveLiquidityPermit withdraws liquidity to userAddress through the
+// router's permit entry point, relayed by the server. Only positions
+// userAddress deposited with a permit can be withdrawn. The router requires
+// permits for |liquidityDelta| of both tokens even when removing.
+func RemoveLiquidityPermit(c *gin.Context) {
+	var req struct {
+		RemoveLiquidityPosition
+		UserAddress      string `json:"userAddress" binding:"required"`
+		Permit0Signature string `json:"permit0Signature"`
+		Permit1Signature string `json:"permit1Signature"`
+		HookDataOption
+		ClaimOptions
+		PermitAuth
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	userAddress := common.HexToAddress(req.UserAddress)
+	userSigner, err := req.permitSigner(req.Permit0Signature, req.Permit1Signature)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	deadline, err := req.deadline(big.NewInt(time.Now().Unix() + 3600)) // 1 hour from now
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Router owned positions are shared, so only what userAddress deposited
+	// can be withdrawn to them
+	ref, status, err := req.PositionRef.depositedBy(userAddress)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	req.PositionRef = ref
+
+	plan, status, err := planRemoval(req.RemoveLiquidityPosition)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	hookData, status, err := req.hookData(plan.poolKey.Hooks, hookadapters.RemoveLiquidity)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	permit0, err := ownerPermit(plan.poolKey.Currency0, userAddress, ethereum.LPRouterAddress, plan.liquidity, deadline, userSigner, req.Permit0Signature)
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Error generating permit signature for currency0: " + err.Error()})
+		return
+	}
+	permit1, err := ownerPermit(plan.poolKey.Currency1, userAddress, ethereum.LPRouterAddress, plan.liquidity, deadline, userSigner, req.Permit1Signature)
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Error generating permit signature for currency1: " + err.Error()})
+		return
+	}
+	for i, permit := range []*utils.Permit{permit0, permit1} {
+		if err := utils.ValidatePermit(permit); err != nil {
+			c.JSON(permitErrorStatus(err), gin.H{"error": fmt.Sprintf("Invalid permit for currency%d: %v", i, err)})
+			return
+		}
+	}
+	v0, r0, s0, err := permit0.VRS()
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Permit for currency0: " + err.Error()})
+		return
+	}
+	v1, r1, s1, err := permit1.VRS()
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": "Permit for currency1: " + err.Error()})
+		return
+	}
+
+	data, err := ethereum.LPRouterABI.Pack("modifyLiquidityWithPermit",
+		userAddress,
+		plan.poolKey,
+		plan.params(),
+		hookData,
+		req.SettleUsingBurn,
+		req.TakeClaims,
+		deadline,
+		v0, r0, s0,
+		v1, r1, s1,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Error packing data: " + err.Error()})
+		return
+	}
+
+	if exported {
+		if err := checkMinAmounts(plan.principal0, plan.principal1, plan.amount0Min, plan.amount1Min); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.LPRouterAddress, gasLimit: 1000000, data: data}})
+		return
+	}
+
+	// The router does not check the caller, so any account can simulate
+	received0, received1, err := simulateModifyLiquidity(ethereum.Signer.Address(), "modifyLiquidityWithPermit", data, nil)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("modifyLiquidityWithPermit simulation failed: %v", err)})
+		return
+	}
+	if err := checkMinAmounts(received0, received1, plan.amount0Min, plan.amount1Min); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	sponsorReq := sponsorship.Request{
+		User:    userAddress,
+		PoolID:  plan.poolKey.ID(),
+		Tokens:  []common.Address{plan.poolKey.Currency0, plan.poolKey.Currency1},
+		Amounts: []*big.Int{received0, received1},
+	}
+	// The label is dropped once the withdrawal is mined
+	calls := []txCall{{to: ethereum.LPRouterAddress, gasLimit: 1000000, data: data, onMined: plan.unregisterIfEmptied}}
+	sent, relayerAddress, err := sponsoredRelay(sponsorReq, calls, nil, common.Address{})
+	if err != nil {
+		c.JSON(relayErrorStatus(err), gin.H{"error": fmt.Sprintf("Error relaying transaction: %v", err)})
+		return
+	}
+
+	response := removalResponse(plan, received0, received1)
+	response["message"] = "Remove liquidity with permit initiated successfully"
+	response["txHash"] = sent[0].Hash().Hex()
+	response["relayer"] = relayerAddress.Hex()
+	response["recipient"] = userAddress.Hex()
+	c.JSON(200, response)
+}
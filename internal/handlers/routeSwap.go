@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/routing"
+	"uniswap-v4-rpc/pkg/v4actions"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxHops bounds the path search when the request does not.
+const defaultMaxHops = 3
+
+// quoteSender returns the account quotes are simulated from: the account
+// that will execute the swap when it is known, else the server account.
+func quoteSender(opts TxOptions) common.Address {
+	if mode, _ := opts.mode(); mode == ModeSafe {
+		return common.HexToAddress(opts.SafeAddress)
+	}
+	if common.IsHexAddress(opts.From) {
+		return common.HexToAddress(opts.From)
+	}
+	return ethereum.Signer.Address()
+}
+
+// RouteHookData is embedded in routing requests, whose pools are only known
+// once a route is found. HookData maps a hook address to the hookData of
+// swaps through its pools, raw hex or schema fields as in HookDataOption.
+type RouteHookData struct {
+	HookData map[string]json.RawMessage `json:"hookData"`
+}
+
+// routeKeys encodes the swap hookData of every hook of pools and returns
+// the keys routes may use with it. The same bytes are quoted and sent. A
+// pool whose hook needs hookData the request does not give is left out.
+func (o RouteHookData) routeKeys(pools []ethereum.InitializedPool) ([]ethereum.PoolKey, map[common.Address][]byte, int, error) {
+	hookData := map[common.Address][]byte{}
+	for hook, raw := range o.HookData {
+		address, err := parseAddressField(hook, "hookData key")
+		if err != nil {
+			return nil, nil, 400, err
+		}
+		data, status, err := HookDataOption{HookData: raw}.hookData(address, hookadapters.Swap)
+		if err != nil {
+			return nil, nil, status, fmt.Errorf("hook %s: %v", address.Hex(), err)
+		}
+		hookData[address] = data
+	}
+
+	var keys []ethereum.PoolKey
+	skipped := map[common.Address]bool{}
+	for _, pool := range pools {
+		hook := pool.Key.Hooks
+		if _, ok := hookData[hook]; !ok && !skipped[hook] {
+			data, _, err := HookDataOption{}.hookData(hook, hookadapters.Swap)
+			if err != nil {
+				log.Printf("Not routing through pools of hook %s: %v", hook.Hex(), err)
+				skipped[hook] = true
+			} else {
+				hookData[hook] = data
+			}
+		}
+		if !skipped[hook] {
+			keys = append(keys, pool.Key)
+		}
+	}
+	return keys, hookData, 200, nil
+}
+
+// withSlippage scales amount by (10000 + bps) / 10000, rounding down.
+func withSlippage(amount *big.Int, bps int64) *big.Int {
+	scaled := new(big.Int).Mul(amount, big.NewInt(10000+bps))
+	return scaled.Div(scaled, big.NewInt(10000))
+}
+
+// routeJSON describes a quoted route and its hops.
+func routeJSON(route *routing.Route) gin.H {
+	path := []string{}
+	for _, currency := range route.Currencies() {
+		path = append(path, currency.Hex())
+	}
+	hops := []gin.H{}
+	for _, hop := range route.Hops {
+		hops = append(hops, gin.H{
+			"poolId":      hop.PoolKey.ID().Hex(),
+			"currencyIn":  hop.CurrencyIn().Hex(),
+			"currencyOut": hop.CurrencyOut().Hex(),
+			"fee":         hop.PoolKey.Fee.String(),
+			"tickSpacing": hop.PoolKey.TickSpacing.String(),
+			"hooks":       hop.PoolKey.Hooks.Hex(),
+			"zeroForOne":  hop.ZeroForOne,
+			"hookData":    hexutil.Encode(hop.HookData),
+			"amountIn":    hop.AmountIn.String(),
+			"amountOut":   hop.AmountOut.String(),
+		})
+	}
+	return gin.H{
+		"path":      path,
+		"hops":      hops,
+		"amountIn":  route.AmountIn().String(),
+		"amountOut": route.AmountOut().String(),
+	}
+}
+
+// RouteSwap finds the best path of up to maxHops pools between two
+// currencies, from the pools the PoolManager has initialized, and swaps
+// along it through the V4Router. With quoteOnly it only returns the quote.
+func RouteSwap(c *gin.Context) {
+	var req struct {
+		CurrencyIn  string `json:"currencyIn" binding:"required"`
+		CurrencyOut string `json:"currencyOut" binding:"required"`
+		// Exactly one of AmountIn (exact input) and AmountOut (exact output)
+		AmountIn  string `json:"amountIn"`
+		AmountOut string `json:"amountOut"`
+		MaxHops   int    `json:"maxHops"`
+		// SlippageBps bounds the output (or input) against the quote,
+		// 50 (0.5%) when unset
+		SlippageBps *int64 `json:"slippageBps"`
+		QuoteOnly   bool   `json:"quoteOnly"`
+		RouteHookData
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	if !common.IsHexAddress(req.CurrencyIn) || !common.IsHexAddress(req.CurrencyOut) {
+		c.JSON(400, gin.H{"error": "Invalid currency address"})
+		return
+	}
+	currencyIn := common.HexToAddress(req.CurrencyIn)
+	currencyOut := common.HexToAddress(req.CurrencyOut)
+	if currencyIn == currencyOut {
+		c.JSON(400, gin.H{"error": "currencyIn and currencyOut must differ"})
+		return
+	}
+	if (req.AmountIn == "") == (req.AmountOut == "") {
+		c.JSON(400, gin.H{"error": "Exactly one of amountIn and amountOut is required"})
+		return
+	}
+	exactIn := req.AmountIn != ""
+	raw := req.AmountIn
+	if !exactIn {
+		raw = req.AmountOut
+	}
+	amount, ok := new(big.Int).SetString(raw, 10)
+	if !ok || amount.Sign() <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid amount"})
+		return
+	}
+	maxHops := req.MaxHops
+	if maxHops == 0 {
+		maxHops = defaultMaxHops
+	}
+	if maxHops < 1 {
+		c.JSON(400, gin.H{"error": "maxHops must be positive"})
+		return
+	}
+	slippageBps := int64(50)
+	if req.SlippageBps != nil {
+		slippageBps = *req.SlippageBps
+	}
+	if slippageBps < 0 || slippageBps >= 10000 {
+		c.JSON(400, gin.H{"error": "slippageBps must be between 0 and 9999"})
+		return
+	}
+
+	pools, err := ethereum.GetInitializedPools()
+	if err != nil {
+		c.JSON(poolsErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to read pools: %v", err)})
+		return
+	}
+	keys, hookData, status, err := req.routeKeys(pools)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	routes := routing.Paths(keys, currencyIn, currencyOut, maxHops)
+	routing.SetHookData(routes, hookData)
+	best, err := routing.Best(routes, exactIn, amount, routing.SimulatedQuoter(quoteSender(req.TxOptions)))
+	if err != nil {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("No route from %s to %s within %d hops (%d candidates)", currencyIn.Hex(), currencyOut.Hex(), maxHops, len(routes))})
+		return
+	}
+	log.Printf("Routing %s to %s through %d hops out of %d candidates", currencyIn.Hex(), currencyOut.Hex(), len(best.Hops), len(routes))
+
+	plan := &v4actions.Plan{}
+	var limit *big.Int
+	if exactIn {
+		limit = withSlippage(best.AmountOut(), -slippageBps)
+		plan.SwapExactIn(v4actions.ExactInputParams{
+			CurrencyIn:       currencyIn,
+			Path:             best.PathKeys(true),
+			AmountIn:         amount,
+			AmountOutMinimum: limit,
+		}).SettleAll(currencyIn, amount).TakeAll(currencyOut, limit)
+	} else {
+		limit = withSlippage(best.AmountIn(), slippageBps)
+		plan.SwapExactOut(v4actions.ExactOutputParams{
+			CurrencyOut:     currencyOut,
+			Path:            best.PathKeys(false),
+			AmountOut:       amount,
+			AmountInMaximum: limit,
+		}).SettleAll(currencyIn, limit).TakeAll(currencyOut, amount)
+	}
+
+	response := gin.H{
+		"exactInput":  exactIn,
+		"route":       routeJSON(best),
+		"candidates":  len(routes),
+		"amountLimit": limit.String(),
+	}
+	if req.QuoteOnly {
+		c.JSON(200, response)
+		return
+	}
+	if !ethereum.HasV4Router() {
+		c.JSON(503, gin.H{"error": "V4Router is not configured, set v4_router_address"})
+		return
+	}
+
+	value := nativeValue(currencyIn, amount)
+	if !exactIn {
+		value = nativeValue(currencyIn, limit)
+	}
+	data, err := packExecuteActions(plan, value)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.V4RouterAddress, value: value, gasLimit: uint64(300000 + 200000*len(best.Hops)), data: data}
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, _, native, err := sendAndWaitNative(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	response["status"] = "Swap routed successfully"
+	response["txHash"] = signedTx.Hash().Hex()
+	if native != nil {
+		response["native"] = native
+	}
+	c.JSON(200, response)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/routing"
+	"uniswap-v4-rpc/pkg/v4actions"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSplitParts is the number of increments a split swap is divided
+// into when the request does not say.
+const defaultSplitParts = 10
+
+// outputGasCost converts gas into the output currency at the current gas
+// price. The rate is outputPerEth when given, one to one when the output is
+// native ETH, or the spot price of an initialized ETH pool with the output.
+// It returns nil when there is no rate.
+func outputGasCost(pools []ethereum.InitializedPool, currencyOut common.Address, outputPerEth *big.Int) (routing.GasCostFunc, error) {
+	gasPrice, err := ethereum.Client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	wei := func(gas uint64) *big.Int {
+		return new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas))
+	}
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	switch {
+	case outputPerEth != nil:
+		return func(gas uint64) *big.Int {
+			cost := new(big.Int).Mul(wei(gas), outputPerEth)
+			return cost.Div(cost, ether)
+		}, nil
+	case currencyOut == (common.Address{}):
+		return wei, nil
+	}
+	for _, pool := range pools {
+		// Native ETH is the zero address, so always currency0
+		if pool.Key.Currency0 == (common.Address{}) && pool.Key.Currency1 == currencyOut {
+			priceX192 := new(big.Int).Mul(pool.SqrtPriceX96, pool.SqrtPriceX96)
+			return func(gas uint64) *big.Int {
+				cost := new(big.Int).Mul(wei(gas), priceX192)
+				return cost.Rsh(cost, 192)
+			}, nil
+		}
+	}
+	return func(uint64) *big.Int { return nil }, nil
+}
+
+// splitJSON describes a split and the share of each route.
+func splitJSON(split *routing.Split) gin.H {
+	routes := []gin.H{}
+	for _, route := range split.Routes {
+		share := new(big.Float).Quo(new(big.Float).SetInt(route.AmountIn()), new(big.Float).SetInt(split.AmountIn))
+		described := routeJSON(&route)
+		described["share"] = share.Text('f', 4)
+		routes = append(routes, described)
+	}
+	response := gin.H{
+		"routes":       routes,
+		"amountIn":     split.AmountIn.String(),
+		"amountOut":    split.AmountOut.String(),
+		"gasEstimate":  split.GasEstimate,
+		"netAmountOut": split.NetAmountOut.String(),
+	}
+	if split.GasCost != nil {
+		response["gasCost"] = split.GasCost.String()
+	}
+	return response
+}
+
+// SplitSwap divides an exact input swap over the pools of a pair, across
+// fee tiers and hooks, to lower its price impact, and executes the split
+// through the V4Router. With quoteOnly it only returns the split.
+func SplitSwap(c *gin.Context) {
+	var req struct {
+		CurrencyIn  string `json:"currencyIn" binding:"required"`
+		CurrencyOut string `json:"currencyOut" binding:"required"`
+		AmountIn    string `json:"amountIn" binding:"required"`
+		// Parts is the number of increments the input is split into
+		Parts int `json:"parts"`
+		// OutputPerEth prices gas in the output currency, as raw output
+		// units per 1 ETH
+		OutputPerEth string `json:"outputPerEth"`
+		SlippageBps  *int64 `json:"slippageBps"`
+		QuoteOnly    bool   `json:"quoteOnly"`
+		RouteHookData
+		TxOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
+
+	if !common.IsHexAddress(req.CurrencyIn) || !common.IsHexAddress(req.CurrencyOut) {
+		c.JSON(400, gin.H{"error": "Invalid currency address"})
+		return
+	}
+	currencyIn := common.HexToAddress(req.CurrencyIn)
+	currencyOut := common.HexToAddress(req.CurrencyOut)
+	if currencyIn == currencyOut {
+		c.JSON(400, gin.H{"error": "currencyIn and currencyOut must differ"})
+		return
+	}
+	amountIn, ok := new(big.Int).SetString(req.AmountIn, 10)
+	if !ok || amountIn.Sign() <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid amount"})
+		return
+	}
+	parts := req.Parts
+	if parts == 0 {
+		parts = defaultSplitParts
+	}
+	if parts < 1 || parts > 100 {
+		c.JSON(400, gin.H{"error": "parts must be between 1 and 100"})
+		return
+	}
+	var outputPerEth *big.Int
+	if req.OutputPerEth != "" {
+		outputPerEth, ok = new(big.Int).SetString(req.OutputPerEth, 10)
+		if !ok || outputPerEth.Sign() < 0 {
+			c.JSON(400, gin.H{"error": "Invalid outputPerEth"})
+			return
+		}
+	}
+	slippageBps := int64(50)
+	if req.SlippageBps != nil {
+		slippageBps = *req.SlippageBps
+	}
+	if slippageBps < 0 || slippageBps >= 10000 {
+		c.JSON(400, gin.H{"error": "slippageBps must be between 0 and 9999"})
+		return
+	}
+
+	pools, err := ethereum.GetInitializedPools()
+	if err != nil {
+		c.JSON(poolsErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to read pools: %v", err)})
+		return
+	}
+	keys, hookData, status, err := req.routeKeys(pools)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	// Only direct pools, so no two candidates share a pool
+	routes := routing.Paths(keys, currencyIn, currencyOut, 1)
+	routing.SetHookData(routes, hookData)
+	gasCost, err := outputGasCost(pools, currencyOut, outputPerEth)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get gas price: %v", err)})
+		return
+	}
+	split, err := routing.Optimize(routes, amountIn, parts, routing.SimulatedQuoter(quoteSender(req.TxOptions)), gasCost)
+	if err != nil {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("No pool between %s and %s can take the swap (%d candidates)", currencyIn.Hex(), currencyOut.Hex(), len(routes))})
+		return
+	}
+	log.Printf("Splitting %s of %s over %d of %d pools", amountIn.String(), currencyIn.Hex(), len(split.Routes), len(routes))
+
+	limit := withSlippage(split.AmountOut, -slippageBps)
+	plan := &v4actions.Plan{}
+	for _, route := range split.Routes {
+		// The minimum is enforced on the total by TAKE_ALL
+		plan.SwapExactIn(v4actions.ExactInputParams{
+			CurrencyIn:       currencyIn,
+			Path:             route.PathKeys(true),
+			AmountIn:         route.AmountIn(),
+			AmountOutMinimum: big.NewInt(0),
+		})
+	}
+	plan.SettleAll(currencyIn, amountIn).TakeAll(currencyOut, limit)
+
+	response := gin.H{
+		"split":       splitJSON(split),
+		"candidates":  len(routes),
+		"amountLimit": limit.String(),
+	}
+	if req.QuoteOnly {
+		c.JSON(200, response)
+		return
+	}
+	if !ethereum.HasV4Router() {
+		c.JSON(503, gin.H{"error": "V4Router is not configured, set v4_router_address"})
+		return
+	}
+
+	value := nativeValue(currencyIn, amountIn)
+	data, err := packExecuteActions(plan, value)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to pack data: %v", err)})
+		return
+	}
+	call := txCall{to: ethereum.V4RouterAddress, value: value, gasLimit: 2 * split.GasEstimate, data: data}
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
+
+	signedTx, receipt, native, err := sendAndWaitNative(call)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	response["status"] = "Split swap executed successfully"
+	response["txHash"] = signedTx.Hash().Hex()
+	response["gasUsed"] = receipt.GasUsed
+	if native != nil {
+		response["native"] = native
+	}
+	c.JSON(200, response)
+}
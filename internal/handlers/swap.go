@@ -5,27 +5,49 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"time"
 
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
 	"uniswap-v4-rpc/pkg/utils"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gin-gonic/gin"
 )
 
+// swapReceiptTimeout bounds how long /performSwap waits for a receipt it
+// needs, after which the swap may still be mined.
+const swapReceiptTimeout = 2 * time.Minute
+
 func Swap(c *gin.Context) {
 	var req struct {
 		Currency0  string `json:"currency0" binding:"required"`
 		Currency1  string `json:"currency1" binding:"required"`
 		Amount     string `json:"amount" binding:"required"`
 		ZeroForOne bool   `json:"zeroForOne"`
+		// Router is "test" or "v4router", the configured swap_router_mode
+		// when empty
+		Router string `json:"router"`
+		// AmountLimit is the minimum output of an exact input or maximum
+		// input of an exact output, enforced by the V4Router. It is also
+		// the ETH sent for an exact output paid in native ETH.
+		AmountLimit string `json:"amountLimit"`
+		PoolFee
+		HookDataOption
+		ClaimOptions
+		TxOptions
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
 
 	currency0 := common.HexToAddress(req.Currency0)
 	currency1 := common.HexToAddress(req.Currency1)
@@ -34,9 +56,83 @@ func Swap(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid amount"})
 		return
 	}
+	router, status, err := swapRouterMode(req.Router)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	amountLimit, err := parseAmountLimit(req.AmountLimit)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	poolKey := req.poolKey(currency0, currency1)
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.Swap)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
 
+	// The test router always sells currency0
 	zeroForOne := true
-	sqrtPriceLimitX96, _ := new(big.Int).SetString("4295128740", 10)
+	if router == ethereum.SwapRouterV4 {
+		zeroForOne = req.ZeroForOne
+	}
+	currencyIn := currency0
+	if !zeroForOne {
+		currencyIn = currency1
+	}
+	if router == ethereum.SwapRouterV4 && req.ClaimOptions.used() {
+		c.JSON(400, gin.H{"error": "takeClaims and settleUsingBurn are only supported by the test router"})
+		return
+	}
+	value, err := swapValue(currencyIn, amountSpecified, amountLimit)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// Burning claims pays instead of ETH
+	if req.SettleUsingBurn {
+		value = big.NewInt(0)
+	}
+
+	var call txCall
+	if router == ethereum.SwapRouterV4 {
+		// The V4Router takes the direction from the request, with no price limit
+		data, err := packExecuteActions(v4RouterSwapPlan(poolKey, zeroForOne, amountSpecified, amountLimit, hookData), value)
+		if err != nil {
+			log.Printf("Error packing data: %v", err)
+			c.JSON(500, gin.H{"error": "Internal server error"})
+			return
+		}
+		call = txCall{to: ethereum.V4RouterAddress, value: value, gasLimit: 1000000, data: data}
+	} else {
+		sqrtPriceLimitX96, _ := new(big.Int).SetString("4295128740", 10)
+
+		swapParams := struct {
+			ZeroForOne        bool
+			AmountSpecified   *big.Int
+			SqrtPriceLimitX96 *big.Int
+		}{
+			ZeroForOne:        zeroForOne,
+			AmountSpecified:   amountSpecified,
+			SqrtPriceLimitX96: sqrtPriceLimitX96,
+		}
+
+		data, err := ethereum.SwapRouterABI.Pack("swap", poolKey, swapParams, req.testSettings(), hookData)
+		if err != nil {
+			log.Printf("Error packing data: %v", err)
+			c.JSON(500, gin.H{"error": "Internal server error"})
+			return
+		}
+		call = txCall{to: ethereum.SwapRouterAddress, value: value, gasLimit: 1000000, data: data}
+	}
+
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{call})
+		return
+	}
 
 	auth, err := createTransactor()
 	if err != nil {
@@ -57,48 +153,59 @@ func Swap(c *gin.Context) {
 		return
 	}
 
-	poolKey := createPoolKey(currency0, currency1, ethereum.HookAddress)
-
-	swapParams := struct {
-		ZeroForOne        bool
-		AmountSpecified   *big.Int
-		SqrtPriceLimitX96 *big.Int
-	}{
-		ZeroForOne:        zeroForOne,
-		AmountSpecified:   amountSpecified,
-		SqrtPriceLimitX96: sqrtPriceLimitX96,
-	}
-
-	testSettings := struct {
-		TakeClaims      bool
-		SettleUsingBurn bool
-	}{
-		TakeClaims:      false,
-		SettleUsingBurn: false,
-	}
+	tx := types.NewTransaction(auth.Nonce.Uint64(), call.to, value, call.gasLimit, auth.GasPrice, call.data)
 
-	data, err := ethereum.SwapRouterABI.Pack("swap", poolKey, swapParams, testSettings, []byte{})
+	signedTx, err := auth.Signer(auth.From, tx)
 	if err != nil {
-		log.Printf("Error packing data: %v", err)
+		log.Printf("Error signing transaction: %v", err)
 		c.JSON(500, gin.H{"error": "Internal server error"})
 		return
 	}
 
-	tx := types.NewTransaction(auth.Nonce.Uint64(), ethereum.SwapRouterAddress, big.NewInt(0), 1000000, auth.GasPrice, data)
-
-	signedTx, err := auth.Signer(auth.From, tx)
+	err = ethereum.Client.SendTransaction(context.Background(), signedTx)
 	if err != nil {
-		log.Printf("Error signing transaction: %v", err)
+		log.Printf("Error sending transaction: %v", err)
 		c.JSON(500, gin.H{"error": "Internal server error"})
 		return
 	}
 
-	err = ethereum.Client.SendTransaction(context.Background(), signedTx)
+	// The receipt is needed to account for ETH refunded by the router, and
+	// for the events of a hook whose adapter decodes them
+	adapter, err := hookadapters.Default.Lookup(poolKey.Hooks)
 	if err != nil {
-		log.Printf("Error sending transaction: %v", err)
+		log.Printf("Error resolving the hook adapter: %v", err)
 		c.JSON(500, gin.H{"error": "Internal server error"})
 		return
 	}
+	hasEvents := adapter != nil && adapter.HasEvents()
+	var receipt *types.Receipt
+	if value.Sign() > 0 || hasEvents {
+		ctx, cancel := context.WithTimeout(context.Background(), swapReceiptTimeout)
+		receipt, err = bind.WaitMined(ctx, ethereum.Client, signedTx)
+		cancel()
+		if err != nil {
+			log.Printf("Error waiting for swap: %v", err)
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to wait for swap %s: %v", signedTx.Hash().Hex(), err)})
+			return
+		}
+		if receipt.Status == types.ReceiptStatusFailed {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("swap %s reverted", signedTx.Hash().Hex()), "txHash": signedTx.Hash().Hex()})
+			return
+		}
+	}
+
+	var native gin.H
+	if value.Sign() > 0 {
+		before := balance0Before
+		if !zeroForOne {
+			before = balance1Before
+		}
+		if native, err = nativeRefund(auth.From, before, value, receipt); err != nil {
+			log.Printf("Error accounting for the ETH sent: %v", err)
+			c.JSON(500, gin.H{"error": "Internal server error"})
+			return
+		}
+	}
 
 	balance0After, err := utils.GetBalance(currency0, auth.From)
 	if err != nil {
@@ -116,10 +223,27 @@ func Swap(c *gin.Context) {
 	delta0 := new(big.Int).Sub(balance0After, balance0Before)
 	delta1 := new(big.Int).Sub(balance1After, balance1Before)
 
-	c.JSON(200, gin.H{
+	response := gin.H{
 		"txHash":         signedTx.Hash().Hex(),
+		"router":         router,
 		"balancesBefore": gin.H{"currency0": balance0Before.String(), "currency1": balance1Before.String()},
 		"balancesAfter":  gin.H{"currency0": balance0After.String(), "currency1": balance1After.String()},
 		"deltaBalances":  gin.H{"currency0": delta0.String(), "currency1": delta1.String()},
-	})
+	}
+	if native != nil {
+		response["native"] = native
+	}
+	if hasEvents {
+		events, err := adapter.DecodeEvents(poolKey.Hooks, receipt.Logs)
+		if err != nil {
+			log.Printf("Error decoding hook events: %v", err)
+			c.JSON(500, gin.H{"error": "Internal server error"})
+			return
+		}
+		if events == nil {
+			events = []hookadapters.Event{}
+		}
+		response["hookEvents"] = events
+	}
+	c.JSON(200, response)
 }
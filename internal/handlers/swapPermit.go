@@ -1,18 +1,16 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"math/big"
 	"time"
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+	"uniswap-v4-rpc/internal/sponsorship"
 	"uniswap-v4-rpc/pkg/utils"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,25 +21,40 @@ func SwapPermit(c *gin.Context) {
 		Amount      string `json:"amount" binding:"required"`
 		ZeroForOne  bool   `json:"zeroForOne"`
 		UserAddress string `json:"userAddress" binding:"required"`
-		PrivateKey  string `json:"privateKey" binding:"required"`
+		// PermitSignature is the owner's signature over the swap permit,
+		// used instead of privateKey
+		PermitSignature string `json:"permitSignature"`
+		PoolFee
+		HookDataOption
+		ClaimOptions
+		PermitAuth
+		TxOptions
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	exported, handled := req.exported(c)
+	if handled {
+		return
+	}
 
 	currency0 := common.HexToAddress(req.Currency0)
 	currency1 := common.HexToAddress(req.Currency1)
+	if err := rejectNative(currency0, currency1); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	amountSpecified, ok := new(big.Int).SetString(req.Amount, 10)
 	if !ok {
 		c.JSON(400, gin.H{"error": "Invalid amount"})
 		return
 	}
 	userAddress := common.HexToAddress(req.UserAddress)
-	alicePrivKey, err := crypto.HexToECDSA(req.PrivateKey)
+	userSigner, err := req.permitSigner(req.PermitSignature)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid private key"})
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -49,10 +62,14 @@ func SwapPermit(c *gin.Context) {
 	sqrtPriceLimitX96, _ := new(big.Int).SetString("4295128740", 10)
 
 	fmt.Printf("Users's address: %s\n", userAddress.Hex())
-	fmt.Printf("Users's private key: 0x%x\n", crypto.FromECDSA(alicePrivKey))
 
 	// Create the pool key
-	poolKey := createPoolKey(currency0, currency1, ethereum.HookAddress)
+	poolKey := req.poolKey(currency0, currency1)
+	hookData, status, err := req.hookData(poolKey.Hooks, hookadapters.Swap)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Prepare swap parameters
 	swapParams := struct {
@@ -65,34 +82,49 @@ func SwapPermit(c *gin.Context) {
 		SqrtPriceLimitX96: sqrtPriceLimitX96,
 	}
 
-	testSettings := struct {
-		TakeClaims      bool
-		SettleUsingBurn bool
-	}{
-		TakeClaims:      false,
-		SettleUsingBurn: false,
-	}
-
 	log.Printf("PoolKey: currency0=%s, currency1=%s, fee=%d, tickSpacing=%d, hooks=%s",
 		poolKey.Currency0.Hex(), poolKey.Currency1.Hex(), poolKey.Fee, poolKey.TickSpacing, poolKey.Hooks.Hex())
 	log.Printf("SwapParams: zeroForOne=%v, amountSpecified=%s, sqrtPriceLimitX96=%s",
 		swapParams.ZeroForOne, swapParams.AmountSpecified.String(), swapParams.SqrtPriceLimitX96.String())
 
 	// Prepare permit data
-	deadline := big.NewInt(time.Now().Unix() + 3600) // 1 hour from now
+	deadline, err := req.deadline(big.NewInt(time.Now().Unix() + 3600)) // 1 hour from now
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 	value := new(big.Int).Mul(amountSpecified, big.NewInt(11))
 	value = value.Div(value, big.NewInt(10)) // Increase by 10% to account for fees and slippage
 
-	log.Printf("Token Address (currency0): %s", currency0.Hex())
+	// The router pulls the input token, so that is the token the permit is for
+	permitToken := currency0
+	if !zeroForOne {
+		permitToken = currency1
+	}
+
+	log.Printf("Token Address: %s", permitToken.Hex())
 	log.Printf("Spender Address (SwapRouterAddress): %s", ethereum.SwapRouterAddress.Hex())
 	log.Printf("User Address: %s", userAddress.Hex())
 	log.Printf("Value: %s", value.String())
 	log.Printf("Deadline: %s", deadline.String())
 
-	// Generate permit signature
-	v, r, s, err := utils.GeneratePermitSignature(currency0, userAddress, ethereum.SwapRouterAddress, value, deadline, alicePrivKey)
+	// Generate permit signature, or take the owner's
+	permit, err := ownerPermit(permitToken, userAddress, ethereum.SwapRouterAddress, value, deadline, userSigner, req.PermitSignature)
+	if err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to generate permit signature: %v", err)})
+		return
+	}
+
+	// Check the permit against chain state before paying gas to relay it
+	if err := utils.ValidatePermit(permit); err != nil {
+		c.JSON(permitErrorStatus(err), gin.H{"error": fmt.Sprintf("Invalid permit: %v", err)})
+		return
+	}
+
+	// The router takes the permit as v, r and s
+	v, r, sig, err := permit.VRS()
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to generate permit signature: %v", err)})
+		c.JSON(permitErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -101,20 +133,24 @@ func SwapPermit(c *gin.Context) {
 		userAddress,
 		poolKey,
 		swapParams,
-		testSettings,
-		[]byte{}, // hookData
+		req.testSettings(),
+		hookData,
 		deadline,
 		v,
 		r,
-		s,
+		sig,
 	)
 	if err != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("Error packing data: %v", err)})
 		return
 	}
-	chainID, _ := ethereum.Client.ChainID(context.Background())
 
-	auth, _ := bind.NewKeyedTransactorWithChainID(ethereum.PrivateKey, chainID)
+	// The permit authorizes the router, so the caller can submit the swap
+	// from any account without the relayer pool or sponsorship
+	if exported {
+		respondExported(c, req.TxOptions, []txCall{{to: ethereum.SwapRouterAddress, gasLimit: 1000000, data: data}})
+		return
+	}
 
 	balance0Before, err := utils.GetBalance(currency0, userAddress)
 	if err != nil {
@@ -129,32 +165,33 @@ func SwapPermit(c *gin.Context) {
 		return
 	}
 
-	// Create and send the transaction
-	nonce, err := ethereum.Client.PendingNonceAt(context.Background(), auth.From)
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error fetching nonce: %v", err)})
-		return
-	}
-
-	gasPrice, err := ethereum.Client.SuggestGasPrice(context.Background())
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error fetching gas price: %v", err)})
-		return
-	}
-
-	tx := types.NewTransaction(nonce, ethereum.SwapRouterAddress, big.NewInt(0), 1000000, gasPrice, data)
-
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), ethereum.PrivateKey)
-	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error signing transaction: %v", err)})
-		return
-	}
-
-	err = ethereum.Client.SendTransaction(context.Background(), signedTx)
+	// Optionally charge a relayer fee in the input token, collected with a
+	// second permit that is only valid once the swap permit has been used
+	calls := []txCall{{to: ethereum.SwapRouterAddress, gasLimit: 1000000, data: data}}
+	relayerFee := ethereum.Sponsorship.RelayerFee(amountSpecified)
+	if relayerFee.Sign() > 0 {
+		// The fee permit names the relayer, which is only known once the
+		// relay starts, so the server must be able to sign it
+		if userSigner == nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("a relayer fee of %s is charged on this route, which requires privateKey", relayerFee.String())})
+			return
+		}
+		calls = append(calls, relayerFeeCalls(permitToken, userAddress, userSigner, relayerFee, deadline, permit.Nonce, 1)...)
+	}
+
+	// Relay the transaction from a pool account, which pays for gas
+	sponsorReq := sponsorship.Request{
+		User:    userAddress,
+		PoolID:  poolKey.ID(),
+		Tokens:  []common.Address{permitToken},
+		Amounts: []*big.Int{amountSpecified},
+	}
+	sent, relayerAddress, err := sponsoredRelay(sponsorReq, calls, relayerFee, permitToken)
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Error sending transaction: %v", err)})
+		c.JSON(relayErrorStatus(err), gin.H{"error": fmt.Sprintf("Error relaying transaction: %v", err)})
 		return
 	}
+	signedTx := sent[0]
 
 	balance0After, err := utils.GetBalance(currency0, userAddress)
 	if err != nil {
@@ -174,6 +211,8 @@ func SwapPermit(c *gin.Context) {
 
 	c.JSON(200, gin.H{
 		"txHash":         signedTx.Hash().Hex(),
+		"relayer":        relayerAddress.Hex(),
+		"relayerFee":     relayerFee.String(),
 		"message":        "Swap with permit initiated successfully",
 		"balancesBefore": gin.H{"currency0": balance0Before.String(), "currency1": balance1Before.String()},
 		"balancesAfter":  gin.H{"currency0": balance0After.String(), "currency1": balance1After.String()},
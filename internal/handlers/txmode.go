@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/safe"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+)
+
+// Output modes for write methods.
+const (
+	// ModeSend signs with the server key (or a relayer) and broadcasts
+	ModeSend = "send"
+	// ModeUnsigned returns the transaction fields for the caller to sign
+	ModeUnsigned = "unsigned"
+	// ModeSafe returns Safe transaction service proposals executed by a Safe
+	ModeSafe = "safe"
+)
+
+// TxOptions is embedded in every write request and chooses what the server
+// does with the transaction it builds.
+type TxOptions struct {
+	Mode string `json:"mode"`
+	// From is the account that will sign in unsigned mode. It is used for
+	// gas estimation and the suggested nonce.
+	From string `json:"from"`
+	// SafeAddress is the Safe executing the calls in safe mode. SafeNonce
+	// defaults to the Safe's current nonce.
+	SafeAddress string  `json:"safeAddress"`
+	SafeNonce   *uint64 `json:"safeNonce"`
+}
+
+func (o TxOptions) mode() (string, error) {
+	switch strings.ToLower(o.Mode) {
+	case "", ModeSend:
+		return ModeSend, nil
+	case ModeUnsigned:
+		return ModeUnsigned, nil
+	case ModeSafe:
+		if !common.IsHexAddress(o.SafeAddress) {
+			return "", fmt.Errorf("safe mode requires a valid safeAddress")
+		}
+		return ModeSafe, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q", o.Mode)
+	}
+}
+
+// exported reports whether the request asks for the transaction to be handed
+// back instead of sent. It writes a 400 response for an unknown mode, in
+// which case handled is true.
+func (o TxOptions) exported(c *gin.Context) (exported bool, handled bool) {
+	mode, err := o.mode()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return false, true
+	}
+	return mode != ModeSend, false
+}
+
+// txCall is one transaction a write method wants executed. buildData, when
+// set, is called with the sending account once it is known.
+type txCall struct {
+	to        common.Address
+	value     *big.Int
+	gasLimit  uint64
+	data      []byte
+	buildData func(sender common.Address) ([]byte, error)
+	// collectsFee marks the relayed call that moves the relayer fee, which
+	// is only recorded once it succeeds
+	collectsFee bool
+	// onMined is called with the receipt of a relayed call, or nil when
+	// none arrived before the relayer's receipt timeout
+	onMined func(*types.Receipt)
+}
+
+// UnsignedTx is a transaction ready to be signed by the caller.
+type UnsignedTx struct {
+	From                 *common.Address `json:"from,omitempty"`
+	To                   common.Address  `json:"to"`
+	Data                 hexutil.Bytes   `json:"data"`
+	Value                *hexutil.Big    `json:"value"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Nonce                *hexutil.Uint64 `json:"nonce,omitempty"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+	GasEstimateError     string          `json:"gasEstimateError,omitempty"`
+}
+
+// buildUnsignedTxs fills in gas, fee fields, chain ID and, when from is known,
+// consecutive nonces for calls. Gas is estimated from the node and falls back
+// to the call's default limit when estimation fails, for example because a
+// previous call in the batch has not been mined yet.
+func buildUnsignedTxs(from *common.Address, calls []txCall) ([]UnsignedTx, error) {
+	ctx := context.Background()
+
+	chainID, err := ethereum.Client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	var gasPrice, maxFee, tip *big.Int
+	header, err := ethereum.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block: %v", err)
+	}
+	if header.BaseFee != nil {
+		tip, err = ethereum.Client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch gas tip: %v", err)
+		}
+		maxFee = new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip)
+	} else {
+		gasPrice, err = ethereum.Client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch gas price: %v", err)
+		}
+	}
+
+	var nonce uint64
+	if from != nil {
+		nonce, err = ethereum.Client.PendingNonceAt(ctx, *from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce: %v", err)
+		}
+	}
+
+	txs := make([]UnsignedTx, 0, len(calls))
+	for i, call := range calls {
+		value := call.value
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		data := call.data
+		if call.buildData != nil {
+			var sender common.Address
+			if from != nil {
+				sender = *from
+			}
+			data, err = call.buildData(sender)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		tx := UnsignedTx{
+			From:    from,
+			To:      call.to,
+			Data:    data,
+			Value:   (*hexutil.Big)(value),
+			Gas:     hexutil.Uint64(call.gasLimit),
+			ChainID: (*hexutil.Big)(chainID),
+		}
+		if maxFee != nil {
+			tx.MaxFeePerGas = (*hexutil.Big)(maxFee)
+			tx.MaxPriorityFeePerGas = (*hexutil.Big)(tip)
+		} else {
+			tx.GasPrice = (*hexutil.Big)(gasPrice)
+		}
+		if from != nil {
+			n := hexutil.Uint64(nonce + uint64(i))
+			tx.Nonce = &n
+		}
+
+		msg := goethereum.CallMsg{To: &call.to, Value: value, Data: data}
+		if from != nil {
+			msg.From = *from
+		}
+		if estimate, err := ethereum.Client.EstimateGas(ctx, msg); err == nil {
+			// Leave headroom since state can move before the caller submits
+			tx.Gas = hexutil.Uint64(estimate * 12 / 10)
+		} else {
+			tx.GasEstimateError = err.Error()
+		}
+
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// respondExported writes the transactions for calls in the requested output
+// mode instead of sending them.
+func respondExported(c *gin.Context, opts TxOptions, calls []txCall) {
+	if mode, _ := opts.mode(); mode == ModeSafe {
+		respondSafe(c, opts, calls)
+		return
+	}
+
+	var from *common.Address
+	if opts.From != "" {
+		if !common.IsHexAddress(opts.From) {
+			c.JSON(400, gin.H{"error": "Invalid from address"})
+			return
+		}
+		address := common.HexToAddress(opts.From)
+		from = &address
+	}
+
+	txs, err := buildUnsignedTxs(from, calls)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to build unsigned transactions: %v", err)})
+		return
+	}
+	c.JSON(200, gin.H{
+		"mode":         ModeUnsigned,
+		"transactions": txs,
+	})
+}
+
+// respondSafe writes one Safe proposal per call with consecutive Safe nonces.
+// The calls are made by the Safe, so buildData gets the Safe address.
+func respondSafe(c *gin.Context, opts TxOptions, calls []txCall) {
+	ctx := context.Background()
+	safeAddress := common.HexToAddress(opts.SafeAddress)
+
+	chainID, err := ethereum.Client.ChainID(ctx)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get chain ID: %v", err)})
+		return
+	}
+
+	var nonce uint64
+	if opts.SafeNonce != nil {
+		nonce = *opts.SafeNonce
+	} else {
+		current, err := safe.Nonce(ctx, ethereum.Client, safeAddress)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		nonce = current.Uint64()
+	}
+
+	proposals := make([]safe.Proposal, 0, len(calls))
+	for i, call := range calls {
+		data := call.data
+		if call.buildData != nil {
+			data, err = call.buildData(safeAddress)
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		tx := safe.NewTransaction(call.to, call.value, data, new(big.Int).SetUint64(nonce+uint64(i)))
+		proposals = append(proposals, tx.Proposal(chainID, safeAddress))
+	}
+
+	c.JSON(200, gin.H{
+		"mode":      ModeSafe,
+		"safe":      safeAddress.Hex(),
+		"proposals": proposals,
+	})
+}
+
+// sendErrorStatus maps a transaction the node rejected, for example with a
+// nonce too low, an underpriced fee or insufficient funds, to 400, and a
+// failure to reach the node to 500.
+func sendErrorStatus(err error) int {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return 400
+	}
+	return 500
+}
+
+// SendRawTransaction broadcasts a transaction the caller signed, typically
+// one built in unsigned mode.
+func SendRawTransaction(c *gin.Context) {
+	var req struct {
+		RawTransaction string `json:"rawTransaction" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	raw, err := hexutil.Decode(req.RawTransaction)
+	if err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid raw transaction hex: %v", err)})
+		return
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid raw transaction: %v", err)})
+		return
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid transaction signature: %v", err)})
+		return
+	}
+
+	if err := ethereum.Client.SendTransaction(context.Background(), tx); err != nil {
+		c.JSON(sendErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to send transaction: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"txHash": tx.Hash().Hex(),
+		"from":   sender.Hex(),
+	})
+}
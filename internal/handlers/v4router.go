@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4actions"
+)
+
+// maxUint128 is the largest V4Router amount, used when no input limit is given.
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// swapRouterMode returns the router a swap goes through, the configured
+// default unless the request picks one, with the status for its error.
+func swapRouterMode(value string) (string, int, error) {
+	if value == "" {
+		value = ethereum.SwapRouterMode
+	}
+	switch value {
+	case ethereum.SwapRouterTest:
+		return value, 0, nil
+	case ethereum.SwapRouterV4:
+		if !ethereum.HasV4Router() {
+			return "", 503, fmt.Errorf("V4Router is not configured, set v4_router_address")
+		}
+		return value, 0, nil
+	}
+	return "", 400, fmt.Errorf("invalid router %q, expected %q or %q", value, ethereum.SwapRouterTest, ethereum.SwapRouterV4)
+}
+
+// packExecuteActions encodes plan as a V4Router call. With ETH attached it
+// uses executeActionsAndSweepExcessETH, so what the swap leaves is refunded.
+func packExecuteActions(plan *v4actions.Plan, value *big.Int) ([]byte, error) {
+	unlockData, err := plan.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if value != nil && value.Sign() > 0 {
+		return ethereum.V4RouterABI.Pack("executeActionsAndSweepExcessETH", unlockData)
+	}
+	return ethereum.V4RouterABI.Pack("executeActions", unlockData)
+}
+
+// v4RouterSwapPlan swaps through one pool, following the PoolSwapTest sign
+// convention: a negative amountSpecified is an exact input, a positive one
+// an exact output. limit is the minimum output of an exact input or the
+// maximum input of an exact output, nil for none.
+func v4RouterSwapPlan(poolKey ethereum.PoolKey, zeroForOne bool, amountSpecified, limit *big.Int, hookData []byte) *v4actions.Plan {
+	currencyIn, currencyOut := poolKey.Currency0, poolKey.Currency1
+	if !zeroForOne {
+		currencyIn, currencyOut = currencyOut, currencyIn
+	}
+	amount := new(big.Int).Abs(amountSpecified)
+
+	plan := &v4actions.Plan{}
+	if amountSpecified.Sign() < 0 {
+		if limit == nil {
+			limit = big.NewInt(0)
+		}
+		return plan.SwapExactInSingle(v4actions.ExactInputSingleParams{
+			PoolKey:           poolKey,
+			ZeroForOne:        zeroForOne,
+			AmountIn:          amount,
+			AmountOutMinimum:  limit,
+			SqrtPriceLimitX96: big.NewInt(0),
+			HookData:          hookData,
+		}).SettleAll(currencyIn, amount).TakeAll(currencyOut, limit)
+	}
+
+	if limit == nil {
+		limit = maxUint128
+	}
+	return plan.SwapExactOutSingle(v4actions.ExactOutputSingleParams{
+		PoolKey:           poolKey,
+		ZeroForOne:        zeroForOne,
+		AmountOut:         amount,
+		AmountInMaximum:   limit,
+		SqrtPriceLimitX96: big.NewInt(0),
+		HookData:          hookData,
+	}).SettleAll(currencyIn, limit).TakeAll(currencyOut, amount)
+}
+
+// parseAmountLimit parses the optional swap limit.
+func parseAmountLimit(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	limit, ok := new(big.Int).SetString(value, 10)
+	if !ok || limit.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amountLimit %q", value)
+	}
+	return limit, nil
+}
@@ -0,0 +1,106 @@
+// Package hookadapters is the plugin interface for hook support. An adapter
+// teaches the server about one kind of hook: the addresses or bytecode it
+// is deployed with, the hookData each operation takes, the state it keeps
+// and the events it emits. Handlers go through the adapter of a pool's hook,
+// so supporting a new hook means registering an adapter, not editing them.
+package hookadapters
+
+import (
+	"encoding/json"
+	"errors"
+
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Operation is a PoolManager call that passes hookData to the pool's hook.
+type Operation string
+
+const (
+	Initialize      Operation = "initialize"
+	Swap            Operation = "swap"
+	AddLiquidity    Operation = "addLiquidity"
+	RemoveLiquidity Operation = "removeLiquidity"
+	Donate          Operation = "donate"
+)
+
+// Operations are the operations hookData is encoded for.
+var Operations = []Operation{Initialize, Swap, AddLiquidity, RemoveLiquidity, Donate}
+
+// ErrNotHandled is returned by EncodeHookData when the adapter leaves the
+// request's hookData to the default encoding, raw hex or the hook's
+// registered schema.
+var ErrNotHandled = errors.New("hookData is not handled by the adapter")
+
+// Match lists what an adapter handles: deployments by address, and any
+// deployment of the same runtime bytecode by its keccak256 hash.
+type Match struct {
+	Addresses  []common.Address
+	CodeHashes []common.Hash
+}
+
+// Event is a hook event decoded from a transaction's logs.
+type Event struct {
+	Event    string                 `json:"event"`
+	Address  string                 `json:"address"`
+	LogIndex uint                   `json:"logIndex"`
+	Fields   map[string]interface{} `json:"fields"`
+}
+
+// Adapter is the server side support of a kind of hook. Adapters embed Base
+// and override what their hook needs.
+type Adapter interface {
+	// Name identifies the adapter in config and responses
+	Name() string
+	// Match is what the adapter handles, besides the addresses bound to it
+	// in config
+	Match() Match
+	// ABI is read by the hook view routes when no ABI is configured for a
+	// deployment, nil for none
+	ABI() *abi.ABI
+	// EncodeHookData turns a request's hookData into the bytes op passes to
+	// the hook, or returns ErrNotHandled
+	EncodeHookData(op Operation, hook common.Address, hookData json.RawMessage) ([]byte, error)
+	// State reads what the hook keeps for a pool
+	State(hook common.Address, poolKey ethereum.PoolKey) (map[string]interface{}, error)
+	// HasEvents reports whether DecodeEvents can find any, so routes only
+	// wait for a receipt to decode when it can
+	HasEvents() bool
+	// DecodeEvents decodes the hook's events among logs
+	DecodeEvents(hook common.Address, logs []*types.Log) ([]Event, error)
+}
+
+// Base is the default of each optional Adapter method: no ABI, the default
+// hookData encoding, no state and no events.
+type Base struct{}
+
+func (Base) Match() Match { return Match{} }
+
+func (Base) ABI() *abi.ABI { return nil }
+
+func (Base) EncodeHookData(Operation, common.Address, json.RawMessage) ([]byte, error) {
+	return nil, ErrNotHandled
+}
+
+func (Base) State(common.Address, ethereum.PoolKey) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (Base) HasEvents() bool { return false }
+
+func (Base) DecodeEvents(common.Address, []*types.Log) ([]Event, error) {
+	return nil, nil
+}
+
+// ParseOperation parses an operation name.
+func ParseOperation(name string) (Operation, error) {
+	for _, op := range Operations {
+		if string(op) == name {
+			return op, nil
+		}
+	}
+	return "", errors.New("unknown operation " + name)
+}
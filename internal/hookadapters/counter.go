@@ -0,0 +1,42 @@
+package hookadapters
+
+import (
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CounterName is the name of the Counter adapter.
+const CounterName = "counter"
+
+func init() {
+	Default.MustRegister(Counter{})
+}
+
+// Counter is the adapter of the bundled Counter hook, and the reference for
+// new adapters. Counter reads no hookData and emits no events, so it keeps
+// Base's defaults for them. Its runtime code embeds the PoolManager it was
+// deployed with, so deployments are bound by address in config rather than
+// matched by code hash.
+type Counter struct {
+	Base
+}
+
+func (Counter) Name() string { return CounterName }
+
+func (Counter) ABI() *abi.ABI { return &ethereum.CounterABI }
+
+// State returns the callback counters Counter keeps for the pool.
+func (Counter) State(hook common.Address, poolKey ethereum.PoolKey) (map[string]interface{}, error) {
+	stats, err := ethereum.GetHookStats(hook, poolKey.ID())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"beforeSwapCount":            stats.BeforeSwap.String(),
+		"afterSwapCount":             stats.AfterSwap.String(),
+		"beforeAddLiquidityCount":    stats.BeforeAddLiquidity.String(),
+		"beforeRemoveLiquidityCount": stats.BeforeRemoveLiquidity.String(),
+	}, nil
+}
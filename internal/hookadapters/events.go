@@ -0,0 +1,56 @@
+package hookadapters
+
+import (
+	"fmt"
+
+	"uniswap-v4-rpc/pkg/abijson"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DecodeABIEvents decodes the events of contract emitted by hook among logs.
+// Adapters whose hook has an ABI can implement DecodeEvents with it.
+func DecodeABIEvents(contract *abi.ABI, hook common.Address, logs []*types.Log) ([]Event, error) {
+	var events []Event
+	for _, log := range logs {
+		if log.Address != hook || len(log.Topics) == 0 {
+			continue
+		}
+		event, err := contract.EventByID(log.Topics[0])
+		if err != nil || event.Anonymous {
+			continue
+		}
+		values := make(map[string]interface{})
+		if len(log.Data) > 0 {
+			if err := contract.UnpackIntoMap(values, event.Name, log.Data); err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %v", event.Name, err)
+			}
+		}
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+			return nil, fmt.Errorf("failed to decode %s topics: %v", event.Name, err)
+		}
+		fields := make(map[string]interface{}, len(values))
+		for _, input := range event.Inputs {
+			value, ok := values[input.Name]
+			if !ok {
+				continue
+			}
+			// Indexed dynamic values are only logged as their hash
+			if hash, isHash := value.(common.Hash); isHash && input.Type.T != abi.FixedBytesTy {
+				fields[input.Name] = hash.Hex()
+				continue
+			}
+			fields[input.Name] = abijson.Format(input.Type, value)
+		}
+		events = append(events, Event{Event: event.Name, Address: log.Address.Hex(), LogIndex: log.Index, Fields: fields})
+	}
+	return events, nil
+}
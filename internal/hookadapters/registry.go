@@ -0,0 +1,198 @@
+package hookadapters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Registry resolves hooks to the adapters that handle them.
+type Registry struct {
+	mu         sync.RWMutex
+	adapters   map[string]Adapter
+	byAddress  map[common.Address]Adapter
+	byCodeHash map[common.Hash]Adapter
+	// resolved caches code hash lookups, which need the hook's code
+	resolved map[common.Address]Adapter
+}
+
+// NewRegistry returns a registry without adapters.
+func NewRegistry() *Registry {
+	return &Registry{
+		adapters:   make(map[string]Adapter),
+		byAddress:  make(map[common.Address]Adapter),
+		byCodeHash: make(map[common.Hash]Adapter),
+		resolved:   make(map[common.Address]Adapter),
+	}
+}
+
+// Default holds the adapters shipped with the server, which register
+// themselves in init, and the config bindings.
+var Default = NewRegistry()
+
+// Register adds an adapter and indexes what it matches. Names, addresses
+// and code hashes may only be claimed once.
+func (r *Registry) Register(adapter Adapter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := adapter.Name()
+	if _, ok := r.adapters[name]; ok {
+		return fmt.Errorf("hook adapter %q is already registered", name)
+	}
+	match := adapter.Match()
+	for _, address := range match.Addresses {
+		if other, ok := r.byAddress[address]; ok {
+			return fmt.Errorf("hook %s is already handled by adapter %q", address.Hex(), other.Name())
+		}
+	}
+	for _, hash := range match.CodeHashes {
+		if other, ok := r.byCodeHash[hash]; ok {
+			return fmt.Errorf("code hash %s is already handled by adapter %q", hash.Hex(), other.Name())
+		}
+	}
+	r.adapters[name] = adapter
+	for _, address := range match.Addresses {
+		r.byAddress[address] = adapter
+	}
+	for _, hash := range match.CodeHashes {
+		r.byCodeHash[hash] = adapter
+	}
+	return nil
+}
+
+// MustRegister is Register for adapters registered in init.
+func (r *Registry) MustRegister(adapter Adapter) {
+	if err := r.Register(adapter); err != nil {
+		panic(err)
+	}
+}
+
+// Bind hands hook to the adapter called name, overriding what it matches.
+func (r *Registry) Bind(hook common.Address, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	adapter, ok := r.adapters[name]
+	if !ok {
+		return fmt.Errorf("unknown hook adapter %q", name)
+	}
+	r.byAddress[hook] = adapter
+	return nil
+}
+
+// Get returns the adapter called name.
+func (r *Registry) Get(name string) (Adapter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	adapter, ok := r.adapters[name]
+	return adapter, ok
+}
+
+// Lookup returns the adapter of hook, nil when none handles it. Hooks not
+// matched by address are matched by the hash of their code, which is only
+// read when an adapter matches code hashes.
+func (r *Registry) Lookup(hook common.Address) (Adapter, error) {
+	if hook == (common.Address{}) {
+		return nil, nil
+	}
+	r.mu.RLock()
+	adapter, ok := r.byAddress[hook]
+	if !ok {
+		adapter, ok = r.resolved[hook]
+	}
+	byCode := len(r.byCodeHash) > 0
+	r.mu.RUnlock()
+	if ok || !byCode {
+		return adapter, nil
+	}
+
+	code, err := ethereum.Client.CodeAt(context.Background(), hook, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook code: %v", err)
+	}
+	// Nothing is cached before the hook is deployed
+	if len(code) == 0 {
+		return nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	adapter = r.byCodeHash[crypto.Keccak256Hash(code)]
+	r.resolved[hook] = adapter
+	return adapter, nil
+}
+
+// Info describes a registered adapter and the hooks bound to it.
+type Info struct {
+	Name       string   `json:"name"`
+	Addresses  []string `json:"addresses"`
+	CodeHashes []string `json:"codeHashes"`
+	// Bound are the addresses resolved to the adapter without reading code,
+	// those it matches and those bound in config
+	Bound []string `json:"bound"`
+}
+
+// Adapters describes the registered adapters by name.
+func (r *Registry) Adapters() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	infos := make([]Info, 0, len(r.adapters))
+	for name, adapter := range r.adapters {
+		match := adapter.Match()
+		info := Info{Name: name, Addresses: []string{}, CodeHashes: []string{}, Bound: []string{}}
+		for _, address := range match.Addresses {
+			info.Addresses = append(info.Addresses, address.Hex())
+		}
+		for _, hash := range match.CodeHashes {
+			info.CodeHashes = append(info.CodeHashes, hash.Hex())
+		}
+		for hook, bound := range r.byAddress {
+			if bound == adapter {
+				info.Bound = append(info.Bound, hook.Hex())
+			}
+		}
+		sort.Strings(info.Bound)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Init binds the hooks in hook_adapters to their adapters, and the
+// configured hook_address to the Counter adapter unless it is listed. A
+// bound hook without a configured ABI is read with its adapter's.
+func Init(cfg *config.Config) error {
+	bindings := make(map[common.Address]string, len(cfg.HookAdapters)+1)
+	if hook := common.HexToAddress(cfg.HookAddress); hook != (common.Address{}) {
+		bindings[hook] = CounterName
+	}
+	for hook, name := range cfg.HookAdapters {
+		if !common.IsHexAddress(hook) {
+			return fmt.Errorf("invalid hook address %q in hook adapters", hook)
+		}
+		bindings[common.HexToAddress(hook)] = name
+	}
+	for hook, name := range bindings {
+		if err := Default.Bind(hook, name); err != nil {
+			return err
+		}
+	}
+
+	for _, info := range Default.Adapters() {
+		adapter, _ := Default.Get(info.Name)
+		if adapter.ABI() == nil {
+			continue
+		}
+		for _, bound := range info.Bound {
+			if hook := common.HexToAddress(bound); !ethereum.HookViews.Has(hook) {
+				ethereum.HookViews.Register(hook, *adapter.ABI())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package hookdata
+
+import (
+	"fmt"
+
+	"uniswap-v4-rpc/pkg/abijson"
+)
+
+// Encode ABI encodes values, keyed by field name, against the schema.
+// Integers may be JSON numbers or decimal or 0x-prefixed strings, and bytes
+// are 0x-prefixed hex.
+func (s *Schema) Encode(values map[string]interface{}) ([]byte, error) {
+	args, err := s.convert(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHookData, err)
+	}
+	return s.arguments.Pack(args...)
+}
+
+func (s *Schema) convert(values map[string]interface{}) ([]interface{}, error) {
+	names := make([]string, len(s.arguments))
+	positional := make([]interface{}, len(s.arguments))
+	for i, argument := range s.arguments {
+		names[i] = argument.Name
+		positional[i] = values[argument.Name]
+	}
+	if err := abijson.CheckKeys(values, names); err != nil {
+		return nil, err
+	}
+	return abijson.Arguments(s.arguments, positional)
+}
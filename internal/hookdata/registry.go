@@ -0,0 +1,167 @@
+package hookdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/pkg/abijson"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrNoSchema is returned when structured hookData is given for a hook
+	// without a registered schema.
+	ErrNoSchema = errors.New("no hookData schema is registered for the hook")
+	// ErrInvalidHookData is wrapped by errors about the caller's hookData.
+	ErrInvalidHookData = errors.New("invalid hookData")
+)
+
+// IsRequestError reports whether err was caused by the caller's hookData.
+func IsRequestError(err error) bool {
+	return errors.Is(err, ErrNoSchema) || errors.Is(err, ErrInvalidHookData)
+}
+
+// Schema is the ABI layout of a hook's hookData, abi.encode of its fields in
+// order.
+type Schema struct {
+	Fields    []config.HookDataField
+	arguments abi.Arguments
+}
+
+// NewSchema builds the ABI arguments for fields. Every field needs a name,
+// since structured hookData is keyed by it.
+func NewSchema(fields []config.HookDataField) (*Schema, error) {
+	arguments := make(abi.Arguments, 0, len(fields))
+	for _, field := range fields {
+		if field.Name == "" {
+			return nil, fmt.Errorf("hookData field of type %q has no name", field.Type)
+		}
+		typ, err := abi.NewType(field.Type, "", marshaling(field.Components))
+		if err != nil {
+			return nil, fmt.Errorf("hookData field %q: %v", field.Name, err)
+		}
+		arguments = append(arguments, abi.Argument{Name: field.Name, Type: typ})
+	}
+	return &Schema{Fields: fields, arguments: arguments}, nil
+}
+
+func marshaling(fields []config.HookDataField) []abi.ArgumentMarshaling {
+	var components []abi.ArgumentMarshaling
+	for _, field := range fields {
+		components = append(components, abi.ArgumentMarshaling{
+			Name:       field.Name,
+			Type:       field.Type,
+			Components: marshaling(field.Components),
+		})
+	}
+	return components
+}
+
+// Registry maps hook addresses to their hookData schemas.
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[common.Address]*Schema
+}
+
+// NewRegistry loads the schemas in the JSON file at path, an object keyed by
+// hook address, and then those from config, which take precedence. An empty
+// path loads nothing from disk.
+func NewRegistry(schemas map[string][]config.HookDataField, path string) (*Registry, error) {
+	r := &Registry{schemas: make(map[common.Address]*Schema)}
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hookData schemas: %v", err)
+		}
+		var fromFile map[string][]config.HookDataField
+		if err := json.Unmarshal(content, &fromFile); err != nil {
+			return nil, fmt.Errorf("failed to decode hookData schemas: %v", err)
+		}
+		if err := r.load(fromFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.load(schemas); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Registry) load(schemas map[string][]config.HookDataField) error {
+	for hook, fields := range schemas {
+		if !common.IsHexAddress(hook) {
+			return fmt.Errorf("invalid hook address %q in hookData schemas", hook)
+		}
+		if err := r.Register(common.HexToAddress(hook), fields); err != nil {
+			return fmt.Errorf("hookData schema of %s: %v", hook, err)
+		}
+	}
+	return nil
+}
+
+// Register sets the schema of hook.
+func (r *Registry) Register(hook common.Address, fields []config.HookDataField) error {
+	schema, err := NewSchema(fields)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[hook] = schema
+	return nil
+}
+
+// Get returns the schema of hook.
+func (r *Registry) Get(hook common.Address) (*Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[hook]
+	return schema, ok
+}
+
+// All returns the fields of every registered schema by hook address.
+func (r *Registry) All() map[string][]config.HookDataField {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make(map[string][]config.HookDataField, len(r.schemas))
+	for hook, schema := range r.schemas {
+		all[hook.Hex()] = schema.Fields
+	}
+	return all
+}
+
+// Encode turns a request's hookData into bytes for hook. It accepts nothing
+// (empty hookData), a hex string of raw bytes, or an object of the schema's
+// fields, which is ABI encoded against the hook's schema.
+func (r *Registry) Encode(hook common.Address, hookData json.RawMessage) ([]byte, error) {
+	var value interface{}
+	if len(hookData) > 0 {
+		if err := abijson.Decode(hookData, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHookData, err)
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return []byte{}, nil
+	case string:
+		raw, err := abijson.DecodeHex(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHookData, err)
+		}
+		return raw, nil
+	case map[string]interface{}:
+		schema, ok := r.Get(hook)
+		if !ok {
+			return nil, fmt.Errorf("%w %s, pass hookData as hex", ErrNoSchema, hook.Hex())
+		}
+		return schema.Encode(v)
+	default:
+		return nil, fmt.Errorf("%w: expected a hex string or an object", ErrInvalidHookData)
+	}
+}
@@ -0,0 +1,191 @@
+// Package hookviews keeps the ABIs of deployed hooks, so their view functions
+// can be read without code for each hook.
+package hookviews
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"uniswap-v4-rpc/pkg/abijson"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrNoABI is returned for a hook without a registered ABI.
+	ErrNoABI = errors.New("no ABI is registered for the hook")
+	// ErrUnknownView is returned for a method that is not a view function of
+	// the hook's ABI.
+	ErrUnknownView = errors.New("unknown view function")
+	// ErrInvalidArguments wraps errors about the caller's arguments.
+	ErrInvalidArguments = errors.New("invalid arguments")
+)
+
+// View describes a view function of a hook.
+type View struct {
+	Name      string      `json:"name"`
+	Signature string      `json:"signature"`
+	Inputs    []Parameter `json:"inputs"`
+	Outputs   []Parameter `json:"outputs"`
+	method    abi.Method
+}
+
+// Parameter is a named, typed input or output of a view.
+type Parameter struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func parameters(args abi.Arguments) []Parameter {
+	params := make([]Parameter, len(args))
+	for i, arg := range args {
+		params[i] = Parameter{Name: arg.Name, Type: arg.Type.String()}
+	}
+	return params
+}
+
+// Call is a packed view call and how to decode its result.
+type Call struct {
+	Data []byte
+	view View
+}
+
+// Decode unpacks the call's return data into JSON friendly outputs.
+func (c *Call) Decode(out []byte) (map[string]interface{}, error) {
+	values, err := c.view.method.Outputs.Unpack(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", c.view.Name, err)
+	}
+	return abijson.Outputs(c.view.method.Outputs, values), nil
+}
+
+// Registry maps hook addresses to their ABIs.
+type Registry struct {
+	mu   sync.RWMutex
+	abis map[common.Address]abi.ABI
+}
+
+// NewRegistry reads the ABI file of each hook in paths, keyed by hook
+// address.
+func NewRegistry(paths map[string]string) (*Registry, error) {
+	r := &Registry{abis: make(map[common.Address]abi.ABI)}
+	for hook, path := range paths {
+		if !common.IsHexAddress(hook) {
+			return nil, fmt.Errorf("invalid hook address %q in hook ABIs", hook)
+		}
+		parsed, err := readABI(path)
+		if err != nil {
+			return nil, fmt.Errorf("ABI of %s: %v", hook, err)
+		}
+		r.Register(common.HexToAddress(hook), parsed)
+	}
+	return r, nil
+}
+
+// readABI reads a JSON ABI, or the abi of a forge or hardhat artifact.
+func readABI(path string) (abi.ABI, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(content, &artifact); err == nil && len(artifact.ABI) > 0 {
+		content = artifact.ABI
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(content)))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return parsed, nil
+}
+
+// Register sets the ABI of hook.
+func (r *Registry) Register(hook common.Address, parsed abi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.abis[hook] = parsed
+}
+
+// Has reports whether hook has a registered ABI.
+func (r *Registry) Has(hook common.Address) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.abis[hook]
+	return ok
+}
+
+// Hooks returns the addresses with a registered ABI.
+func (r *Registry) Hooks() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hooks := make([]string, 0, len(r.abis))
+	for hook := range r.abis {
+		hooks = append(hooks, hook.Hex())
+	}
+	sort.Strings(hooks)
+	return hooks
+}
+
+// Views returns the view and pure functions of hook's ABI, by name.
+func (r *Registry) Views(hook common.Address) ([]View, error) {
+	r.mu.RLock()
+	parsed, ok := r.abis[hook]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoABI, hook.Hex())
+	}
+	var views []View
+	for _, method := range parsed.Methods {
+		if !method.IsConstant() {
+			continue
+		}
+		views = append(views, View{
+			Name:      method.RawName,
+			Signature: method.Sig,
+			Inputs:    parameters(method.Inputs),
+			Outputs:   parameters(method.Outputs),
+			method:    method,
+		})
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Signature < views[j].Signature })
+	return views, nil
+}
+
+// Pack packs a call of hook's view name with args, positional values as
+// decoded by abijson.Decode. name may be a signature to pick an overload.
+func (r *Registry) Pack(hook common.Address, name string, args []interface{}) (*Call, error) {
+	views, err := r.Views(hook)
+	if err != nil {
+		return nil, err
+	}
+	var matches []View
+	for _, view := range views {
+		if view.Name == name || view.Signature == name {
+			matches = append(matches, view)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("%w %q of %s", ErrUnknownView, name, hook.Hex())
+	case len(matches) > 1:
+		return nil, fmt.Errorf("%w %q of %s is overloaded, name it by signature", ErrUnknownView, name, hook.Hex())
+	}
+	view := matches[0]
+	converted, err := abijson.Arguments(view.method.Inputs, args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	packed, err := view.method.Inputs.Pack(converted...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	return &Call{Data: append(append([]byte{}, view.method.ID...), packed...), view: view}, nil
+}
@@ -0,0 +1,137 @@
+// Package pools keeps the pools the PoolManager has initialized, as
+// announced by its Initialize events.
+package pools
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Pool is a pool announced by an Initialize event, with the price it was
+// initialized at.
+type Pool struct {
+	ID           common.Hash
+	Currency0    common.Address
+	Currency1    common.Address
+	Fee          *big.Int
+	TickSpacing  *big.Int
+	Hooks        common.Address
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Block        uint64
+	TxHash       common.Hash
+	LogIndex     uint
+}
+
+// Filter selects pools. Zero fields match any pool.
+type Filter struct {
+	// Currency matches pools with it on either side
+	Currency  common.Address
+	Currency0 common.Address
+	Currency1 common.Address
+	Hooks     *common.Address
+}
+
+func (f Filter) matches(p *Pool) bool {
+	if f.Currency != (common.Address{}) && p.Currency0 != f.Currency && p.Currency1 != f.Currency {
+		return false
+	}
+	if f.Currency0 != (common.Address{}) && p.Currency0 != f.Currency0 {
+		return false
+	}
+	if f.Currency1 != (common.Address{}) && p.Currency1 != f.Currency1 {
+		return false
+	}
+	return f.Hooks == nil || p.Hooks == *f.Hooks
+}
+
+// Registry holds the pools seen up to the block it is synced to. The hash
+// of that block tells whether the chain it was read from changed since.
+type Registry struct {
+	mu         sync.RWMutex
+	pools      map[common.Hash]*Pool
+	synced     uint64
+	syncedHash common.Hash
+	// started is false until the first sync, since block 0 is a valid
+	// synced block
+	started bool
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{pools: make(map[common.Hash]*Pool)}
+}
+
+// Add records pools seen in blocks up to synced, whose hash is hash. A pool
+// ID is only initialized once, so a pool seen again keeps its first entry.
+func (r *Registry) Add(synced uint64, hash common.Hash, pools ...Pool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range pools {
+		if _, ok := r.pools[pools[i].ID]; !ok {
+			pool := pools[i]
+			r.pools[pool.ID] = &pool
+		}
+	}
+	if !r.started || synced > r.synced {
+		r.synced, r.syncedHash, r.started = synced, hash, true
+	}
+}
+
+// Reset drops every pool, for a chain that went back behind the synced
+// block or replaced it, such as a restarted dev node or a reorg.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pools = make(map[common.Hash]*Pool)
+	r.synced, r.syncedHash, r.started = 0, common.Hash{}, false
+}
+
+// Synced returns the last block the registry has seen and its hash, and
+// false before the first sync.
+func (r *Registry) Synced() (uint64, common.Hash, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.synced, r.syncedHash, r.started
+}
+
+// Get returns the pool with id.
+func (r *Registry) Get(id common.Hash) (Pool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.pools[id]
+	if !ok {
+		return Pool{}, false
+	}
+	return *p, true
+}
+
+// List returns the pools matching filter in the order they were
+// initialized.
+func (r *Registry) List(filter Filter) []Pool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Pool, 0, len(r.pools))
+	for _, p := range r.pools {
+		if filter.matches(p) {
+			list = append(list, *p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Block != list[j].Block {
+			return list[i].Block < list[j].Block
+		}
+		return list[i].LogIndex < list[j].LogIndex
+	})
+	return list
+}
+
+// Len returns how many pools the registry holds.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.pools)
+}
@@ -0,0 +1,256 @@
+package positions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrLabelConflict is returned when a label is already registered to a
+// different position, or the position is registered under another label.
+var ErrLabelConflict = errors.New("position label conflict")
+
+// Position is a labelled position held by the LP router. The salt keeps it
+// apart from other positions over the same tick range. Depositor is the
+// account whose tokens were added, the only one that may withdraw them with
+// a permit. Fee is the pool key's fee, 0 for entries recorded before it was
+// stored, which were all in static 3000 fee pools.
+type Position struct {
+	Label     string         `json:"label"`
+	PoolID    common.Hash    `json:"poolId"`
+	Currency0 common.Address `json:"currency0"`
+	Currency1 common.Address `json:"currency1"`
+	Fee       uint32         `json:"fee,omitempty"`
+	TickLower int            `json:"tickLower"`
+	TickUpper int            `json:"tickUpper"`
+	Salt      common.Hash    `json:"salt"`
+	Depositor common.Address `json:"depositor"`
+	// Pending entries were recorded before their deposit was mined
+	Pending bool      `json:"pending,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+func (p *Position) same(other *Position) bool {
+	return p.PoolID == other.PoolID && p.TickLower == other.TickLower && p.TickUpper == other.TickUpper && p.Salt == other.Salt
+}
+
+// DeriveSalt returns the salt the server uses for a label when the caller
+// does not pick one.
+func DeriveSalt(label string) common.Hash {
+	return crypto.Keccak256Hash([]byte("position:" + label))
+}
+
+// DepositorSalt binds the salt of an unlabelled permit deposit to the
+// depositor, so the router owned position can only be referenced by them.
+func DepositorSalt(depositor common.Address, salt common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte("deposit:"), depositor.Bytes(), salt.Bytes())
+}
+
+// Registry maps labels to positions, and the salts of unlabelled permit
+// deposits to their depositors. When path is set every change is written to
+// it so the registry survives restarts.
+type Registry struct {
+	mu        sync.Mutex
+	path      string
+	positions map[string]*Position
+	deposits  map[common.Hash]common.Address
+}
+
+// registryFile is the persisted registry. Registries written before deposits
+// were recorded are a bare array of positions.
+type registryFile struct {
+	Positions []*Position                    `json:"positions"`
+	Deposits  map[common.Hash]common.Address `json:"deposits,omitempty"`
+}
+
+// NewRegistry loads the registry at path, or starts an empty in-memory
+// registry when path is empty.
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, positions: make(map[string]*Position), deposits: make(map[common.Hash]common.Address)}
+	if path == "" {
+		return r, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read position registry: %v", err)
+	}
+	var file registryFile
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(content, &file.Positions)
+	} else {
+		err = json.Unmarshal(content, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode position registry: %v", err)
+	}
+	for _, p := range file.Positions {
+		r.positions[p.Label] = p
+	}
+	for salt, depositor := range file.Deposits {
+		r.deposits[salt] = depositor
+	}
+	return r, nil
+}
+
+// RecordDeposit records that the unlabelled position with salt holds
+// depositor's permit deposit.
+func (r *Registry) RecordDeposit(salt common.Hash, depositor common.Address) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.deposits[salt]; ok && existing == depositor {
+		return
+	}
+	r.deposits[salt] = depositor
+	r.save()
+}
+
+// Depositor returns the depositor recorded for the salt of an unlabelled
+// permit deposit.
+func (r *Registry) Depositor(salt common.Hash) (common.Address, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	depositor, ok := r.deposits[salt]
+	return depositor, ok
+}
+
+// Get returns the position registered under label.
+func (r *Registry) Get(label string) (Position, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.positions[label]
+	if !ok {
+		return Position{}, false
+	}
+	return *p, true
+}
+
+// Check reports whether p can be registered: its label must be free or
+// already point at the same position, and no other label may hold it.
+func (r *Registry) Check(p Position) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.check(&p)
+}
+
+func (r *Registry) check(p *Position) error {
+	if existing, ok := r.positions[p.Label]; ok && !existing.same(p) {
+		return fmt.Errorf("%w: %q is registered to [%d, %d] with salt %s", ErrLabelConflict, p.Label, existing.TickLower, existing.TickUpper, existing.Salt.Hex())
+	}
+	for label, existing := range r.positions {
+		if label != p.Label && existing.same(p) {
+			return fmt.Errorf("%w: position is registered as %q", ErrLabelConflict, label)
+		}
+	}
+	return nil
+}
+
+// Register records p under its label. Registering the same position again
+// keeps the original entry, confirming it unless p is pending.
+func (r *Registry) Register(p Position) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(&p); err != nil {
+		return err
+	}
+	if existing, ok := r.positions[p.Label]; ok {
+		if existing.Pending && !p.Pending {
+			existing.Pending = false
+			r.save()
+		}
+		return nil
+	}
+	if p.Created.IsZero() {
+		p.Created = time.Now().UTC()
+	}
+	r.positions[p.Label] = &p
+	r.save()
+	return nil
+}
+
+// Confirm marks the position under label as deposited.
+func (r *Registry) Confirm(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.positions[label]; ok && p.Pending {
+		p.Pending = false
+		r.save()
+	}
+}
+
+// Release drops the label if its deposit is still pending, reporting whether
+// it was dropped.
+func (r *Registry) Release(label string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.positions[label]; !ok || !p.Pending {
+		return false
+	}
+	delete(r.positions, label)
+	r.save()
+	return true
+}
+
+// Remove drops the label, reporting whether it was registered.
+func (r *Registry) Remove(label string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.positions[label]; !ok {
+		return false
+	}
+	delete(r.positions, label)
+	r.save()
+	return true
+}
+
+// List returns the registered positions, optionally only those in poolID,
+// ordered by creation time.
+func (r *Registry) List(poolID *common.Hash) []Position {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []Position{}
+	for _, p := range r.positions {
+		if poolID != nil && p.PoolID != *poolID {
+			continue
+		}
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Created.Equal(list[j].Created) {
+			return list[i].Created.Before(list[j].Created)
+		}
+		return list[i].Label < list[j].Label
+	})
+	return list
+}
+
+// save writes the registry to disk. Callers must hold r.mu.
+func (r *Registry) save() {
+	if r.path == "" {
+		return
+	}
+	list := make([]*Position, 0, len(r.positions))
+	for _, p := range r.positions {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Label < list[j].Label })
+	content, err := json.MarshalIndent(registryFile{Positions: list, Deposits: r.deposits}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(r.path, content, 0600)
+	}
+	if err != nil {
+		// The in-memory registry stays authoritative, so keep serving
+		log.Printf("Failed to persist position registry: %v", err)
+	}
+}
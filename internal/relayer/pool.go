@@ -0,0 +1,398 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"uniswap-v4-rpc/internal/signer"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Selection strategies for picking the next relayer account.
+const (
+	RoundRobin   = "round-robin"
+	LeastPending = "least-pending"
+)
+
+var ErrNoHealthyRelayer = errors.New("no healthy relayer account available")
+
+// Backend is the subset of ethclient.Client the pool needs.
+type Backend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// Account is a relayer account with its own nonce stream.
+type Account struct {
+	signer signer.Signer
+
+	mu          sync.Mutex
+	nonce       uint64
+	nonceLoaded bool
+	// free holds nonces of failed leases, handed out again before new ones
+	// so later transactions are not stuck behind a gap
+	free    []uint64
+	pending int
+	// reload is set when the node's pending nonce must be read again once
+	// no lease is outstanding, after a failure or a receipt timeout
+	reload  bool
+	balance *big.Int
+	healthy bool
+}
+
+// settle ends one outstanding lease. The nonce is only reloaded from the
+// node when none is left, since the node does not count nonces leased but
+// not yet broadcast. Callers hold a.mu.
+func (a *Account) settle(reload bool) {
+	a.pending--
+	a.reload = a.reload || reload
+	if a.pending == 0 && a.reload {
+		a.nonceLoaded, a.free, a.reload = false, nil, false
+	}
+}
+
+// AccountStatus is a point in time view of an Account.
+type AccountStatus struct {
+	Address common.Address `json:"address"`
+	Nonce   uint64         `json:"nonce"`
+	Pending int            `json:"pending"`
+	Balance string         `json:"balance"`
+	Healthy bool           `json:"healthy"`
+}
+
+// Pool hands out relayer accounts so permit transactions can be submitted
+// from several nonce streams in parallel.
+type Pool struct {
+	backend    Backend
+	accounts   []*Account
+	strategy   string
+	minBalance *big.Int
+
+	mu   sync.Mutex
+	next int
+
+	// ReceiptPollInterval and ReceiptTimeout bound how long a sent
+	// transaction counts as pending.
+	ReceiptPollInterval time.Duration
+	ReceiptTimeout      time.Duration
+}
+
+// NewPool creates a pool over signers. Accounts start healthy; call
+// RefreshBalances or Start to apply minBalance.
+func NewPool(backend Backend, signers []signer.Signer, strategy string, minBalance *big.Int) (*Pool, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("relayer pool needs at least one account")
+	}
+	switch strategy {
+	case "":
+		strategy = RoundRobin
+	case RoundRobin, LeastPending:
+	default:
+		return nil, fmt.Errorf("unknown relayer selection %q", strategy)
+	}
+	if minBalance == nil {
+		minBalance = new(big.Int)
+	}
+
+	p := &Pool{
+		backend:             backend,
+		strategy:            strategy,
+		minBalance:          minBalance,
+		ReceiptPollInterval: time.Second,
+		ReceiptTimeout:      5 * time.Minute,
+	}
+	seen := make(map[common.Address]bool)
+	for _, s := range signers {
+		if seen[s.Address()] {
+			return nil, fmt.Errorf("duplicate relayer account %s", s.Address().Hex())
+		}
+		seen[s.Address()] = true
+		p.accounts = append(p.accounts, &Account{signer: s, healthy: true})
+	}
+	return p, nil
+}
+
+// ParseMinBalance parses a wei amount from config, treating "" as zero.
+func ParseMinBalance(value string) (*big.Int, error) {
+	if strings.TrimSpace(value) == "" {
+		return new(big.Int), nil
+	}
+	minBalance, ok := new(big.Int).SetString(strings.TrimSpace(value), 10)
+	if !ok || minBalance.Sign() < 0 {
+		return nil, fmt.Errorf("invalid relayer_min_balance %q", value)
+	}
+	return minBalance, nil
+}
+
+// Lease is an account reserved for one transaction with the nonce to use.
+// Exactly one of Sent or Failed must be called.
+type Lease struct {
+	pool    *Pool
+	account *Account
+	Nonce   uint64
+}
+
+func (l *Lease) Address() common.Address {
+	return l.account.signer.Address()
+}
+
+// Transactor returns TransactOpts signing with the leased account and
+// pinned to the leased nonce.
+func (l *Lease) Transactor(chainID, gasPrice *big.Int) *bind.TransactOpts {
+	auth := signer.NewTransactor(l.account.signer, chainID)
+	auth.Nonce = new(big.Int).SetUint64(l.Nonce)
+	auth.GasPrice = gasPrice
+	return auth
+}
+
+// Next reserves the following nonce of the same account, for transactions
+// that must be mined in order after this one.
+func (l *Lease) Next() *Lease {
+	l.account.mu.Lock()
+	defer l.account.mu.Unlock()
+	next := &Lease{pool: l.pool, account: l.account, Nonce: l.account.nonce}
+	l.account.nonce++
+	l.account.pending++
+	return next
+}
+
+// Sent records that tx was broadcast. The account stays pending until the
+// transaction is mined or the receipt timeout passes; onMined callbacks get
+// the receipt once it is available, or nil after the timeout.
+func (l *Lease) Sent(tx *types.Transaction, onMined ...func(*types.Receipt)) {
+	go l.pool.watch(l.account, tx.Hash(), onMined)
+}
+
+// Failed releases the lease without a broadcast transaction. Its nonce is
+// leased again before new ones, and the nonce is reloaded from the node once
+// the account has no outstanding lease.
+func (l *Lease) Failed() {
+	l.account.mu.Lock()
+	defer l.account.mu.Unlock()
+	l.account.free = append(l.account.free, l.Nonce)
+	l.account.settle(true)
+}
+
+// Send signs a call with the leased account and nonce and broadcasts it,
+// settling the lease either way.
+func (l *Lease) Send(ctx context.Context, to common.Address, data []byte, gasLimit uint64, onMined ...func(*types.Receipt)) (*types.Transaction, error) {
+	backend := l.pool.backend
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		l.Failed()
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		l.Failed()
+		return nil, fmt.Errorf("failed to fetch gas price: %v", err)
+	}
+	tx := types.NewTransaction(l.Nonce, to, big.NewInt(0), gasLimit, gasPrice, data)
+	signedTx, err := l.account.signer.SignTx(tx, chainID)
+	if err != nil {
+		l.Failed()
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := backend.SendTransaction(ctx, signedTx); err != nil {
+		l.Failed()
+		return nil, fmt.Errorf("failed to send transaction: %v", err)
+	}
+	l.Sent(signedTx, onMined...)
+	return signedTx, nil
+}
+
+// Acquire picks a healthy account and reserves its next nonce.
+func (p *Pool) Acquire(ctx context.Context) (*Lease, error) {
+	account, err := p.pick()
+	if err != nil {
+		return nil, err
+	}
+	return p.lease(ctx, account)
+}
+
+// AcquireAccount reserves the next nonce of the account at address, healthy
+// or not, for transactions that must come from it.
+func (p *Pool) AcquireAccount(ctx context.Context, address common.Address) (*Lease, error) {
+	for _, account := range p.accounts {
+		if account.signer.Address() == address {
+			return p.lease(ctx, account)
+		}
+	}
+	return nil, fmt.Errorf("%s is not a relayer account", address.Hex())
+}
+
+func (p *Pool) lease(ctx context.Context, account *Account) (*Lease, error) {
+	account.mu.Lock()
+	defer account.mu.Unlock()
+	if !account.nonceLoaded {
+		nonce, err := p.backend.PendingNonceAt(ctx, account.signer.Address())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce for relayer %s: %v", account.signer.Address().Hex(), err)
+		}
+		account.nonce = nonce
+		account.nonceLoaded = true
+	}
+	lease := &Lease{pool: p, account: account}
+	if len(account.free) > 0 {
+		// The lowest gap first, since every later nonce waits on it
+		sort.Slice(account.free, func(i, j int) bool { return account.free[i] < account.free[j] })
+		lease.Nonce, account.free = account.free[0], account.free[1:]
+	} else {
+		lease.Nonce = account.nonce
+		account.nonce++
+	}
+	account.pending++
+	return lease, nil
+}
+
+func (p *Pool) pick() (*Account, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.accounts)
+	var best *Account
+	bestPending := 0
+	bestIndex := 0
+	for i := 0; i < n; i++ {
+		index := (p.next + i) % n
+		account := p.accounts[index]
+		account.mu.Lock()
+		healthy, pending := account.healthy, account.pending
+		account.mu.Unlock()
+		if !healthy {
+			continue
+		}
+		if p.strategy == RoundRobin {
+			best, bestIndex = account, index
+			break
+		}
+		if best == nil || pending < bestPending {
+			best, bestPending, bestIndex = account, pending, index
+		}
+	}
+	if best == nil {
+		return nil, ErrNoHealthyRelayer
+	}
+	p.next = (bestIndex + 1) % n
+	return best, nil
+}
+
+func (p *Pool) watch(account *Account, hash common.Hash, onMined []func(*types.Receipt)) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.ReceiptTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(p.ReceiptPollInterval)
+	defer ticker.Stop()
+	var receipt *types.Receipt
+	for {
+		var err error
+		receipt, err = p.backend.TransactionReceipt(ctx, hash)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			log.Printf("Relayer %s: error fetching receipt for %s: %v", account.signer.Address().Hex(), hash.Hex(), err)
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("Relayer %s: transaction %s not mined before timeout", account.signer.Address().Hex(), hash.Hex())
+			account.mu.Lock()
+			account.settle(true)
+			account.mu.Unlock()
+			for _, callback := range onMined {
+				callback(nil)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+
+	account.mu.Lock()
+	account.settle(false)
+	account.mu.Unlock()
+	p.refreshBalance(context.Background(), account)
+
+	for _, callback := range onMined {
+		callback(receipt)
+	}
+}
+
+// RefreshBalances reads every account balance and marks accounts below the
+// minimum balance unhealthy.
+func (p *Pool) RefreshBalances(ctx context.Context) {
+	for _, account := range p.accounts {
+		p.refreshBalance(ctx, account)
+	}
+}
+
+func (p *Pool) refreshBalance(ctx context.Context, account *Account) {
+	balance, err := p.backend.BalanceAt(ctx, account.signer.Address(), nil)
+	if err != nil {
+		log.Printf("Relayer %s: failed to fetch balance: %v", account.signer.Address().Hex(), err)
+		return
+	}
+
+	account.mu.Lock()
+	defer account.mu.Unlock()
+	healthy := balance.Cmp(p.minBalance) >= 0
+	if account.healthy && !healthy {
+		log.Printf("Relayer %s: balance %s below minimum %s, marking unhealthy", account.signer.Address().Hex(), balance.String(), p.minBalance.String())
+	} else if !account.healthy && healthy {
+		log.Printf("Relayer %s: balance %s restored, marking healthy", account.signer.Address().Hex(), balance.String())
+	}
+	account.balance = balance
+	account.healthy = healthy
+}
+
+// Start refreshes balances every interval until ctx is cancelled.
+func (p *Pool) Start(ctx context.Context, interval time.Duration) {
+	p.RefreshBalances(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.RefreshBalances(ctx)
+			}
+		}
+	}()
+}
+
+// Status returns the state of every account in the pool.
+func (p *Pool) Status() []AccountStatus {
+	statuses := make([]AccountStatus, 0, len(p.accounts))
+	for _, account := range p.accounts {
+		account.mu.Lock()
+		balance := ""
+		if account.balance != nil {
+			balance = account.balance.String()
+		}
+		statuses = append(statuses, AccountStatus{
+			Address: account.signer.Address(),
+			Nonce:   account.nonce,
+			Pending: account.pending,
+			Balance: balance,
+			Healthy: account.healthy,
+		})
+		account.mu.Unlock()
+	}
+	return statuses
+}
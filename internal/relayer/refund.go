@@ -0,0 +1,117 @@
+package relayer
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TransferFunc returns the calldata sending amount of token to the refunded
+// user, and the gas limit for it.
+type TransferFunc func(token common.Address, amount *big.Int) ([]byte, uint64, error)
+
+// Refund returns the tokens a relayer account pulled from a user when the
+// call meant to spend them fails. The pulls and the spending call report
+// their receipts through the callbacks it hands out, so it must be created
+// with the number of pulls before any is sent.
+type Refund struct {
+	pool     *Pool
+	transfer TransferFunc
+
+	// pulls is released once every pull has a receipt, or none arrived
+	pulls sync.WaitGroup
+
+	mu      sync.Mutex
+	account common.Address
+	pulled  []refundAmount
+}
+
+type refundAmount struct {
+	token  common.Address
+	amount *big.Int
+}
+
+// NewRefund tracks pulls transfers into a relayer account.
+func (p *Pool) NewRefund(pulls int, transfer TransferFunc) *Refund {
+	r := &Refund{pool: p, transfer: transfer}
+	r.pulls.Add(pulls)
+	return r
+}
+
+// From records the relayer account the tokens are pulled into.
+func (r *Refund) From(account common.Address) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.account = account
+}
+
+// Pulled returns the receipt callback of the transfer pulling amount of
+// token into the relayer account.
+func (r *Refund) Pulled(token common.Address, amount *big.Int) func(*types.Receipt) {
+	return func(receipt *types.Receipt) {
+		defer r.pulls.Done()
+		if receipt != nil && receipt.Status == types.ReceiptStatusSuccessful {
+			r.mu.Lock()
+			r.pulled = append(r.pulled, refundAmount{token: token, amount: amount})
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Spent is the receipt callback of the call spending the pulled tokens. When
+// it reverts, every pull that succeeded is sent back. Without a receipt the
+// call may still be mined, so nothing is sent back.
+func (r *Refund) Spent(receipt *types.Receipt) {
+	if receipt == nil {
+		log.Printf("Relayer %s: no receipt for the call spending pulled tokens, not refunding", r.account.Hex())
+		return
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return
+	}
+	r.refund("call " + receipt.TxHash.Hex() + " reverted")
+}
+
+// Abort sends back what was pulled when the spending call is never sent.
+// unsent is the number of pulls that were not sent either.
+func (r *Refund) Abort(unsent int) {
+	for i := 0; i < unsent; i++ {
+		r.pulls.Done()
+	}
+	go r.refund("relay failed")
+}
+
+// refund waits for the receipts of the pulls and sends back those that
+// succeeded.
+func (r *Refund) refund(reason string) {
+	// The pulls are mined before the spending call, but their receipts may
+	// be reported after its
+	r.pulls.Wait()
+
+	r.mu.Lock()
+	account, pulled := r.account, r.pulled
+	r.mu.Unlock()
+	ctx := context.Background()
+	for _, refund := range pulled {
+		data, gasLimit, err := r.transfer(refund.token, refund.amount)
+		if err != nil {
+			log.Printf("Relayer %s: failed to pack refund of %s %s: %v", account.Hex(), refund.amount.String(), refund.token.Hex(), err)
+			continue
+		}
+		lease, err := r.pool.AcquireAccount(ctx, account)
+		if err != nil {
+			log.Printf("Relayer %s: failed to refund %s %s: %v", account.Hex(), refund.amount.String(), refund.token.Hex(), err)
+			continue
+		}
+		tx, err := lease.Send(ctx, refund.token, data, gasLimit)
+		if err != nil {
+			log.Printf("Relayer %s: failed to refund %s %s: %v", account.Hex(), refund.amount.String(), refund.token.Hex(), err)
+			continue
+		}
+		log.Printf("Relayer %s: %s, refunding %s %s in %s", account.Hex(), reason, refund.amount.String(), refund.token.Hex(), tx.Hash().Hex())
+	}
+}
@@ -11,7 +11,50 @@ func SetupRoutes(router *gin.Engine) {
 	router.POST("/initialize", handlers.Initialize)
 	router.POST("/addLiquidity", handlers.AddLiquidity)
 	router.POST("/addLiquidityPermit", handlers.AddLiquidityPermit)
+	router.POST("/removeLiquidity", handlers.RemoveLiquidity)
+	router.POST("/removeLiquidityPermit", handlers.RemoveLiquidityPermit)
+	router.POST("/collectFees", handlers.CollectFees)
+	router.GET("/positions", handlers.ListPositions)
+	router.GET("/positions/:label", handlers.GetPosition)
+	router.POST("/mintPosition", handlers.MintPosition)
+	router.POST("/increasePosition", handlers.IncreasePosition)
+	router.POST("/decreasePosition", handlers.DecreasePosition)
+	router.POST("/transferPosition", handlers.TransferPosition)
+	router.GET("/positionNFT/:tokenId", handlers.GetPositionNFT)
 	router.POST("/performSwap", handlers.Swap)
 	router.POST("/performSwapWithPermit", handlers.SwapPermit)
+	router.POST("/routeSwap", handlers.RouteSwap)
+	router.POST("/splitSwap", handlers.SplitSwap)
+	router.GET("/claims/balance", handlers.GetClaimBalance)
+	router.GET("/claims/allowance", handlers.GetClaimAllowance)
+	router.POST("/claims/transfer", handlers.TransferClaims)
+	router.POST("/claims/transferFrom", handlers.TransferClaimsFrom)
+	router.POST("/claims/approve", handlers.ApproveClaims)
+	router.POST("/claims/setOperator", handlers.SetClaimOperator)
+	router.POST("/donate", handlers.Donate)
+	router.POST("/donatePermit", handlers.DonatePermit)
+	router.POST("/updateDynamicLPFee", handlers.UpdateDynamicLPFee)
+	router.GET("/lpFee", handlers.GetLPFee)
+	router.GET("/pools", handlers.ListPools)
+	router.GET("/pools/:poolId", handlers.GetPool)
+	router.GET("/describeHook", handlers.DescribeHook)
+	router.GET("/hookData/schemas", handlers.HookDataSchemas)
+	router.POST("/hookData/encode", handlers.EncodeHookData)
+	router.GET("/hookStats", handlers.GetHookStats)
+	router.GET("/hooks", handlers.ListHookViews)
+	router.GET("/hooks/:address/views", handlers.ListHookViews)
+	router.POST("/hooks/:address/views/:method", handlers.ReadHookView)
+	router.GET("/hookAdapters", handlers.ListHookAdapters)
+	router.GET("/hooks/:address/state", handlers.GetHookState)
+	router.GET("/hooks/:address/events", handlers.GetHookEvents)
+	router.POST("/eth_sendRawTransaction", handlers.SendRawTransaction)
+	router.GET("/relayers", handlers.RelayerStatus)
+
+	admin := router.Group("/admin", handlers.RequireAdmin)
+	admin.GET("/sponsorship/ledger", handlers.SponsorshipLedger)
+	admin.GET("/protocolFees", handlers.GetProtocolFees)
+	admin.POST("/protocolFees/controller", handlers.SetProtocolFeeController)
+	admin.POST("/protocolFees/setFee", handlers.SetProtocolFee)
+	admin.POST("/protocolFees/collect", handlers.CollectProtocolFees)
 
 }
@@ -0,0 +1,79 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// noPriceLimit returns the sqrt price limit just inside the swap direction's
+// bound, so a quote is only limited by the pool's liquidity.
+func noPriceLimit(zeroForOne bool) *big.Int {
+	if zeroForOne {
+		return new(big.Int).Add(v4math.MinSqrtPrice, big.NewInt(1))
+	}
+	return new(big.Int).Sub(v4math.MaxSqrtPrice, big.NewInt(1))
+}
+
+// SimulatedQuoter quotes hops with an eth_call of the PoolSwapTest router
+// from sender, which needs the input balance and approval for the swap to
+// go through. Hops are simulated against the current state independently,
+// which is exact as long as a route uses each pool once. Native ETH inputs
+// are sent as value: the exact input, or for an exact output the sender's
+// balance, of which the router refunds the rest.
+func SimulatedQuoter(sender common.Address) QuoteFunc {
+	return func(key ethereum.PoolKey, hookData []byte, zeroForOne, exactIn bool, amount *big.Int) (*big.Int, *big.Int, error) {
+		amountSpecified := new(big.Int).Set(amount)
+		if exactIn {
+			amountSpecified.Neg(amountSpecified)
+		}
+		swapParams := struct {
+			ZeroForOne        bool
+			AmountSpecified   *big.Int
+			SqrtPriceLimitX96 *big.Int
+		}{zeroForOne, amountSpecified, noPriceLimit(zeroForOne)}
+		testSettings := struct {
+			TakeClaims      bool
+			SettleUsingBurn bool
+		}{false, false}
+
+		data, err := ethereum.SwapRouterABI.Pack("swap", key, swapParams, testSettings, orEmpty(hookData))
+		if err != nil {
+			return nil, nil, err
+		}
+		value := new(big.Int)
+		currencyIn := key.Currency0
+		if !zeroForOne {
+			currencyIn = key.Currency1
+		}
+		if currency := ethereum.NewCurrency(currencyIn); currency.IsNative() {
+			if exactIn {
+				value.Set(amount)
+			} else if value, err = currency.BalanceOf(sender); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		out, err := ethereum.Client.CallContract(context.Background(), goethereum.CallMsg{From: sender, To: &ethereum.SwapRouterAddress, Value: value, Data: data}, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("swap simulation failed: %v", err)
+		}
+		values, err := ethereum.SwapRouterABI.Unpack("swap", out)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode swap result: %v", err)
+		}
+
+		// The caller pays the negative side of the delta and receives the positive
+		amount0, amount1 := ethereum.DecodeBalanceDelta(values[0].(*big.Int))
+		if zeroForOne {
+			return amount0.Neg(amount0), amount1, nil
+		}
+		return amount1.Neg(amount1), amount0, nil
+	}
+}
@@ -0,0 +1,200 @@
+// Package routing finds, quotes and picks swap paths across the pools the
+// PoolManager has initialized.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4actions"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNoRoute is returned when no path between the currencies can be quoted.
+var ErrNoRoute = errors.New("no route found")
+
+// Hop is one swap of a route, with its amounts once quoted. HookData is
+// passed to the pool's hook, both when quoting and when swapping.
+type Hop struct {
+	PoolKey    ethereum.PoolKey
+	ZeroForOne bool
+	HookData   []byte
+	AmountIn   *big.Int
+	AmountOut  *big.Int
+}
+
+// CurrencyIn returns the currency the hop sells.
+func (h Hop) CurrencyIn() common.Address {
+	if h.ZeroForOne {
+		return h.PoolKey.Currency0
+	}
+	return h.PoolKey.Currency1
+}
+
+// CurrencyOut returns the currency the hop buys.
+func (h Hop) CurrencyOut() common.Address {
+	if h.ZeroForOne {
+		return h.PoolKey.Currency1
+	}
+	return h.PoolKey.Currency0
+}
+
+// Route is a path of hops from the input to the output currency.
+type Route struct {
+	Hops []Hop
+}
+
+// Currencies returns the currencies the route passes through, input first.
+func (r Route) Currencies() []common.Address {
+	currencies := []common.Address{r.Hops[0].CurrencyIn()}
+	for _, hop := range r.Hops {
+		currencies = append(currencies, hop.CurrencyOut())
+	}
+	return currencies
+}
+
+// AmountIn returns the quoted input of the first hop.
+func (r Route) AmountIn() *big.Int {
+	return r.Hops[0].AmountIn
+}
+
+// AmountOut returns the quoted output of the last hop.
+func (r Route) AmountOut() *big.Int {
+	return r.Hops[len(r.Hops)-1].AmountOut
+}
+
+// PathKeys returns the route as a V4Router path. Exact input paths name
+// each hop's output currency and exact output paths each hop's input.
+func (r Route) PathKeys(exactIn bool) []v4actions.PathKey {
+	path := make([]v4actions.PathKey, len(r.Hops))
+	for i, hop := range r.Hops {
+		currency := hop.CurrencyIn()
+		if exactIn {
+			currency = hop.CurrencyOut()
+		}
+		path[i] = v4actions.PathKey{
+			IntermediateCurrency: currency,
+			Fee:                  hop.PoolKey.Fee,
+			TickSpacing:          hop.PoolKey.TickSpacing,
+			Hooks:                hop.PoolKey.Hooks,
+			HookData:             orEmpty(hop.HookData),
+		}
+	}
+	return path
+}
+
+// orEmpty returns data, or empty bytes for the ABI when it is nil.
+func orEmpty(data []byte) []byte {
+	if data == nil {
+		return []byte{}
+	}
+	return data
+}
+
+// SetHookData sets the hookData of each hop to that of its pool's hook.
+// Hops through other hooks keep theirs.
+func SetHookData(routes []Route, hookData map[common.Address][]byte) {
+	for _, route := range routes {
+		for i := range route.Hops {
+			if data, ok := hookData[route.Hops[i].PoolKey.Hooks]; ok {
+				route.Hops[i].HookData = data
+			}
+		}
+	}
+}
+
+// Paths returns every route from currencyIn to currencyOut of at most
+// maxHops hops, visiting each currency at most once.
+func Paths(pools []ethereum.PoolKey, currencyIn, currencyOut common.Address, maxHops int) []Route {
+	var routes []Route
+	visited := map[common.Address]bool{currencyIn: true}
+	var hops []Hop
+
+	var search func(from common.Address)
+	search = func(from common.Address) {
+		if len(hops) == maxHops {
+			return
+		}
+		for _, key := range pools {
+			var hop Hop
+			switch from {
+			case key.Currency0:
+				hop = Hop{PoolKey: key, ZeroForOne: true}
+			case key.Currency1:
+				hop = Hop{PoolKey: key, ZeroForOne: false}
+			default:
+				continue
+			}
+			next := hop.CurrencyOut()
+			if visited[next] {
+				continue
+			}
+			hops = append(hops, hop)
+			if next == currencyOut {
+				routes = append(routes, Route{Hops: append([]Hop(nil), hops...)})
+			} else {
+				visited[next] = true
+				search(next)
+				visited[next] = false
+			}
+			hops = hops[:len(hops)-1]
+		}
+	}
+	search(currencyIn)
+	return routes
+}
+
+// QuoteFunc quotes a swap through one pool with the hookData the swap will
+// pass. With exactIn, amount is the input, otherwise it is the output.
+type QuoteFunc func(key ethereum.PoolKey, hookData []byte, zeroForOne, exactIn bool, amount *big.Int) (amountIn, amountOut *big.Int, err error)
+
+// Quote fills in the amounts of each hop: forwards from the input for exact
+// input routes, backwards from the output for exact output routes.
+func Quote(route Route, exactIn bool, amount *big.Int, quote QuoteFunc) (Route, error) {
+	hops := append([]Hop(nil), route.Hops...)
+	if exactIn {
+		for i := range hops {
+			amountIn, amountOut, err := quote(hops[i].PoolKey, hops[i].HookData, hops[i].ZeroForOne, true, amount)
+			if err != nil {
+				return Route{}, fmt.Errorf("hop %d: %v", i, err)
+			}
+			hops[i].AmountIn, hops[i].AmountOut = amountIn, amountOut
+			amount = amountOut
+		}
+	} else {
+		for i := len(hops) - 1; i >= 0; i-- {
+			amountIn, amountOut, err := quote(hops[i].PoolKey, hops[i].HookData, hops[i].ZeroForOne, false, amount)
+			if err != nil {
+				return Route{}, fmt.Errorf("hop %d: %v", i, err)
+			}
+			hops[i].AmountIn, hops[i].AmountOut = amountIn, amountOut
+			amount = amountIn
+		}
+	}
+	return Route{Hops: hops}, nil
+}
+
+// Best quotes every route and returns the one with the largest output for
+// an exact input, or the smallest input for an exact output. Routes that
+// fail to quote, or quote to nothing, are skipped.
+func Best(routes []Route, exactIn bool, amount *big.Int, quote QuoteFunc) (*Route, error) {
+	var best *Route
+	for _, route := range routes {
+		quoted, err := Quote(route, exactIn, amount, quote)
+		if err != nil || quoted.AmountIn().Sign() <= 0 || quoted.AmountOut().Sign() <= 0 {
+			continue
+		}
+		if best == nil ||
+			(exactIn && quoted.AmountOut().Cmp(best.AmountOut()) > 0) ||
+			(!exactIn && quoted.AmountIn().Cmp(best.AmountIn()) < 0) {
+			best = &quoted
+		}
+	}
+	if best == nil {
+		return nil, ErrNoRoute
+	}
+	return best, nil
+}
@@ -0,0 +1,122 @@
+package routing
+
+import (
+	"math/big"
+)
+
+// Gas estimates for a V4Router swap, used to weigh splitting a swap over
+// more pools against the gas each extra pool costs.
+const (
+	BaseGas = 100000
+	HopGas  = 120000
+)
+
+// Split is an exact input swap divided over several routes.
+type Split struct {
+	// Routes are the quoted routes with a share of the input
+	Routes    []Route
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	// GasEstimate is the estimated gas of executing every route
+	GasEstimate uint64
+	// GasCost is GasEstimate in the output currency, nil when unknown
+	GasCost *big.Int
+	// NetAmountOut is AmountOut less GasCost
+	NetAmountOut *big.Int
+}
+
+// GasCostFunc converts gas units into the output currency. It returns nil
+// when there is no price to convert with.
+type GasCostFunc func(gas uint64) *big.Int
+
+func routeGas(route Route) uint64 {
+	return uint64(HopGas * len(route.Hops))
+}
+
+// newSplit totals the quoted routes and their gas.
+func newSplit(routes []Route, gasCost GasCostFunc) *Split {
+	split := &Split{Routes: routes, AmountIn: new(big.Int), AmountOut: new(big.Int), GasEstimate: BaseGas}
+	for _, route := range routes {
+		split.AmountIn.Add(split.AmountIn, route.AmountIn())
+		split.AmountOut.Add(split.AmountOut, route.AmountOut())
+		split.GasEstimate += routeGas(route)
+	}
+	split.NetAmountOut = new(big.Int).Set(split.AmountOut)
+	if gasCost != nil {
+		if split.GasCost = gasCost(split.GasEstimate); split.GasCost != nil {
+			split.NetAmountOut.Sub(split.NetAmountOut, split.GasCost)
+		}
+	}
+	return split
+}
+
+// Optimize splits amountIn into parts increments and gives each increment
+// to the route whose output it raises the most, charging a route its gas
+// the first time it is used. Routes must not share pools, since each is
+// quoted on its own. The result is compared with sending everything along
+// the single best route, and the one with the larger net output is returned.
+func Optimize(routes []Route, amountIn *big.Int, parts int, quote QuoteFunc, gasCost GasCostFunc) (*Split, error) {
+	if parts < 1 {
+		parts = 1
+	}
+	increment := new(big.Int).Div(amountIn, big.NewInt(int64(parts)))
+	if increment.Sign() == 0 {
+		increment, parts = new(big.Int).Set(amountIn), 1
+	}
+
+	allocated := make([]*big.Int, len(routes))
+	quoted := make([]*Route, len(routes))
+	for i := range allocated {
+		allocated[i] = new(big.Int)
+	}
+
+	for step := 0; step < parts; step++ {
+		size := increment
+		if step == parts-1 {
+			// The last increment takes the rounding remainder
+			size = new(big.Int).Sub(amountIn, new(big.Int).Mul(increment, big.NewInt(int64(parts-1))))
+		}
+
+		best, bestGain := -1, (*big.Int)(nil)
+		var bestRoute Route
+		for i, route := range routes {
+			candidate, err := Quote(route, true, new(big.Int).Add(allocated[i], size), quote)
+			if err != nil || candidate.AmountOut().Sign() <= 0 {
+				continue
+			}
+			gain := new(big.Int).Set(candidate.AmountOut())
+			if quoted[i] != nil {
+				gain.Sub(gain, quoted[i].AmountOut())
+			} else if gasCost != nil {
+				if cost := gasCost(routeGas(route)); cost != nil {
+					gain.Sub(gain, cost)
+				}
+			}
+			if best < 0 || gain.Cmp(bestGain) > 0 {
+				best, bestGain, bestRoute = i, gain, candidate
+			}
+		}
+		if best < 0 {
+			return nil, ErrNoRoute
+		}
+		allocated[best].Add(allocated[best], size)
+		quoted[best] = &bestRoute
+	}
+
+	var used []Route
+	for _, route := range quoted {
+		if route != nil {
+			used = append(used, *route)
+		}
+	}
+	split := newSplit(used, gasCost)
+
+	if len(used) > 1 {
+		if single, err := Best(routes, true, amountIn, quote); err == nil {
+			if alone := newSplit([]Route{*single}, gasCost); alone.NetAmountOut.Cmp(split.NetAmountOut) > 0 {
+				return alone, nil
+			}
+		}
+	}
+	return split, nil
+}
@@ -0,0 +1,160 @@
+// Package safe builds Safe (Gnosis Safe) multisig transactions and the
+// proposal payload accepted by the Safe transaction service.
+package safe
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	goethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Operations a Safe can execute.
+const (
+	Call         uint8 = 0
+	DelegateCall uint8 = 1
+)
+
+var (
+	// DomainTypehash is used by Safe contracts from v1.3.0 on
+	DomainTypehash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	SafeTxTypehash = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+
+	// nonceSelector is the selector of the Safe's nonce() view
+	nonceSelector = crypto.Keccak256([]byte("nonce()"))[:4]
+)
+
+// Transaction is a SafeTx. With zero gas fields and token the Safe uses all
+// available gas and pays no refund, which is what signers expect for
+// proposals executed by one of the owners.
+type Transaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      uint8
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// NewTransaction returns a plain call from the Safe with no gas refund.
+func NewTransaction(to common.Address, value *big.Int, data []byte, nonce *big.Int) *Transaction {
+	if value == nil {
+		value = new(big.Int)
+	}
+	return &Transaction{
+		To:        to,
+		Value:     value,
+		Data:      data,
+		Operation: Call,
+		SafeTxGas: new(big.Int),
+		BaseGas:   new(big.Int),
+		GasPrice:  new(big.Int),
+		Nonce:     nonce,
+	}
+}
+
+// DomainSeparator returns the EIP-712 domain separator of the Safe at address.
+func DomainSeparator(chainID *big.Int, address common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		DomainTypehash.Bytes(),
+		math.U256Bytes(new(big.Int).Set(chainID)),
+		common.LeftPadBytes(address.Bytes(), 32),
+	)
+}
+
+// StructHash returns the EIP-712 struct hash of the SafeTx.
+func (t *Transaction) StructHash() common.Hash {
+	return crypto.Keccak256Hash(
+		SafeTxTypehash.Bytes(),
+		common.LeftPadBytes(t.To.Bytes(), 32),
+		math.U256Bytes(new(big.Int).Set(t.Value)),
+		crypto.Keccak256(t.Data),
+		common.LeftPadBytes([]byte{t.Operation}, 32),
+		math.U256Bytes(new(big.Int).Set(t.SafeTxGas)),
+		math.U256Bytes(new(big.Int).Set(t.BaseGas)),
+		math.U256Bytes(new(big.Int).Set(t.GasPrice)),
+		common.LeftPadBytes(t.GasToken.Bytes(), 32),
+		common.LeftPadBytes(t.RefundReceiver.Bytes(), 32),
+		math.U256Bytes(new(big.Int).Set(t.Nonce)),
+	)
+}
+
+// Hash returns the hash the Safe owners sign, the same value the Safe's
+// getTransactionHash returns.
+func (t *Transaction) Hash(chainID *big.Int, safe common.Address) common.Hash {
+	domain := DomainSeparator(chainID, safe)
+	structHash := t.StructHash()
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain.Bytes(), structHash.Bytes())
+}
+
+// Proposal is the body of POST /api/v1/safes/{address}/multisig-transactions/
+// on the Safe transaction service. Sender and Signature are filled in by the
+// proposing owner after signing ContractTransactionHash.
+type Proposal struct {
+	Safe                    common.Address  `json:"safe"`
+	To                      common.Address  `json:"to"`
+	Value                   string          `json:"value"`
+	Data                    *hexutil.Bytes  `json:"data"`
+	Operation               uint8           `json:"operation"`
+	SafeTxGas               string          `json:"safeTxGas"`
+	BaseGas                 string          `json:"baseGas"`
+	GasPrice                string          `json:"gasPrice"`
+	GasToken                common.Address  `json:"gasToken"`
+	RefundReceiver          common.Address  `json:"refundReceiver"`
+	Nonce                   uint64          `json:"nonce"`
+	ContractTransactionHash common.Hash     `json:"contractTransactionHash"`
+	Sender                  *common.Address `json:"sender"`
+	Signature               *hexutil.Bytes  `json:"signature"`
+	Origin                  string          `json:"origin,omitempty"`
+	ChainID                 *hexutil.Big    `json:"chainId"`
+}
+
+// Proposal returns the transaction service payload for the Safe at address.
+func (t *Transaction) Proposal(chainID *big.Int, safe common.Address) Proposal {
+	p := Proposal{
+		Safe:                    safe,
+		To:                      t.To,
+		Value:                   t.Value.String(),
+		Operation:               t.Operation,
+		SafeTxGas:               t.SafeTxGas.String(),
+		BaseGas:                 t.BaseGas.String(),
+		GasPrice:                t.GasPrice.String(),
+		GasToken:                t.GasToken,
+		RefundReceiver:          t.RefundReceiver,
+		Nonce:                   t.Nonce.Uint64(),
+		ContractTransactionHash: t.Hash(chainID, safe),
+		ChainID:                 (*hexutil.Big)(new(big.Int).Set(chainID)),
+	}
+	// The service expects null rather than 0x for empty calldata
+	if len(t.Data) > 0 {
+		data := hexutil.Bytes(t.Data)
+		p.Data = &data
+	}
+	return p
+}
+
+// Caller is the subset of ethclient.Client needed to read Safe state.
+type Caller interface {
+	CallContract(ctx context.Context, call goethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Nonce reads the current nonce of the Safe at address.
+func Nonce(ctx context.Context, client Caller, address common.Address) (*big.Int, error) {
+	out, err := client.CallContract(ctx, goethereum.CallMsg{To: &address, Data: nonceSelector}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Safe nonce: %v", err)
+	}
+	if len(out) != 32 {
+		return nil, fmt.Errorf("no Safe contract at %s", address.Hex())
+	}
+	return new(big.Int).SetBytes(out), nil
+}
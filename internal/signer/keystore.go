@@ -0,0 +1,25 @@
+package signer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+// NewKeystoreSigner decrypts a go-ethereum (Web3 Secret Storage) keystore
+// file. The decrypted key is only held by the returned signer.
+func NewKeystoreSigner(path, passphrase string) (*LocalSigner, error) {
+	if path == "" {
+		return nil, fmt.Errorf("signer_type keystore requires keystore_path")
+	}
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file: %v", err)
+	}
+	return newLocalSigner(key.PrivateKey), nil
+}
@@ -0,0 +1,54 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LocalSigner holds a private key in memory. It is meant for development
+// chains and for the dev-only permit routes that take the user's key.
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewLocalSigner parses a hex private key, with or without the 0x prefix.
+func NewLocalSigner(hexPrivateKey string) (*LocalSigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexPrivateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return newLocalSigner(key), nil
+}
+
+// NewLocalSignerFromSeed derives a deterministic key from seed, matching
+// forge's makeAddrAndKey.
+func NewLocalSignerFromSeed(seed string) (*LocalSigner, error) {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte(seed)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive private key: %v", err)
+	}
+	return newLocalSigner(key), nil
+}
+
+func newLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *LocalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+func (s *LocalSigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
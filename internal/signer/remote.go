@@ -0,0 +1,150 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// RemoteSigner delegates signing to an HTTP service. The service exposes
+//
+//	GET  /address -> {"address": "0x..."}
+//	POST /sign    {"address": "0x...", "hash": "0x..."} -> {"signature": "0x..."}
+//
+// where signature is 65 bytes [R || S || V]. Every signature is checked to
+// recover to the configured address before it is used.
+type RemoteSigner struct {
+	url     string
+	address common.Address
+	client  *http.Client
+}
+
+type remoteAddressResponse struct {
+	Address common.Address `json:"address"`
+}
+
+type remoteSignRequest struct {
+	Address common.Address `json:"address"`
+	Hash    hexutil.Bytes  `json:"hash"`
+}
+
+type remoteSignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// NewRemoteSigner creates a signer for the service at url. If address is the
+// zero address it is fetched from the service. A nil client uses a client
+// with a 10 second timeout.
+func NewRemoteSigner(url string, address common.Address, client *http.Client) (*RemoteSigner, error) {
+	if url == "" {
+		return nil, errors.New("signer_type remote requires remote_signer_url")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &RemoteSigner{url: strings.TrimRight(url, "/"), address: address, client: client}
+
+	if address == (common.Address{}) {
+		resp, err := client.Get(s.url + "/address")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch remote signer address: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("remote signer returned status %d for address", resp.StatusCode)
+		}
+		var out remoteAddressResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("failed to decode remote signer address: %v", err)
+		}
+		s.address = out.Address
+	}
+	return s, nil
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *RemoteSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signTxWithHash(s, tx, chainID)
+}
+
+func (s *RemoteSigner) SignHash(hash []byte) ([]byte, error) {
+	body, err := json.Marshal(remoteSignRequest{Address: s.address, Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Post(s.url+"/sign", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("remote signer request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out remoteSignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode remote signer response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer returned status %d: %s", resp.StatusCode, out.Error)
+	}
+	if len(out.Signature) != 65 {
+		return nil, fmt.Errorf("remote signer returned %d byte signature", len(out.Signature))
+	}
+
+	sig := []byte(out.Signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if err := verifyHashSignature(hash, sig, s.address); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// NewRemoteSignerHandler serves the RemoteSigner protocol backed by s. It is a
+// local stand-in for a real signing service in development and tests.
+func NewRemoteSignerHandler(s Signer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/address", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, remoteAddressResponse{Address: s.Address()})
+	})
+	mux.HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, remoteSignResponse{Error: "method not allowed"})
+			return
+		}
+		var req remoteSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, remoteSignResponse{Error: err.Error()})
+			return
+		}
+		if req.Address != s.Address() {
+			writeJSON(w, http.StatusForbidden, remoteSignResponse{Error: "unknown account " + req.Address.Hex()})
+			return
+		}
+		sig, err := s.SignHash(req.Hash)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, remoteSignResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, remoteSignResponse{Signature: sig})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,117 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"uniswap-v4-rpc/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions and digests for a single account. Handlers only
+// ever see this interface; key material stays inside this package.
+type Signer interface {
+	// Address returns the account the signer signs for.
+	Address() common.Address
+	// SignTx returns tx signed for chainID.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignHash signs a 32 byte digest and returns the 65 byte [R || S || V]
+	// signature with V in {0, 1}.
+	SignHash(hash []byte) ([]byte, error)
+}
+
+var ErrSignerMismatch = errors.New("signature was not produced by the expected account")
+
+// NewTransactor returns bind.TransactOpts that sign through s.
+func NewTransactor(s Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: s.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(tx, chainID)
+		},
+	}
+}
+
+// signTxWithHash signs tx by handing its signing hash to signHash. It is
+// shared by the signers that only expose raw digest signing.
+func signTxWithHash(s Signer, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	txSigner := types.LatestSignerForChainID(chainID)
+	sig, err := s.SignHash(txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	signed, err := tx.WithSignature(txSigner, sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply signature: %v", err)
+	}
+	sender, err := types.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+	if sender != s.Address() {
+		return nil, fmt.Errorf("%w: got %s, expected %s", ErrSignerMismatch, sender.Hex(), s.Address().Hex())
+	}
+	return signed, nil
+}
+
+// FromConfig builds the server signer selected by signer_type. It defaults to
+// the in-memory private_key signer so existing dev configs keep working.
+func FromConfig(cfg *config.SignerConfig) (Signer, error) {
+	switch strings.ToLower(cfg.SignerType) {
+	case "", "local":
+		if cfg.PrivateKey == "" {
+			return nil, errors.New("signer_type local requires private_key")
+		}
+		return NewLocalSigner(cfg.PrivateKey)
+	case "keystore":
+		passphrase, err := ResolvePassphrase(cfg.KeystorePassphraseEnv, cfg.KeystorePassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeystoreSigner(cfg.KeystorePath, passphrase)
+	case "remote":
+		return NewRemoteSigner(cfg.RemoteSignerURL, common.HexToAddress(cfg.RemoteSignerAddress), nil)
+	default:
+		return nil, fmt.Errorf("unknown signer_type %q", cfg.SignerType)
+	}
+}
+
+// ResolvePassphrase reads a keystore passphrase from the named environment
+// variable, falling back to the contents of file.
+func ResolvePassphrase(env, file string) (string, error) {
+	if env != "" {
+		if passphrase, ok := os.LookupEnv(env); ok {
+			return passphrase, nil
+		}
+	}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %v", err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return "", errors.New("no keystore passphrase configured: set keystore_passphrase_env or keystore_passphrase_file")
+}
+
+// verifyHashSignature checks that sig over hash recovers to expected.
+func verifyHashSignature(hash, sig []byte, expected common.Address) error {
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("failed to recover signer: %v", err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pubKey); recovered != expected {
+		return fmt.Errorf("%w: got %s, expected %s", ErrSignerMismatch, recovered.Hex(), expected.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package sponsorship
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Entry statuses. Reserved entries count against budgets with their worst
+// case cost until the receipt arrives. Unconfirmed entries had a transaction
+// whose receipt never arrived, and keep that worst case cost.
+const (
+	StatusReserved    = "reserved"
+	StatusSettled     = "settled"
+	StatusUnconfirmed = "unconfirmed"
+	StatusCancelled   = "cancelled"
+)
+
+// Entry is one sponsored relay.
+type Entry struct {
+	ID         uint64         `json:"id"`
+	Day        string         `json:"day"`
+	Time       time.Time      `json:"time"`
+	User       common.Address `json:"user"`
+	PoolID     common.Hash    `json:"poolId"`
+	TxHashes   []common.Hash  `json:"txHashes,omitempty"`
+	GasUsed    uint64         `json:"gasUsed"`
+	GasCost    *big.Int       `json:"gasCost"`
+	FeeToken   common.Address `json:"feeToken,omitempty"`
+	RelayerFee *big.Int       `json:"relayerFee,omitempty"`
+	Status     string         `json:"status"`
+}
+
+// Totals aggregates gas sponsored over a set of entries.
+type Totals struct {
+	Transactions int      `json:"transactions"`
+	GasUsed      uint64   `json:"gasUsed"`
+	GasCost      *big.Int `json:"gasCost"`
+}
+
+// Ledger records gas sponsored per user and pool. When path is set every
+// change is written to it so the ledger survives restarts.
+type Ledger struct {
+	mu      sync.Mutex
+	path    string
+	entries []*Entry
+	nextID  uint64
+}
+
+// NewLedger loads the ledger at path, or starts an empty in-memory ledger
+// when path is empty.
+func NewLedger(path string) (*Ledger, error) {
+	l := &Ledger{path: path, nextID: 1}
+	if path == "" {
+		return l, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sponsorship ledger: %v", err)
+	}
+	if err := json.Unmarshal(content, &l.entries); err != nil {
+		return nil, fmt.Errorf("failed to decode sponsorship ledger: %v", err)
+	}
+	for _, entry := range l.entries {
+		if entry.ID >= l.nextID {
+			l.nextID = entry.ID + 1
+		}
+	}
+	return l, nil
+}
+
+func (l *Ledger) add(entry *Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.ID = l.nextID
+	l.nextID++
+	l.entries = append(l.entries, entry)
+	l.save()
+}
+
+func (l *Ledger) update(entry *Entry, change func(*Entry)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	change(entry)
+	l.save()
+}
+
+// save writes the ledger to disk. Callers must hold l.mu.
+func (l *Ledger) save() {
+	if l.path == "" {
+		return
+	}
+	content, err := json.MarshalIndent(l.entries, "", "  ")
+	if err == nil {
+		err = os.WriteFile(l.path, content, 0600)
+	}
+	if err != nil {
+		// The in-memory ledger stays authoritative, so keep serving
+		log.Printf("Sponsorship ledger: failed to persist: %v", err)
+	}
+}
+
+// spent sums the cost of non-cancelled entries on day matching filter.
+func (l *Ledger) spent(day string, filter func(*Entry) bool) *big.Int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	total := new(big.Int)
+	for _, entry := range l.entries {
+		if entry.Day == day && entry.Status != StatusCancelled && filter(entry) {
+			total.Add(total, entry.GasCost)
+		}
+	}
+	return total
+}
+
+// Query returns the entries matching the optional user, pool and day filters
+// together with their totals per user.
+func (l *Ledger) Query(user *common.Address, poolID *common.Hash, day string) ([]Entry, map[common.Address]*Totals) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []Entry{}
+	totals := make(map[common.Address]*Totals)
+	for _, entry := range l.entries {
+		if user != nil && entry.User != *user {
+			continue
+		}
+		if poolID != nil && entry.PoolID != *poolID {
+			continue
+		}
+		if day != "" && entry.Day != day {
+			continue
+		}
+		entries = append(entries, *entry)
+		if entry.Status == StatusCancelled {
+			continue
+		}
+		t, ok := totals[entry.User]
+		if !ok {
+			t = &Totals{GasCost: new(big.Int)}
+			totals[entry.User] = t
+		}
+		t.Transactions++
+		t.GasUsed += entry.GasUsed
+		t.GasCost.Add(t.GasCost, entry.GasCost)
+	}
+	return entries, totals
+}
@@ -0,0 +1,271 @@
+package sponsorship
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"uniswap-v4-rpc/internal/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Errors returned by Authorize. They are wrapped with details, so use
+// errors.Is or IsPolicyError to check for them.
+var (
+	ErrTokenNotAllowed      = errors.New("token is not allowlisted for sponsorship")
+	ErrPoolNotAllowed       = errors.New("pool is not allowlisted for sponsorship")
+	ErrAmountTooLarge       = errors.New("amount exceeds the sponsored maximum per transaction")
+	ErrUserBudgetExceeded   = errors.New("user daily gas budget exceeded")
+	ErrPoolBudgetExceeded   = errors.New("pool daily gas budget exceeded")
+	ErrInvalidPolicyRequest = errors.New("invalid sponsorship request")
+)
+
+// IsPolicyError reports whether err is a sponsorship refusal.
+func IsPolicyError(err error) bool {
+	for _, target := range []error{ErrTokenNotAllowed, ErrPoolNotAllowed, ErrAmountTooLarge, ErrUserBudgetExceeded, ErrPoolBudgetExceeded, ErrInvalidPolicyRequest} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Request describes a relay the server is asked to pay for.
+type Request struct {
+	User   common.Address
+	PoolID common.Hash
+	// Tokens the user moves, with the amounts pulled from them
+	Tokens  []common.Address
+	Amounts []*big.Int
+	// MaxGasCost is the worst case cost of the relay, gasLimit * gasPrice
+	MaxGasCost *big.Int
+}
+
+// Policy decides which relays the server sponsors. Zero budgets and empty
+// allowlists mean no limit.
+type Policy struct {
+	userDailyBudget *big.Int
+	poolDailyBudget *big.Int
+	maxAmountPerTx  map[common.Address]*big.Int
+	allowedTokens   map[common.Address]bool
+	allowedPools    map[common.Hash]bool
+	relayerFeeBps   int64
+
+	// mu serialises Authorize so concurrent requests cannot overspend
+	mu     sync.Mutex
+	ledger *Ledger
+	now    func() time.Time
+}
+
+func NewPolicy(cfg config.SponsorshipConfig) (*Policy, error) {
+	userBudget, err := parseWei(cfg.UserDailyGasWei, "user_daily_gas_wei")
+	if err != nil {
+		return nil, err
+	}
+	poolBudget, err := parseWei(cfg.PoolDailyGasWei, "pool_daily_gas_wei")
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RelayerFeeBps < 0 || cfg.RelayerFeeBps >= 10000 {
+		return nil, fmt.Errorf("relayer_fee_bps must be between 0 and 9999, got %d", cfg.RelayerFeeBps)
+	}
+
+	ledger, err := NewLedger(cfg.LedgerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Policy{
+		userDailyBudget: userBudget,
+		poolDailyBudget: poolBudget,
+		maxAmountPerTx:  make(map[common.Address]*big.Int),
+		allowedTokens:   make(map[common.Address]bool),
+		allowedPools:    make(map[common.Hash]bool),
+		relayerFeeBps:   cfg.RelayerFeeBps,
+		ledger:          ledger,
+		now:             time.Now,
+	}
+	for token, amount := range cfg.MaxAmountPerTx {
+		if !common.IsHexAddress(token) {
+			return nil, fmt.Errorf("invalid token %q in max_amount_per_tx", token)
+		}
+		max, err := parseWei(amount, "max_amount_per_tx")
+		if err != nil {
+			return nil, err
+		}
+		p.maxAmountPerTx[common.HexToAddress(token)] = max
+	}
+	for _, token := range cfg.AllowedTokens {
+		if !common.IsHexAddress(token) {
+			return nil, fmt.Errorf("invalid token %q in allowed_tokens", token)
+		}
+		p.allowedTokens[common.HexToAddress(token)] = true
+	}
+	for _, pool := range cfg.AllowedPools {
+		p.allowedPools[common.HexToHash(pool)] = true
+	}
+	return p, nil
+}
+
+func parseWei(value, name string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return new(big.Int), nil
+	}
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return amount, nil
+}
+
+// Ledger returns the accounting ledger of sponsored gas.
+func (p *Policy) Ledger() *Ledger {
+	return p.ledger
+}
+
+// SetClock replaces the time source used to bucket spending into UTC days.
+func (p *Policy) SetClock(now func() time.Time) {
+	p.now = now
+}
+
+// RelayerFee returns the fee charged on amount of the input token.
+func (p *Policy) RelayerFee(amount *big.Int) *big.Int {
+	fee := new(big.Int).Mul(new(big.Int).Abs(amount), big.NewInt(p.relayerFeeBps))
+	return fee.Div(fee, big.NewInt(10000))
+}
+
+// Authorize checks req against the policy and reserves its worst case gas
+// cost in the ledger. The reservation must be settled or cancelled.
+func (p *Policy) Authorize(req Request) (*Reservation, error) {
+	if len(req.Tokens) != len(req.Amounts) || req.MaxGasCost == nil {
+		return nil, ErrInvalidPolicyRequest
+	}
+	if len(p.allowedPools) > 0 && !p.allowedPools[req.PoolID] {
+		return nil, fmt.Errorf("%w: %s", ErrPoolNotAllowed, req.PoolID.Hex())
+	}
+	for i, token := range req.Tokens {
+		if len(p.allowedTokens) > 0 && !p.allowedTokens[token] {
+			return nil, fmt.Errorf("%w: %s", ErrTokenNotAllowed, token.Hex())
+		}
+		if max, ok := p.maxAmountPerTx[token]; ok && new(big.Int).Abs(req.Amounts[i]).Cmp(max) > 0 {
+			return nil, fmt.Errorf("%w: %s of %s, maximum %s", ErrAmountTooLarge, req.Amounts[i].String(), token.Hex(), max.String())
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now().UTC()
+	day := now.Format("2006-01-02")
+	if p.userDailyBudget.Sign() > 0 {
+		spent := p.ledger.spent(day, func(e *Entry) bool { return e.User == req.User })
+		if new(big.Int).Add(spent, req.MaxGasCost).Cmp(p.userDailyBudget) > 0 {
+			return nil, fmt.Errorf("%w: %s spent %s of %s wei today", ErrUserBudgetExceeded, req.User.Hex(), spent.String(), p.userDailyBudget.String())
+		}
+	}
+	if p.poolDailyBudget.Sign() > 0 {
+		spent := p.ledger.spent(day, func(e *Entry) bool { return e.PoolID == req.PoolID })
+		if new(big.Int).Add(spent, req.MaxGasCost).Cmp(p.poolDailyBudget) > 0 {
+			return nil, fmt.Errorf("%w: pool %s spent %s of %s wei today", ErrPoolBudgetExceeded, req.PoolID.Hex(), spent.String(), p.poolDailyBudget.String())
+		}
+	}
+
+	entry := &Entry{
+		Day:     day,
+		Time:    now,
+		User:    req.User,
+		PoolID:  req.PoolID,
+		GasCost: new(big.Int).Set(req.MaxGasCost),
+		Status:  StatusReserved,
+	}
+	p.ledger.add(entry)
+	return &Reservation{ledger: p.ledger, entry: entry, actualCost: new(big.Int)}, nil
+}
+
+// Reservation is the ledger entry of an authorized relay.
+type Reservation struct {
+	ledger     *Ledger
+	entry      *Entry
+	mu         sync.Mutex
+	sent       int
+	settled    int
+	lost       int
+	done       bool
+	actualGas  uint64
+	actualCost *big.Int
+}
+
+// Sent records a transaction broadcast for this reservation.
+func (r *Reservation) Sent(tx *types.Transaction) {
+	r.mu.Lock()
+	r.sent++
+	r.mu.Unlock()
+	r.ledger.update(r.entry, func(e *Entry) {
+		e.TxHashes = append(e.TxHashes, tx.Hash())
+	})
+}
+
+// SetRelayerFee records the relayer fee charged for this relay.
+func (r *Reservation) SetRelayerFee(token common.Address, fee *big.Int) {
+	r.ledger.update(r.entry, func(e *Entry) {
+		e.FeeToken = token
+		e.RelayerFee = new(big.Int).Set(fee)
+	})
+}
+
+// Settle records the receipt of a sent transaction, or a nil receipt for one
+// that was not mined before the receipt timeout. Once every sent transaction
+// has settled, the reserved cost is replaced by the actual cost unless a
+// receipt is missing.
+func (r *Reservation) Settle(receipt *types.Receipt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settled++
+	if receipt == nil {
+		r.lost++
+		r.finish()
+		return
+	}
+	r.actualGas += receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		r.actualCost.Add(r.actualCost, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice))
+	}
+	r.finish()
+}
+
+// Done marks that no further transactions will be sent. A reservation with
+// nothing sent is cancelled.
+func (r *Reservation) Done() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+	r.finish()
+}
+
+// finish settles the entry once all sent transactions are mined. Callers must
+// hold r.mu.
+func (r *Reservation) finish() {
+	if !r.done || r.settled < r.sent {
+		return
+	}
+	r.ledger.update(r.entry, func(e *Entry) {
+		if r.sent == 0 {
+			e.Status = StatusCancelled
+			return
+		}
+		if r.lost > 0 {
+			e.Status = StatusUnconfirmed
+			e.GasUsed = r.actualGas
+			return
+		}
+		e.Status = StatusSettled
+		e.GasUsed = r.actualGas
+		e.GasCost = new(big.Int).Set(r.actualCost)
+	})
+}
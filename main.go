@@ -5,7 +5,9 @@ import (
 
 	"uniswap-v4-rpc/internal/config"
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
 	"uniswap-v4-rpc/internal/routes"
+	"uniswap-v4-rpc/internal/signer"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,14 +22,45 @@ func main() {
 		log.Fatalf("Failed to initialize Ethereum client: %v", err)
 	}
 
-	if err := ethereum.SetPrivateKey(CFG_TEST.PrivateKey); err != nil {
-		log.Fatalf("Failed to set private key: %v", err)
+	serverSigner, err := signer.FromConfig(&CFG_TEST.SignerConfig)
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+	ethereum.SetSigner(serverSigner)
+
+	if err := ethereum.InitRelayers(CFG_TEST); err != nil {
+		log.Fatalf("Failed to initialize relayer pool: %v", err)
+	}
+
+	if err := ethereum.InitSponsorship(CFG_TEST); err != nil {
+		log.Fatalf("Failed to initialize sponsorship policy: %v", err)
+	}
+	ethereum.SetAdminToken(CFG_TEST.AdminToken)
+
+	if err := ethereum.InitPositions(CFG_TEST); err != nil {
+		log.Fatalf("Failed to initialize position registry: %v", err)
+	}
+
+	if err := ethereum.InitHookData(CFG_TEST); err != nil {
+		log.Fatalf("Failed to load hookData schemas: %v", err)
+	}
+
+	if err := ethereum.InitHookViews(CFG_TEST); err != nil {
+		log.Fatalf("Failed to load hook ABIs: %v", err)
+	}
+
+	if err := hookadapters.Init(CFG_TEST); err != nil {
+		log.Fatalf("Failed to bind hook adapters: %v", err)
 	}
 
 	if err := ethereum.InitContracts(CFG_TEST); err != nil {
 		log.Fatalf("Failed to initialize contracts: %v", err)
 	}
 
+	if err := ethereum.InitPools(CFG_TEST); err != nil {
+		log.Fatalf("Failed to start pool registry: %v", err)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 	routes.SetupRoutes(router)
@@ -0,0 +1,232 @@
+// Package abijson converts between decoded JSON values and the Go values
+// go-ethereum packs and unpacks for ABI types.
+package abijson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+// Decode decodes with numbers kept as json.Number, so integers above 2^53
+// survive.
+func Decode(data []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
+
+// DecodeHex decodes 0x-prefixed hex, where "" and "0x" are empty bytes.
+func DecodeHex(value string) ([]byte, error) {
+	if value == "" || value == "0x" {
+		return []byte{}, nil
+	}
+	raw, err := hexutil.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %v", value, err)
+	}
+	return raw, nil
+}
+
+// CheckKeys fails for missing or unknown fields, so a misspelt field is not
+// silently encoded as zero.
+func CheckKeys(values map[string]interface{}, names []string) error {
+	known := make(map[string]bool, len(names))
+	var missing []string
+	for _, name := range names {
+		known[name] = true
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing fields %s", strings.Join(missing, ", "))
+	}
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Arguments converts positional values, as decoded by Decode, for args.
+func Arguments(args abi.Arguments, values []interface{}) ([]interface{}, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(args), len(values))
+	}
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := Convert(arg.Type, values[i])
+		if err != nil {
+			name := arg.Name
+			if name == "" {
+				name = fmt.Sprintf("argument %d", i)
+			}
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		converted[i] = value.Interface()
+	}
+	return converted, nil
+}
+
+// Convert turns a value decoded by Decode into the Go value geth packs for
+// typ. Integers may be JSON numbers or decimal or 0x-prefixed strings, bytes
+// are 0x-prefixed hex and tuples are objects keyed by component name.
+func Convert(typ abi.Type, value interface{}) (reflect.Value, error) {
+	goType := typ.GetType()
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := parseInteger(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if err := checkRange(n, typ); err != nil {
+			return reflect.Value{}, err
+		}
+		if goType == bigIntType {
+			return reflect.ValueOf(n), nil
+		}
+		if typ.T == abi.IntTy {
+			return reflect.ValueOf(n.Int64()).Convert(goType), nil
+		}
+		return reflect.ValueOf(n.Uint64()).Convert(goType), nil
+	case abi.BoolTy:
+		b, ok := value.(bool)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a bool")
+		}
+		return reflect.ValueOf(b), nil
+	case abi.StringTy:
+		str, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a string")
+		}
+		return reflect.ValueOf(str), nil
+	case abi.AddressTy:
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return reflect.Value{}, fmt.Errorf("expected an address")
+		}
+		return reflect.ValueOf(common.HexToAddress(str)), nil
+	case abi.BytesTy:
+		raw, err := hexValue(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(raw), nil
+	case abi.FixedBytesTy:
+		raw, err := hexValue(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(raw) != typ.Size {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes, got %d", typ.Size, len(raw))
+		}
+		array := reflect.New(goType).Elem()
+		reflect.Copy(array, reflect.ValueOf(raw))
+		return array, nil
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := value.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected an array")
+		}
+		var list reflect.Value
+		if typ.T == abi.SliceTy {
+			list = reflect.MakeSlice(goType, len(items), len(items))
+		} else {
+			if len(items) != typ.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d items, got %d", typ.Size, len(items))
+			}
+			list = reflect.New(goType).Elem()
+		}
+		for i, item := range items {
+			element, err := Convert(*typ.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("[%d]: %v", i, err)
+			}
+			list.Index(i).Set(element)
+		}
+		return list, nil
+	case abi.TupleTy:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected an object")
+		}
+		if err := CheckKeys(fields, typ.TupleRawNames); err != nil {
+			return reflect.Value{}, err
+		}
+		tuple := reflect.New(goType).Elem()
+		for i, elem := range typ.TupleElems {
+			name := typ.TupleRawNames[i]
+			field, err := Convert(*elem, fields[name])
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: %v", name, err)
+			}
+			tuple.Field(i).Set(field)
+		}
+		return tuple, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", typ.String())
+	}
+}
+
+func parseInteger(value interface{}) (*big.Int, error) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return nil, fmt.Errorf("expected an integer")
+	}
+	base := 10
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "-0x") {
+		text, base = strings.Replace(text, "0x", "", 1), 16
+	}
+	n, ok := new(big.Int).SetString(text, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", text)
+	}
+	return n, nil
+}
+
+// checkRange fails for integers that do not fit typ.
+func checkRange(n *big.Int, typ abi.Type) error {
+	bits := uint(typ.Size)
+	if typ.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > int(bits) {
+			return fmt.Errorf("%s does not fit %s", n, typ.String())
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), bits-1)
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s does not fit %s", n, typ.String())
+	}
+	return nil
+}
+
+func hexValue(value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected 0x-prefixed hex")
+	}
+	return DecodeHex(str)
+}
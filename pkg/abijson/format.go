@@ -0,0 +1,65 @@
+package abijson
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Outputs formats values unpacked for args as JSON friendly values, keyed by
+// output name. Unnamed outputs are keyed by position, so a single unnamed
+// output is "0".
+func Outputs(args abi.Arguments, values []interface{}) map[string]interface{} {
+	formatted := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := args[i].Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		formatted[name] = Format(args[i].Type, value)
+	}
+	return formatted
+}
+
+// Format turns a value unpacked for typ into a JSON friendly value. Integers
+// are decimal strings, since they may not fit a JSON number, and bytes are
+// 0x-prefixed hex.
+func Format(typ abi.Type, value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		if n, ok := value.(*big.Int); ok {
+			return n.String()
+		}
+		if typ.T == abi.IntTy {
+			return big.NewInt(v.Int()).String()
+		}
+		return new(big.Int).SetUint64(v.Uint()).String()
+	case abi.AddressTy:
+		return value.(common.Address).Hex()
+	case abi.BytesTy:
+		return hexutil.Encode(value.([]byte))
+	case abi.FixedBytesTy:
+		raw := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(raw), v)
+		return hexutil.Encode(raw)
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = Format(*typ.Elem, v.Index(i).Interface())
+		}
+		return items
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(typ.TupleElems))
+		for i, elem := range typ.TupleElems {
+			fields[typ.TupleRawNames[i]] = Format(*elem, v.Field(i).Interface())
+		}
+		return fields
+	default:
+		return value
+	}
+}
@@ -0,0 +1,157 @@
+// Package hooks decodes the permissions a v4 hook has from its address. The
+// PoolManager only calls the callbacks whose flag is set in the low 14 bits
+// of the hook address, see Hooks.sol in v4-core.
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Permission flags, the bit of the hook address each callback is keyed on.
+const (
+	BeforeInitializeFlag                 = 1 << 13
+	AfterInitializeFlag                  = 1 << 12
+	BeforeAddLiquidityFlag               = 1 << 11
+	AfterAddLiquidityFlag                = 1 << 10
+	BeforeRemoveLiquidityFlag            = 1 << 9
+	AfterRemoveLiquidityFlag             = 1 << 8
+	BeforeSwapFlag                       = 1 << 7
+	AfterSwapFlag                        = 1 << 6
+	BeforeDonateFlag                     = 1 << 5
+	AfterDonateFlag                      = 1 << 4
+	BeforeSwapReturnsDeltaFlag           = 1 << 3
+	AfterSwapReturnsDeltaFlag            = 1 << 2
+	AfterAddLiquidityReturnsDeltaFlag    = 1 << 1
+	AfterRemoveLiquidityReturnsDeltaFlag = 1 << 0
+
+	// AllHookMask covers every permission flag
+	AllHookMask = 1<<14 - 1
+)
+
+// dynamicFeeFlag in PoolKey.fee marks a dynamic fee pool.
+const dynamicFeeFlag = 0x800000
+
+// ErrInvalidHook is wrapped by the errors of ValidatePoolKey, for pool keys
+// the PoolManager would reject with HookAddressNotValid.
+var ErrInvalidHook = errors.New("invalid hook address")
+
+// Permissions mirrors Hooks.Permissions. Field names match the ABI so a
+// hook's getHookPermissions result converts straight into it.
+type Permissions struct {
+	BeforeInitialize                bool `json:"beforeInitialize"`
+	AfterInitialize                 bool `json:"afterInitialize"`
+	BeforeAddLiquidity              bool `json:"beforeAddLiquidity"`
+	AfterAddLiquidity               bool `json:"afterAddLiquidity"`
+	BeforeRemoveLiquidity           bool `json:"beforeRemoveLiquidity"`
+	AfterRemoveLiquidity            bool `json:"afterRemoveLiquidity"`
+	BeforeSwap                      bool `json:"beforeSwap"`
+	AfterSwap                       bool `json:"afterSwap"`
+	BeforeDonate                    bool `json:"beforeDonate"`
+	AfterDonate                     bool `json:"afterDonate"`
+	BeforeSwapReturnDelta           bool `json:"beforeSwapReturnDelta"`
+	AfterSwapReturnDelta            bool `json:"afterSwapReturnDelta"`
+	AfterAddLiquidityReturnDelta    bool `json:"afterAddLiquidityReturnDelta"`
+	AfterRemoveLiquidityReturnDelta bool `json:"afterRemoveLiquidityReturnDelta"`
+}
+
+// flag pairs each permission with its bit and callback name, in flag order.
+type flag struct {
+	bit      uint16
+	callback string
+	field    func(*Permissions) *bool
+}
+
+var flags = []flag{
+	{BeforeInitializeFlag, "beforeInitialize", func(p *Permissions) *bool { return &p.BeforeInitialize }},
+	{AfterInitializeFlag, "afterInitialize", func(p *Permissions) *bool { return &p.AfterInitialize }},
+	{BeforeAddLiquidityFlag, "beforeAddLiquidity", func(p *Permissions) *bool { return &p.BeforeAddLiquidity }},
+	{AfterAddLiquidityFlag, "afterAddLiquidity", func(p *Permissions) *bool { return &p.AfterAddLiquidity }},
+	{BeforeRemoveLiquidityFlag, "beforeRemoveLiquidity", func(p *Permissions) *bool { return &p.BeforeRemoveLiquidity }},
+	{AfterRemoveLiquidityFlag, "afterRemoveLiquidity", func(p *Permissions) *bool { return &p.AfterRemoveLiquidity }},
+	{BeforeSwapFlag, "beforeSwap", func(p *Permissions) *bool { return &p.BeforeSwap }},
+	{AfterSwapFlag, "afterSwap", func(p *Permissions) *bool { return &p.AfterSwap }},
+	{BeforeDonateFlag, "beforeDonate", func(p *Permissions) *bool { return &p.BeforeDonate }},
+	{AfterDonateFlag, "afterDonate", func(p *Permissions) *bool { return &p.AfterDonate }},
+	{BeforeSwapReturnsDeltaFlag, "beforeSwapReturnDelta", func(p *Permissions) *bool { return &p.BeforeSwapReturnDelta }},
+	{AfterSwapReturnsDeltaFlag, "afterSwapReturnDelta", func(p *Permissions) *bool { return &p.AfterSwapReturnDelta }},
+	{AfterAddLiquidityReturnsDeltaFlag, "afterAddLiquidityReturnDelta", func(p *Permissions) *bool { return &p.AfterAddLiquidityReturnDelta }},
+	{AfterRemoveLiquidityReturnsDeltaFlag, "afterRemoveLiquidityReturnDelta", func(p *Permissions) *bool { return &p.AfterRemoveLiquidityReturnDelta }},
+}
+
+// Flags returns the permission bits of a hook address.
+func Flags(hook common.Address) uint16 {
+	return uint16(hook[common.AddressLength-2])<<8&0x3f00 | uint16(hook[common.AddressLength-1])
+}
+
+// FromAddress decodes the permissions of a hook address.
+func FromAddress(hook common.Address) Permissions {
+	return FromFlags(Flags(hook))
+}
+
+// FromFlags decodes permission bits.
+func FromFlags(bits uint16) Permissions {
+	var p Permissions
+	for _, f := range flags {
+		*f.field(&p) = bits&f.bit != 0
+	}
+	return p
+}
+
+// Flags returns the permission bits a hook address needs for p.
+func (p Permissions) Flags() uint16 {
+	var bits uint16
+	for _, f := range flags {
+		if *f.field(&p) {
+			bits |= f.bit
+		}
+	}
+	return bits
+}
+
+// Callbacks names the callbacks the PoolManager calls, in flag order. The
+// return delta flags are included, since they change how the callback's
+// result is applied.
+func (p Permissions) Callbacks() []string {
+	callbacks := []string{}
+	for _, f := range flags {
+		if *f.field(&p) {
+			callbacks = append(callbacks, f.callback)
+		}
+	}
+	return callbacks
+}
+
+// ValidatePoolKey checks the hook and fee of a pool key as
+// Hooks.isValidHookAddress does before initialize: a return delta flag needs
+// its callback's flag, a dynamic fee needs a hook, and a hook without flags
+// is only allowed with a dynamic fee.
+func ValidatePoolKey(hook common.Address, fee *big.Int) error {
+	p := FromAddress(hook)
+	deltas := []struct {
+		delta, callback bool
+		name            string
+	}{
+		{p.BeforeSwapReturnDelta, p.BeforeSwap, "beforeSwapReturnDelta needs beforeSwap"},
+		{p.AfterSwapReturnDelta, p.AfterSwap, "afterSwapReturnDelta needs afterSwap"},
+		{p.AfterAddLiquidityReturnDelta, p.AfterAddLiquidity, "afterAddLiquidityReturnDelta needs afterAddLiquidity"},
+		{p.AfterRemoveLiquidityReturnDelta, p.AfterRemoveLiquidity, "afterRemoveLiquidityReturnDelta needs afterRemoveLiquidity"},
+	}
+	for _, d := range deltas {
+		if d.delta && !d.callback {
+			return fmt.Errorf("%w %s: %s", ErrInvalidHook, hook.Hex(), d.name)
+		}
+	}
+
+	dynamic := fee.Cmp(big.NewInt(dynamicFeeFlag)) == 0
+	switch {
+	case hook == (common.Address{}) && dynamic:
+		return fmt.Errorf("%w: a dynamic fee pool needs a hook to set its fee", ErrInvalidHook)
+	case hook != (common.Address{}) && Flags(hook) == 0 && !dynamic:
+		return fmt.Errorf("%w %s: a hook without permission flags is only allowed with a dynamic fee", ErrInvalidHook, hook.Hex())
+	}
+	return nil
+}
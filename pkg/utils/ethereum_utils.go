@@ -2,7 +2,6 @@ package utils
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,7 +13,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func ApproveTokens(auth *bind.TransactOpts, currency0, currency1 common.Address) error {
@@ -26,13 +24,28 @@ func ApproveTokens(auth *bind.TransactOpts, currency0, currency1 common.Address)
 		return fmt.Errorf("failed to retrieve account nonce: %v", err)
 	}
 
+	spenders := []common.Address{ethereum.SwapRouterAddress, ethereum.LPRouterAddress}
+	if ethereum.HasPositionManager() {
+		spenders = append(spenders, ethereum.Permit2Address)
+	}
+	if ethereum.HasV4Router() {
+		spenders = append(spenders, ethereum.V4RouterAddress)
+	}
+	if ethereum.HasDonateRouter() {
+		spenders = append(spenders, ethereum.DonateRouterAddress)
+	}
+
 	for _, currency := range []common.Address{currency0, currency1} {
+		// Native ETH is sent as msg.value and has nothing to approve
+		if ethereum.IsNative(currency) {
+			continue
+		}
 		token, err := ethereum.NewERC20(currency)
 		if err != nil {
 			return fmt.Errorf("failed to instantiate token contract: %v", err)
 		}
 
-		for _, router := range []common.Address{ethereum.SwapRouterAddress, ethereum.LPRouterAddress} {
+		for _, router := range spenders {
 			auth.Nonce = big.NewInt(int64(nonce))
 			tx, err := token.Approve(auth, router, maxApproval)
 			if err != nil {
@@ -51,22 +64,56 @@ func ApproveTokens(auth *bind.TransactOpts, currency0, currency1 common.Address)
 			// Increment the nonce for the next transaction
 			nonce++
 		}
+
+		// The PositionManager pulls tokens through Permit2
+		if ethereum.HasPositionManager() {
+			permit2 := bind.NewBoundContract(ethereum.Permit2Address, ethereum.Permit2ABI, ethereum.Client, ethereum.Client, ethereum.Client)
+			auth.Nonce = big.NewInt(int64(nonce))
+			tx, err := permit2.Transact(auth, "approve", currency, ethereum.PositionManagerAddress, MaxPermit2Amount(), MaxPermit2Expiration())
+			if err != nil {
+				return fmt.Errorf("failed to approve token on permit2: %v", err)
+			}
+			receipt, err := bind.WaitMined(context.Background(), ethereum.Client, tx)
+			if err != nil {
+				return fmt.Errorf("failed to wait for approval transaction to be mined: %v", err)
+			}
+			if receipt.Status == 0 {
+				return fmt.Errorf("permit2 approval transaction failed for token %s", currency.Hex())
+			}
+			nonce++
+		}
 	}
 
 	return nil
 }
 
-func GetBalance(tokenAddress, ownerAddress common.Address) (*big.Int, error) {
-	// Check if the tokenAddress is valid
-	if tokenAddress == (common.Address{}) {
-		return nil, fmt.Errorf("invalid token address: zero address")
-	}
+// MaxPermit2Amount is the largest Permit2 allowance, type(uint160).max.
+func MaxPermit2Amount() *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+}
+
+// MaxPermit2Expiration is the latest Permit2 expiration, type(uint48).max.
+func MaxPermit2Expiration() *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 48), big.NewInt(1))
+}
 
+// GetBalance returns the balance of ownerAddress in a currency, read with
+// BalanceAt for native ETH (the zero address) and balanceOf for a token.
+func GetBalance(tokenAddress, ownerAddress common.Address) (*big.Int, error) {
 	// Check if the ownerAddress is valid
 	if ownerAddress == (common.Address{}) {
 		return nil, fmt.Errorf("invalid owner address: zero address")
 	}
 
+	currency := ethereum.NewCurrency(tokenAddress)
+	if currency.IsNative() {
+		balance, err := currency.BalanceOf(ownerAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ETH balance: %v", err)
+		}
+		return balance, nil
+	}
+
 	// Check if there's contract code at the token address
 	code, err := ethereum.Client.CodeAt(context.Background(), tokenAddress, nil)
 	if err != nil {
@@ -160,108 +207,3 @@ func FetchCurrentNonce(tokenAddress, ownerAddress common.Address) (*big.Int, err
 
 	return nonce, nil
 }
-
-func GeneratePermitSignature(tokenAddress, owner, spender common.Address, value, deadline *big.Int, alicePrivKey *ecdsa.PrivateKey) (uint8, [32]byte, [32]byte, error) {
-	PERMIT_TYPEHASH := crypto.Keccak256([]byte("Swap(address sender,address currency0,address currency1,int256 amountSpecified,bool zeroForOne,uint256 sqrtPriceLimitX96,uint256 nonce)"))
-	PERMIT_TYPEHASH = crypto.Keccak256([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
-
-	log.Printf("Permit Typehash: 0x%x", PERMIT_TYPEHASH)
-
-	// Fetch the nonce from the token contract
-	nonce, err := FetchCurrentNonce(tokenAddress, owner)
-	if err != nil {
-		return 0, [32]byte{}, [32]byte{}, fmt.Errorf("failed to fetch current nonce: %v", err)
-	}
-	log.Printf("Nonce : 0x%x", nonce)
-
-	// Fetch the domain separator from the token contract
-	domainSeparator, err := FetchDomainSeparator(tokenAddress)
-	if err != nil {
-		log.Printf("Error getting domain separator: %v", err)
-	} else {
-		log.Printf("Domain Separator: 0x%x", domainSeparator)
-	}
-	permitHash := crypto.Keccak256(
-		PERMIT_TYPEHASH,
-		common.LeftPadBytes(owner.Bytes(), 32),
-		common.LeftPadBytes(spender.Bytes(), 32),
-		common.LeftPadBytes(value.Bytes(), 32),
-		common.LeftPadBytes(nonce.Bytes(), 32),
-		common.LeftPadBytes(deadline.Bytes(), 32),
-	)
-
-	digest := crypto.Keccak256(
-		[]byte("\x19\x01"),
-		domainSeparator[:],
-		permitHash,
-	)
-
-	// Log the encoded permit data
-	encodedPermit := crypto.Keccak256(
-		PERMIT_TYPEHASH,
-		spender.Bytes(),
-		ethereum.SwapRouterAddress.Bytes(),
-		common.LeftPadBytes(value.Bytes(), 32),
-		common.LeftPadBytes(nonce.Bytes(), 32),
-		common.LeftPadBytes(deadline.Bytes(), 32),
-	)
-	log.Printf("Encoded Permit: 0x%x", encodedPermit)
-
-	log.Printf("Digest to Sign: 0x%x", digest)
-
-	signature, err := crypto.Sign(digest, alicePrivKey)
-	if err != nil {
-		log.Printf("Error signing digest: %v", err)
-	}
-	log.Printf("Raw Signature: 0x%x", signature)
-
-	v := signature[64] + 27
-	r := common.BytesToHash(signature[:32])
-	s := common.BytesToHash(signature[32:64])
-
-	log.Printf("v: %d", v)
-	log.Printf("r: 0x%x", r)
-	log.Printf("s: 0x%x", s)
-
-	// Recover signer address
-	pubKey, err := crypto.SigToPub(digest, signature)
-	if err != nil {
-		log.Printf("Error recovering public key: %v", err)
-	} else {
-		recoveredAddr := crypto.PubkeyToAddress(*pubKey)
-		log.Printf("Recovered signer address: %s", recoveredAddr.Hex())
-
-	}
-	publicKey := alicePrivKey.Public()
-
-	publicKeyECDSA, _ := publicKey.(*ecdsa.PublicKey)
-
-	// Log the address derived from the private key
-	derivedAddr := crypto.PubkeyToAddress(*publicKeyECDSA)
-	log.Printf("Address derived from PrivateKey: %s", derivedAddr.Hex())
-
-	return v, r, s, nil
-}
-
-func MakeAddrAndKey(seed string) (common.Address, *ecdsa.PrivateKey) {
-	// Create a deterministic hash from the seed
-	hash := crypto.Keccak256([]byte(seed))
-
-	// Create a private key from the hash
-	privateKey, err := crypto.ToECDSA(hash[:32]) // Use only the first 32 bytes for the private key
-	if err != nil {
-		log.Fatalf("Failed to create private key: %v", err)
-	}
-
-	// Get the public key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("Failed to get public key")
-	}
-
-	// Generate the Ethereum address from the public key
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-
-	return address, privateKey
-}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/signer"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	PERMIT_TYPEHASH        = crypto.Keccak256([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	EIP712_DOMAIN_TYPEHASH = crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+)
+
+// Errors returned by ValidatePermit. They are wrapped with the offending
+// values, so use errors.Is to check for them.
+var (
+	ErrPermitSignerMismatch = errors.New("permit signer does not match owner")
+	ErrPermitNonceMismatch  = errors.New("permit nonce does not match token nonce")
+	ErrPermitExpired        = errors.New("permit deadline has passed")
+	ErrPermitDomainMismatch = errors.New("permit domain separator does not match token")
+	// ErrPermitNotRelayable is returned for signatures the routers cannot
+	// pass on, since they take permits as v, r and s only
+	ErrPermitNotRelayable = errors.New("permit signature is not relayable via router")
+)
+
+// IsPermitError reports whether err is one of the permit validation errors,
+// i.e. a problem with the caller's permit rather than with the server.
+func IsPermitError(err error) bool {
+	return errors.Is(err, ErrPermitSignerMismatch) ||
+		errors.Is(err, ErrPermitNonceMismatch) ||
+		errors.Is(err, ErrPermitExpired) ||
+		errors.Is(err, ErrPermitDomainMismatch) ||
+		errors.Is(err, ErrPermitNotRelayable)
+}
+
+// Permit is a signed ERC-2612 permit together with the values it commits to.
+// Signature is a 65 byte [R || S || V] signature by the owner's key.
+type Permit struct {
+	Token           common.Address
+	Owner           common.Address
+	Spender         common.Address
+	Value           *big.Int
+	Nonce           *big.Int
+	Deadline        *big.Int
+	DomainSeparator [32]byte
+	Signature       []byte
+}
+
+// Digest returns the EIP-712 digest the permit signature is made over.
+func (p *Permit) Digest() []byte {
+	return PermitDigest(p.DomainSeparator, p.Owner, p.Spender, p.Value, p.Nonce, p.Deadline)
+}
+
+// VRS splits a 65 byte [R || S || V] signature for the routers' permit
+// arguments, with V as 27/28. Other lengths return ErrPermitNotRelayable.
+func (p *Permit) VRS() (v uint8, r [32]byte, s [32]byte, err error) {
+	if len(p.Signature) != 65 {
+		return 0, r, s, fmt.Errorf("%w: signature is %d bytes, routers only accept 65 byte v, r, s signatures", ErrPermitNotRelayable, len(p.Signature))
+	}
+	copy(r[:], p.Signature[:32])
+	copy(s[:], p.Signature[32:64])
+	v = p.Signature[64]
+	if v < 27 {
+		v += 27
+	}
+	return v, r, s, nil
+}
+
+func PermitDigest(domainSeparator [32]byte, owner, spender common.Address, value, nonce, deadline *big.Int) []byte {
+	permitHash := crypto.Keccak256(
+		PERMIT_TYPEHASH,
+		common.LeftPadBytes(owner.Bytes(), 32),
+		common.LeftPadBytes(spender.Bytes(), 32),
+		common.LeftPadBytes(value.Bytes(), 32),
+		common.LeftPadBytes(nonce.Bytes(), 32),
+		common.LeftPadBytes(deadline.Bytes(), 32),
+	)
+
+	return crypto.Keccak256(
+		[]byte("\x19\x01"),
+		domainSeparator[:],
+		permitHash,
+	)
+}
+
+// ExpectedDomainSeparator builds the EIP-712 domain separator the token should
+// report for the connected chain. The name and version come from the token's
+// ERC-5267 eip712Domain() when available, otherwise name() and version "1".
+func ExpectedDomainSeparator(tokenAddress common.Address, chainID *big.Int) ([32]byte, error) {
+	erc20, err := ethereum.NewERC20(tokenAddress)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to create ERC20 instance: %v", err)
+	}
+
+	opts := &bind.CallOpts{Context: context.Background()}
+	name, version, _, _, err := erc20.EIP712Domain(opts)
+	if err != nil {
+		name, err = erc20.Name(opts)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("failed to fetch token name: %v", err)
+		}
+		version = "1"
+	}
+
+	var separator [32]byte
+	copy(separator[:], crypto.Keccak256(
+		EIP712_DOMAIN_TYPEHASH,
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte(version)),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(tokenAddress.Bytes(), 32),
+	))
+	return separator, nil
+}
+
+// RecoverSigner returns the address that produced sig over digest. V may be
+// given either as 0/1 or as 27/28.
+func RecoverSigner(digest []byte, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	normalized := make([]byte, 65)
+	copy(normalized, sig)
+	if normalized[64] >= 27 {
+		normalized[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(digest, normalized)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover public key: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// VerifyPermitSignature checks that the permit signature recovers to the
+// owner.
+func VerifyPermitSignature(p *Permit) error {
+	signer, err := RecoverSigner(p.Digest(), p.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermitSignerMismatch, err)
+	}
+	if signer != p.Owner {
+		return fmt.Errorf("%w: recovered %s, expected %s", ErrPermitSignerMismatch, signer.Hex(), p.Owner.Hex())
+	}
+	return nil
+}
+
+// ValidatePermit checks a permit against current chain state before it is
+// relayed: the signature must be valid for the owner, the nonce must equal the
+// token's nonces(owner), the deadline must be after the latest block timestamp
+// and the domain separator must match both the token and the expected domain.
+func ValidatePermit(p *Permit) error {
+	ctx := context.Background()
+
+	if err := VerifyPermitSignature(p); err != nil {
+		return err
+	}
+
+	nonce, err := FetchCurrentNonce(p.Token, p.Owner)
+	if err != nil {
+		return err
+	}
+	if nonce.Cmp(p.Nonce) != 0 {
+		return fmt.Errorf("%w: signed %s, token has %s", ErrPermitNonceMismatch, p.Nonce.String(), nonce.String())
+	}
+
+	header, err := ethereum.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest block: %v", err)
+	}
+	if p.Deadline.Cmp(new(big.Int).SetUint64(header.Time)) < 0 {
+		return fmt.Errorf("%w: deadline %s, latest block timestamp %d", ErrPermitExpired, p.Deadline.String(), header.Time)
+	}
+
+	onchain, err := FetchDomainSeparator(p.Token)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(onchain[:], p.DomainSeparator[:]) {
+		return fmt.Errorf("%w: signed over 0x%x, token reports 0x%x", ErrPermitDomainMismatch, p.DomainSeparator, onchain)
+	}
+
+	chainID, err := ethereum.Client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch chain ID: %v", err)
+	}
+	expected, err := ExpectedDomainSeparator(p.Token, chainID)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected[:], onchain[:]) {
+		return fmt.Errorf("%w: token reports 0x%x, expected 0x%x for chain %s", ErrPermitDomainMismatch, onchain, expected, chainID.String())
+	}
+
+	return nil
+}
+
+// GeneratePermitSignature signs an ERC-2612 permit for owner using the token's
+// current nonce and domain separator. The returned permit has not been checked
+// against chain state; call ValidatePermit before relaying it.
+func GeneratePermitSignature(tokenAddress, owner, spender common.Address, value, deadline *big.Int, ownerSigner signer.Signer) (*Permit, error) {
+	// Fetch the nonce from the token contract
+	nonce, err := FetchCurrentNonce(tokenAddress, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current nonce: %v", err)
+	}
+	return SignPermit(tokenAddress, owner, spender, value, nonce, deadline, ownerSigner)
+}
+
+// SignPermit signs an ERC-2612 permit with an explicit nonce, for permits
+// that will be consumed after others from the same owner. ownerSigner must
+// hold the owner's key.
+func SignPermit(tokenAddress, owner, spender common.Address, value, nonce, deadline *big.Int, ownerSigner signer.Signer) (*Permit, error) {
+	permit, err := UnsignedPermit(tokenAddress, owner, spender, value, nonce, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := permit.Digest()
+	log.Printf("Permit digest for %s: 0x%x", tokenAddress.Hex(), digest)
+
+	signature, err := ownerSigner.SignHash(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign permit digest: %v", err)
+	}
+	signature[64] += 27
+	permit.Signature = signature
+
+	if ownerSigner.Address() != owner {
+		return nil, fmt.Errorf("%w: key belongs to %s, permit owner is %s", ErrPermitSignerMismatch, ownerSigner.Address().Hex(), owner.Hex())
+	}
+
+	return permit, nil
+}
+
+// UnsignedPermit returns the permit for the given values with the token's
+// domain separator, ready to be signed or to take a caller's signature.
+func UnsignedPermit(tokenAddress, owner, spender common.Address, value, nonce, deadline *big.Int) (*Permit, error) {
+	// Fetch the domain separator from the token contract
+	domainSeparator, err := FetchDomainSeparator(tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Permit{
+		Token:           tokenAddress,
+		Owner:           owner,
+		Spender:         spender,
+		Value:           value,
+		Nonce:           nonce,
+		Deadline:        deadline,
+		DomainSeparator: domainSeparator,
+	}, nil
+}
+
+// PermitWithSignature builds a permit at the token's current nonce carrying a
+// 65 byte signature the owner produced. The signature is not checked against
+// the owner; call ValidatePermit before relaying it.
+func PermitWithSignature(tokenAddress, owner, spender common.Address, value, deadline *big.Int, signature []byte) (*Permit, error) {
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("%w: signature is %d bytes, expected 65", ErrPermitSignerMismatch, len(signature))
+	}
+	nonce, err := FetchCurrentNonce(tokenAddress, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current nonce: %v", err)
+	}
+	permit, err := UnsignedPermit(tokenAddress, owner, spender, value, nonce, deadline)
+	if err != nil {
+		return nil, err
+	}
+	permit.Signature = signature
+	return permit, nil
+}
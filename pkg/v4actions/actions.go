@@ -0,0 +1,294 @@
+// Package v4actions encodes action plans for the v4-periphery routers
+// (PositionManager.modifyLiquidities and V4Router), mirroring Actions.sol and
+// CalldataDecoder.sol.
+package v4actions
+
+import (
+	"fmt"
+	"math/big"
+
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Action codes from Actions.sol.
+const (
+	IncreaseLiquidity  byte = 0x00
+	DecreaseLiquidity  byte = 0x01
+	MintPosition       byte = 0x02
+	BurnPosition       byte = 0x03
+	SwapExactInSingle  byte = 0x04
+	SwapExactIn        byte = 0x05
+	SwapExactOutSingle byte = 0x06
+	SwapExactOut       byte = 0x07
+	Donate             byte = 0x08
+	Settle             byte = 0x09
+	SettleAll          byte = 0x10
+	SettlePair         byte = 0x11
+	Take               byte = 0x12
+	TakeAll            byte = 0x13
+	TakePortion        byte = 0x14
+	TakePair           byte = 0x15
+	SettleTakePair     byte = 0x16
+	CloseCurrency      byte = 0x17
+	ClearOrTake        byte = 0x18
+	Sweep              byte = 0x19
+	Mint6909           byte = 0x20
+	Burn6909           byte = 0x21
+)
+
+// Recipients with special meaning, from ActionConstants.sol.
+var (
+	MsgSender   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	AddressThis = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+func mustType(t string, components []abi.ArgumentMarshaling) abi.Type {
+	typ, err := abi.NewType(t, "", components)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+var (
+	poolKeyComponents = []abi.ArgumentMarshaling{
+		{Name: "currency0", Type: "address"},
+		{Name: "currency1", Type: "address"},
+		{Name: "fee", Type: "uint24"},
+		{Name: "tickSpacing", Type: "int24"},
+		{Name: "hooks", Type: "address"},
+	}
+	positionConfigType = mustType("tuple", []abi.ArgumentMarshaling{
+		{Name: "poolKey", Type: "tuple", Components: poolKeyComponents},
+		{Name: "tickLower", Type: "int24"},
+		{Name: "tickUpper", Type: "int24"},
+	})
+	addressType = mustType("address", nil)
+	uint256Type = mustType("uint256", nil)
+	uint128Type = mustType("uint128", nil)
+	boolType    = mustType("bool", nil)
+	bytesType   = mustType("bytes", nil)
+	bytesArray  = mustType("bytes[]", nil)
+)
+
+func args(types ...abi.Type) abi.Arguments {
+	arguments := make(abi.Arguments, len(types))
+	for i, t := range types {
+		arguments[i] = abi.Argument{Type: t}
+	}
+	return arguments
+}
+
+// Plan is a sequence of actions with their abi encoded params.
+type Plan struct {
+	actions []byte
+	params  [][]byte
+	err     error
+}
+
+// Add appends an action with params encoded as the given types. The first
+// encoding error is kept and returned by Encode.
+func (p *Plan) Add(action byte, types abi.Arguments, values ...interface{}) *Plan {
+	if p.err != nil {
+		return p
+	}
+	encoded, err := types.Pack(values...)
+	if err != nil {
+		p.err = fmt.Errorf("failed to encode action 0x%02x: %v", action, err)
+		return p
+	}
+	p.actions = append(p.actions, action)
+	p.params = append(p.params, encoded)
+	return p
+}
+
+// Actions returns the action codes added so far.
+func (p *Plan) Actions() []byte {
+	return append([]byte(nil), p.actions...)
+}
+
+// Encode returns abi.encode(actions, params), the unlockData taken by
+// modifyLiquidities and V4Router.
+func (p *Plan) Encode() ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return args(bytesType, bytesArray).Pack(p.actions, p.params)
+}
+
+// MintPosition mints a position NFT to owner.
+func (p *Plan) MintPosition(config ethereum.PositionConfig, liquidity, amount0Max, amount1Max *big.Int, owner common.Address, hookData []byte) *Plan {
+	return p.Add(MintPosition, args(positionConfigType, uint256Type, uint128Type, uint128Type, addressType, bytesType),
+		config, liquidity, amount0Max, amount1Max, owner, hookData)
+}
+
+// IncreaseLiquidity adds liquidity to an existing position.
+func (p *Plan) IncreaseLiquidity(tokenID *big.Int, config ethereum.PositionConfig, liquidity, amount0Max, amount1Max *big.Int, hookData []byte) *Plan {
+	return p.Add(IncreaseLiquidity, args(uint256Type, positionConfigType, uint256Type, uint128Type, uint128Type, bytesType),
+		tokenID, config, liquidity, amount0Max, amount1Max, hookData)
+}
+
+// DecreaseLiquidity removes liquidity from a position, collecting its fees.
+func (p *Plan) DecreaseLiquidity(tokenID *big.Int, config ethereum.PositionConfig, liquidity, amount0Min, amount1Min *big.Int, hookData []byte) *Plan {
+	return p.Add(DecreaseLiquidity, args(uint256Type, positionConfigType, uint256Type, uint128Type, uint128Type, bytesType),
+		tokenID, config, liquidity, amount0Min, amount1Min, hookData)
+}
+
+// BurnPosition removes all liquidity of a position and burns its NFT.
+func (p *Plan) BurnPosition(tokenID *big.Int, config ethereum.PositionConfig, amount0Min, amount1Min *big.Int, hookData []byte) *Plan {
+	return p.Add(BurnPosition, args(uint256Type, positionConfigType, uint128Type, uint128Type, bytesType),
+		tokenID, config, amount0Min, amount1Min, hookData)
+}
+
+// SettlePair pays what the caller owes in both currencies.
+func (p *Plan) SettlePair(currency0, currency1 common.Address) *Plan {
+	return p.Add(SettlePair, args(addressType, addressType), currency0, currency1)
+}
+
+// TakePair sends what is owed in both currencies to recipient.
+func (p *Plan) TakePair(currency0, currency1, recipient common.Address) *Plan {
+	return p.Add(TakePair, args(addressType, addressType, addressType), currency0, currency1, recipient)
+}
+
+// Settle pays amount of currency, from the caller when payerIsUser. An
+// amount of zero settles the full debt.
+func (p *Plan) Settle(currency common.Address, amount *big.Int, payerIsUser bool) *Plan {
+	return p.Add(Settle, args(addressType, uint256Type, boolType), currency, amount, payerIsUser)
+}
+
+// SettleAll pays the full debt in currency from the caller, up to maxAmount.
+func (p *Plan) SettleAll(currency common.Address, maxAmount *big.Int) *Plan {
+	return p.Add(SettleAll, args(addressType, uint256Type), currency, maxAmount)
+}
+
+// Take sends amount of currency to recipient. An amount of zero takes the
+// full credit.
+func (p *Plan) Take(currency, recipient common.Address, amount *big.Int) *Plan {
+	return p.Add(Take, args(addressType, addressType, uint256Type), currency, recipient, amount)
+}
+
+// TakeAll sends the full credit in currency to the caller, at least minAmount.
+func (p *Plan) TakeAll(currency common.Address, minAmount *big.Int) *Plan {
+	return p.Add(TakeAll, args(addressType, uint256Type), currency, minAmount)
+}
+
+// CloseCurrency settles or takes whatever the delta in currency is.
+func (p *Plan) CloseCurrency(currency common.Address) *Plan {
+	return p.Add(CloseCurrency, args(addressType), currency)
+}
+
+// Sweep sends the router's balance of currency to recipient.
+func (p *Plan) Sweep(currency, recipient common.Address) *Plan {
+	return p.Add(Sweep, args(addressType, addressType), currency, recipient)
+}
+
+// PathKey is one hop of a multi-hop swap through the pool of
+// IntermediateCurrency and the neighbouring currency with this fee, spacing
+// and hooks. In an exact input path IntermediateCurrency is the hop's
+// output, in an exact output path it is the hop's input.
+type PathKey struct {
+	IntermediateCurrency common.Address
+	Fee                  *big.Int
+	TickSpacing          *big.Int
+	Hooks                common.Address
+	HookData             []byte
+}
+
+// ExactInputSingleParams mirrors IV4Router.ExactInputSingleParams.
+type ExactInputSingleParams struct {
+	PoolKey           ethereum.PoolKey
+	ZeroForOne        bool
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+	HookData          []byte
+}
+
+// ExactInputParams mirrors IV4Router.ExactInputParams.
+type ExactInputParams struct {
+	CurrencyIn       common.Address
+	Path             []PathKey
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// ExactOutputSingleParams mirrors IV4Router.ExactOutputSingleParams.
+type ExactOutputSingleParams struct {
+	PoolKey           ethereum.PoolKey
+	ZeroForOne        bool
+	AmountOut         *big.Int
+	AmountInMaximum   *big.Int
+	SqrtPriceLimitX96 *big.Int
+	HookData          []byte
+}
+
+// ExactOutputParams mirrors IV4Router.ExactOutputParams. The path is in
+// input to output order and is swapped backwards from CurrencyOut.
+type ExactOutputParams struct {
+	CurrencyOut     common.Address
+	Path            []PathKey
+	AmountOut       *big.Int
+	AmountInMaximum *big.Int
+}
+
+var (
+	pathKeyComponents = []abi.ArgumentMarshaling{
+		{Name: "intermediateCurrency", Type: "address"},
+		{Name: "fee", Type: "uint24"},
+		{Name: "tickSpacing", Type: "int24"},
+		{Name: "hooks", Type: "address"},
+		{Name: "hookData", Type: "bytes"},
+	}
+	exactInputSingleType = mustType("tuple", []abi.ArgumentMarshaling{
+		{Name: "poolKey", Type: "tuple", Components: poolKeyComponents},
+		{Name: "zeroForOne", Type: "bool"},
+		{Name: "amountIn", Type: "uint128"},
+		{Name: "amountOutMinimum", Type: "uint128"},
+		{Name: "sqrtPriceLimitX96", Type: "uint160"},
+		{Name: "hookData", Type: "bytes"},
+	})
+	exactInputType = mustType("tuple", []abi.ArgumentMarshaling{
+		{Name: "currencyIn", Type: "address"},
+		{Name: "path", Type: "tuple[]", Components: pathKeyComponents},
+		{Name: "amountIn", Type: "uint128"},
+		{Name: "amountOutMinimum", Type: "uint128"},
+	})
+	exactOutputSingleType = mustType("tuple", []abi.ArgumentMarshaling{
+		{Name: "poolKey", Type: "tuple", Components: poolKeyComponents},
+		{Name: "zeroForOne", Type: "bool"},
+		{Name: "amountOut", Type: "uint128"},
+		{Name: "amountInMaximum", Type: "uint128"},
+		{Name: "sqrtPriceLimitX96", Type: "uint160"},
+		{Name: "hookData", Type: "bytes"},
+	})
+	exactOutputType = mustType("tuple", []abi.ArgumentMarshaling{
+		{Name: "currencyOut", Type: "address"},
+		{Name: "path", Type: "tuple[]", Components: pathKeyComponents},
+		{Name: "amountOut", Type: "uint128"},
+		{Name: "amountInMaximum", Type: "uint128"},
+	})
+)
+
+// SwapExactInSingle swaps exactly AmountIn through one pool. A zero
+// SqrtPriceLimitX96 means no limit.
+func (p *Plan) SwapExactInSingle(params ExactInputSingleParams) *Plan {
+	return p.Add(SwapExactInSingle, args(exactInputSingleType), params)
+}
+
+// SwapExactIn swaps exactly AmountIn along Path.
+func (p *Plan) SwapExactIn(params ExactInputParams) *Plan {
+	return p.Add(SwapExactIn, args(exactInputType), params)
+}
+
+// SwapExactOutSingle swaps for exactly AmountOut through one pool.
+func (p *Plan) SwapExactOutSingle(params ExactOutputSingleParams) *Plan {
+	return p.Add(SwapExactOutSingle, args(exactOutputSingleType), params)
+}
+
+// SwapExactOut swaps along Path for exactly AmountOut.
+func (p *Plan) SwapExactOut(params ExactOutputParams) *Plan {
+	return p.Add(SwapExactOut, args(exactOutputType), params)
+}
@@ -0,0 +1,97 @@
+package v4math
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+	ErrLiquidityOverflow = errors.New("liquidity overflows uint128")
+)
+
+// GetLiquidityForAmount0 returns the liquidity amount0 buys between two sqrt
+// prices, as LiquidityAmounts.getLiquidityForAmount0.
+func GetLiquidityForAmount0(sqrtPriceAX96, sqrtPriceBX96, amount0 *big.Int) (*big.Int, error) {
+	sqrtPriceAX96, sqrtPriceBX96 = sortSqrtPrices(sqrtPriceAX96, sqrtPriceBX96)
+	intermediate := new(big.Int).Mul(sqrtPriceAX96, sqrtPriceBX96)
+	intermediate.Div(intermediate, Q96)
+	liquidity := new(big.Int).Mul(amount0, intermediate)
+	liquidity.Div(liquidity, new(big.Int).Sub(sqrtPriceBX96, sqrtPriceAX96))
+	return checkUint128(liquidity)
+}
+
+// GetLiquidityForAmount1 returns the liquidity amount1 buys between two sqrt
+// prices, as LiquidityAmounts.getLiquidityForAmount1.
+func GetLiquidityForAmount1(sqrtPriceAX96, sqrtPriceBX96, amount1 *big.Int) (*big.Int, error) {
+	sqrtPriceAX96, sqrtPriceBX96 = sortSqrtPrices(sqrtPriceAX96, sqrtPriceBX96)
+	liquidity := new(big.Int).Mul(amount1, Q96)
+	liquidity.Div(liquidity, new(big.Int).Sub(sqrtPriceBX96, sqrtPriceAX96))
+	return checkUint128(liquidity)
+}
+
+// GetLiquidityForAmounts returns the most liquidity that amount0 and amount1
+// can provide between sqrtPriceAX96 and sqrtPriceBX96 at sqrtPriceX96.
+func GetLiquidityForAmounts(sqrtPriceX96, sqrtPriceAX96, sqrtPriceBX96, amount0, amount1 *big.Int) (*big.Int, error) {
+	sqrtPriceAX96, sqrtPriceBX96 = sortSqrtPrices(sqrtPriceAX96, sqrtPriceBX96)
+
+	switch {
+	case sqrtPriceX96.Cmp(sqrtPriceAX96) <= 0:
+		return GetLiquidityForAmount0(sqrtPriceAX96, sqrtPriceBX96, amount0)
+	case sqrtPriceX96.Cmp(sqrtPriceBX96) < 0:
+		liquidity0, err := GetLiquidityForAmount0(sqrtPriceX96, sqrtPriceBX96, amount0)
+		if err != nil {
+			return nil, err
+		}
+		liquidity1, err := GetLiquidityForAmount1(sqrtPriceAX96, sqrtPriceX96, amount1)
+		if err != nil {
+			return nil, err
+		}
+		if liquidity0.Cmp(liquidity1) < 0 {
+			return liquidity0, nil
+		}
+		return liquidity1, nil
+	default:
+		return GetLiquidityForAmount1(sqrtPriceAX96, sqrtPriceBX96, amount1)
+	}
+}
+
+// GetAmountsForLiquidity returns the amounts of both currencies liquidity
+// represents between sqrtPriceAX96 and sqrtPriceBX96 at sqrtPriceX96. With
+// roundUp set these are the amounts the pool charges to add the liquidity;
+// otherwise they are what removing it pays out.
+func GetAmountsForLiquidity(sqrtPriceX96, sqrtPriceAX96, sqrtPriceBX96, liquidity *big.Int, roundUp bool) (*big.Int, *big.Int) {
+	sqrtPriceAX96, sqrtPriceBX96 = sortSqrtPrices(sqrtPriceAX96, sqrtPriceBX96)
+
+	amount0, amount1 := new(big.Int), new(big.Int)
+	switch {
+	case sqrtPriceX96.Cmp(sqrtPriceAX96) <= 0:
+		amount0 = GetAmount0Delta(sqrtPriceAX96, sqrtPriceBX96, liquidity, roundUp)
+	case sqrtPriceX96.Cmp(sqrtPriceBX96) < 0:
+		amount0 = GetAmount0Delta(sqrtPriceX96, sqrtPriceBX96, liquidity, roundUp)
+		amount1 = GetAmount1Delta(sqrtPriceAX96, sqrtPriceX96, liquidity, roundUp)
+	default:
+		amount1 = GetAmount1Delta(sqrtPriceAX96, sqrtPriceBX96, liquidity, roundUp)
+	}
+	return amount0, amount1
+}
+
+func checkUint128(x *big.Int) (*big.Int, error) {
+	if x.Cmp(maxUint128) > 0 {
+		return nil, ErrLiquidityOverflow
+	}
+	return x, nil
+}
+
+// FeesOwed returns the fees a position with liquidity has earned since its
+// fee growth inside was feeGrowthInsideLastX128, as Position.update. The
+// growth difference wraps modulo 2^256.
+func FeesOwed(feeGrowthInsideX128, feeGrowthInsideLastX128, liquidity *big.Int) *big.Int {
+	growth := new(big.Int).Sub(feeGrowthInsideX128, feeGrowthInsideLastX128)
+	if growth.Sign() < 0 {
+		growth.Add(growth, new(big.Int).Add(maxUint256, big.NewInt(1)))
+	}
+	owed := growth.Mul(growth, liquidity)
+	return owed.Div(owed, Q128)
+}
@@ -0,0 +1,46 @@
+package v4math
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// pricePrecision is the big.Float precision used for price conversions, enough
+// to represent any Q64.96 sqrt price exactly.
+const pricePrecision = 256
+
+// SqrtPriceX96FromPrice converts a price, the amount of currency1 per unit
+// of currency0 in raw token units, to a Q64.96 sqrt price.
+func SqrtPriceX96FromPrice(price string) (*big.Int, error) {
+	p, ok := new(big.Float).SetPrec(pricePrecision).SetString(price)
+	if !ok || p.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid price %q", price)
+	}
+	sqrt := new(big.Float).SetPrec(pricePrecision).Sqrt(p)
+	sqrt.Mul(sqrt, new(big.Float).SetPrec(pricePrecision).SetInt(Q96))
+	sqrtPriceX96, _ := sqrt.Int(nil)
+	return sqrtPriceX96, nil
+}
+
+// PriceFromSqrtPriceX96 returns the price of currency0 in currency1 raw units.
+func PriceFromSqrtPriceX96(sqrtPriceX96 *big.Int) *big.Float {
+	sqrt := new(big.Float).SetPrec(pricePrecision).SetInt(sqrtPriceX96)
+	sqrt.Quo(sqrt, new(big.Float).SetPrec(pricePrecision).SetInt(Q96))
+	return sqrt.Mul(sqrt, sqrt)
+}
+
+// TickFromPrice returns the greatest tick at or below price, clamped to the
+// valid tick range.
+func TickFromPrice(price string) (int, error) {
+	sqrtPriceX96, err := SqrtPriceX96FromPrice(price)
+	if err != nil {
+		return 0, err
+	}
+	if sqrtPriceX96.Cmp(MinSqrtPrice) < 0 {
+		return MinTick, nil
+	}
+	if sqrtPriceX96.Cmp(MaxSqrtPrice) >= 0 {
+		return MaxTick, nil
+	}
+	return GetTickAtSqrtPrice(sqrtPriceX96)
+}
@@ -0,0 +1,47 @@
+package v4math
+
+import "math/big"
+
+func sortSqrtPrices(a, b *big.Int) (*big.Int, *big.Int) {
+	if a.Cmp(b) > 0 {
+		return b, a
+	}
+	return a, b
+}
+
+// mulDivRoundingUp returns ceil(a * b / denominator).
+func mulDivRoundingUp(a, b, denominator *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	quotient, remainder := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// GetAmount0Delta returns the amount of currency0 between two sqrt prices
+// for liquidity. The pool rounds up what it is owed and down what it pays.
+func GetAmount0Delta(sqrtPriceAX96, sqrtPriceBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	sqrtPriceAX96, sqrtPriceBX96 = sortSqrtPrices(sqrtPriceAX96, sqrtPriceBX96)
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtPriceBX96, sqrtPriceAX96)
+
+	if roundUp {
+		return mulDivRoundingUp(mulDivRoundingUp(numerator1, numerator2, sqrtPriceBX96), big.NewInt(1), sqrtPriceAX96)
+	}
+	amount := new(big.Int).Mul(numerator1, numerator2)
+	amount.Div(amount, sqrtPriceBX96)
+	return amount.Div(amount, sqrtPriceAX96)
+}
+
+// GetAmount1Delta returns the amount of currency1 between two sqrt prices
+// for liquidity.
+func GetAmount1Delta(sqrtPriceAX96, sqrtPriceBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	sqrtPriceAX96, sqrtPriceBX96 = sortSqrtPrices(sqrtPriceAX96, sqrtPriceBX96)
+	difference := new(big.Int).Sub(sqrtPriceBX96, sqrtPriceAX96)
+	if roundUp {
+		return mulDivRoundingUp(liquidity, difference, Q96)
+	}
+	amount := new(big.Int).Mul(liquidity, difference)
+	return amount.Div(amount, Q96)
+}
@@ -0,0 +1,136 @@
+// Package v4math ports the Uniswap v4 tick and liquidity math used to plan
+// positions off chain. Results match the Solidity libraries bit for bit.
+package v4math
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// MinTick and MaxTick bound the ticks getSqrtPriceAtTick accepts
+	MinTick = -887272
+	MaxTick = 887272
+)
+
+var (
+	// MinSqrtPrice and MaxSqrtPrice are the sqrt prices at MinTick and MaxTick
+	MinSqrtPrice, _ = new(big.Int).SetString("4295128739", 10)
+	MaxSqrtPrice, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+
+	// Q96 is 2^96, the scale of sqrt prices
+	Q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+	// Q128 is 2^128, the scale of fee growth values
+	Q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+	ErrInvalidTick      = errors.New("tick out of range")
+	ErrInvalidSqrtPrice = errors.New("sqrt price out of range")
+)
+
+var (
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	// tickRatios[i] is the Q128 ratio multiplied in for bit i of |tick|
+	tickRatios = []string{
+		"fffcb933bd6fad37aa2d162d1a594001",
+		"fff97272373d413259a46990580e213a",
+		"fff2e50f5f656932ef12357cf3c7fdcc",
+		"ffe5caca7e10e4e61c3624eaa0941cd0",
+		"ffcb9843d60f6159c9db58835c926644",
+		"ff973b41fa98c081472e6896dfb254c0",
+		"ff2ea16466c96a3843ec78b326b52861",
+		"fe5dee046a99a2a811c461f1969c3053",
+		"fcbe86c7900a88aedcffc83b479aa3a4",
+		"f987a7253ac413176f2b074cf7815e54",
+		"f3392b0822b70005940c7a398e4b70f3",
+		"e7159475a2c29b7443b29c7fa6e889d9",
+		"d097f3bdfd2022b8845ad8f792aa5825",
+		"a9f746462d870fdf8a65dc1f90e061e5",
+		"70d869a156d2a1b890bb3df62baf32f7",
+		"31be135f97d08fd981231505542fcfa6",
+		"9aa508b5b7a84e1c677de54f3e99bc9",
+		"5d6af8dedb81196699c329225ee604",
+		"2216e584f5fa1ea926041bedfe98",
+		"48a170391f7dc42444e8fa2",
+	}
+)
+
+// GetSqrtPriceAtTick returns sqrt(1.0001^tick) * 2^96.
+func GetSqrtPriceAtTick(tick int) (*big.Int, error) {
+	if tick < MinTick || tick > MaxTick {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidTick, tick)
+	}
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+
+	price := new(big.Int).Lsh(big.NewInt(1), 128)
+	for i, ratio := range tickRatios {
+		if absTick&(1<<i) == 0 {
+			continue
+		}
+		r, _ := new(big.Int).SetString(ratio, 16)
+		if i == 0 {
+			price = r
+			continue
+		}
+		price.Mul(price, r)
+		price.Rsh(price, 128)
+	}
+	if tick > 0 {
+		price = new(big.Int).Div(maxUint256, price)
+	}
+
+	// Q128.128 to Q64.96, rounding up
+	price.Add(price, big.NewInt(1<<32-1))
+	return price.Rsh(price, 32), nil
+}
+
+// GetTickAtSqrtPrice returns the greatest tick whose sqrt price is at most
+// sqrtPriceX96.
+func GetTickAtSqrtPrice(sqrtPriceX96 *big.Int) (int, error) {
+	if sqrtPriceX96.Cmp(MinSqrtPrice) < 0 || sqrtPriceX96.Cmp(MaxSqrtPrice) >= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSqrtPrice, sqrtPriceX96.String())
+	}
+	// GetSqrtPriceAtTick is monotonic, so binary search the tick range
+	low, high := MinTick, MaxTick
+	for low < high {
+		mid := low + (high-low+1)/2
+		price, _ := GetSqrtPriceAtTick(mid)
+		if price.Cmp(sqrtPriceX96) <= 0 {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
+
+// MinUsableTick and MaxUsableTick are the widest ticks usable with tickSpacing.
+func MinUsableTick(tickSpacing int) int {
+	return (MinTick / tickSpacing) * tickSpacing
+}
+
+func MaxUsableTick(tickSpacing int) int {
+	return (MaxTick / tickSpacing) * tickSpacing
+}
+
+// FloorTick rounds tick down to a multiple of tickSpacing.
+func FloorTick(tick, tickSpacing int) int {
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed--
+	}
+	return compressed * tickSpacing
+}
+
+// CeilTick rounds tick up to a multiple of tickSpacing.
+func CeilTick(tick, tickSpacing int) int {
+	floor := FloorTick(tick, tickSpacing)
+	if floor == tick {
+		return tick
+	}
+	return floor + tickSpacing
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimID(t *testing.T) {
+	assert.Equal(t, "0", ethereum.ClaimID(common.Address{}).String())
+	assert.Equal(t, "255", ethereum.ClaimID(common.HexToAddress("0xff")).String())
+	assert.Equal(t, ethereum.Token0_address.Big().String(), ethereum.ClaimID(ethereum.Token0_address).String())
+}
+
+func TestClaimRequestsValidation(t *testing.T) {
+	status, result := postJSON(t, "/claims/transfer", map[string]interface{}{
+		"receiver": "not an address",
+		"currency": ethereum.Token0_address,
+		"amount":   "1",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "invalid receiver")
+
+	status, result = postJSON(t, "/claims/approve", map[string]interface{}{
+		"spender":  ethereum.SwapRouterAddress,
+		"currency": ethereum.Token0_address,
+		"amount":   "-1",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "invalid amount")
+}
+
+func TestSwapTakeClaims(t *testing.T) {
+	owner := "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+	path := fmt.Sprintf("/claims/balance?owner=%s&currency=%s", owner, ethereum.Token1_address.Hex())
+	before := getJSON(t, path)["balance"]
+
+	status, result := postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"amount":     "-1000000000",
+		"zeroForOne": true,
+		"takeClaims": true,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	// The output stays in the PoolManager as claims instead of moving currency1
+	delta := result["deltaBalances"].(map[string]interface{})
+	assert.Equal(t, "0", delta["currency1"])
+	assert.NotEqual(t, before, getJSON(t, path)["balance"])
+}
@@ -0,0 +1,39 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDonationFeeGrowth(t *testing.T) {
+	q128 := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	// Donating the liquidity itself grows fees by exactly one unit
+	assert.Equal(t, q128, ethereum.DonationFeeGrowth(big.NewInt(1000), big.NewInt(1000)))
+	// Rounds down like FullMath.mulDiv
+	expected := new(big.Int).Div(q128, big.NewInt(3))
+	assert.Equal(t, expected, ethereum.DonationFeeGrowth(big.NewInt(1), big.NewInt(3)))
+	assert.Equal(t, "0", ethereum.DonationFeeGrowth(big.NewInt(0), big.NewInt(3)).String())
+	// Nothing can earn a donation without in-range liquidity
+	assert.Nil(t, ethereum.DonationFeeGrowth(big.NewInt(1), big.NewInt(0)))
+}
+
+func TestDonateRoutesNeedConfig(t *testing.T) {
+	if ethereum.HasDonateRouter() {
+		t.Skip("donate router is configured")
+	}
+	for _, path := range []string{"/donate", "/donatePermit"} {
+		status, result := postJSON(t, path, map[string]interface{}{
+			"currency0":   ethereum.Token0_address,
+			"currency1":   ethereum.Token1_address,
+			"amount0":     "1000",
+			"userAddress": "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, status, path)
+		assert.Contains(t, result["error"], "donate_router_address", path)
+	}
+}
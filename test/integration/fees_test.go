@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamicFeeFlag(t *testing.T) {
+	assert.True(t, ethereum.IsDynamicFee(big.NewInt(0x800000)))
+	assert.False(t, ethereum.IsDynamicFee(big.NewInt(3000)))
+
+	// The flag is part of the key, so the dynamic fee pool is a separate pool
+	static := ethereum.PoolKey{Currency0: ethereum.Token0_address, Currency1: ethereum.Token1_address, Fee: big.NewInt(3000), TickSpacing: big.NewInt(60), Hooks: ethereum.HookAddress}
+	dynamic := static
+	dynamic.Fee = big.NewInt(ethereum.DynamicFeeFlag)
+	assert.NotEqual(t, static.ID(), dynamic.ID())
+}
+
+func TestUpdateDynamicLPFeeValidation(t *testing.T) {
+	status, result := postJSON(t, "/updateDynamicLPFee", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"lpFee":     1000001,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "above the maximum")
+
+	status, _ = postJSON(t, "/updateDynamicLPFee", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestDynamicFeePositionAddThenRemove(t *testing.T) {
+	status, result := postJSON(t, "/initialize", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"dynamicFee": true,
+	})
+	require.Contains(t, []int{http.StatusOK, http.StatusConflict}, status, result)
+	dynamic := ethereum.PoolKey{Currency0: ethereum.Token0_address, Currency1: ethereum.Token1_address, Fee: big.NewInt(ethereum.DynamicFeeFlag), TickSpacing: big.NewInt(60), Hooks: ethereum.HookAddress}
+
+	// A label records the fee, so it is removed from the dynamic fee pool
+	// without naming it again
+	status, result = postJSON(t, "/addLiquidityPermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000000",
+		"label":       "dynamic-permit",
+		"dynamicFee":  true,
+		"userAddress": depositorA.Hex(),
+		"privateKey":  depositorAKey,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	defer ethereum.Positions.Remove("dynamic-permit")
+	entry, ok := ethereum.Positions.Get("dynamic-permit")
+	require.True(t, ok)
+	assert.Equal(t, uint32(ethereum.DynamicFeeFlag), entry.Fee)
+	assert.Equal(t, dynamic.ID(), entry.PoolID)
+
+	status, result = postJSON(t, "/removeLiquidityPermit", map[string]interface{}{
+		"label":       "dynamic-permit",
+		"percentage":  100,
+		"userAddress": depositorA.Hex(),
+		"privateKey":  depositorAKey,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	assert.Equal(t, "1000000000", result["liquidityRemoved"])
+
+	// Without a label the reference names the fee
+	add := map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000",
+		"amount1Desired": "1000000000",
+		"tickLower":      -600,
+		"tickUpper":      600,
+		"salt":           "0x0d",
+		"dynamicFee":     true,
+	}
+	status, result = postJSON(t, "/addLiquidity", add)
+	require.Equal(t, http.StatusOK, status, result)
+	remove := map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"tickLower":  -600,
+		"tickUpper":  600,
+		"salt":       "0x0d",
+		"percentage": 100,
+	}
+	status, result = postJSON(t, "/removeLiquidity", remove)
+	assert.Equal(t, http.StatusBadRequest, status, result)
+	remove["dynamicFee"] = true
+	status, result = postJSON(t, "/removeLiquidity", remove)
+	require.Equal(t, http.StatusOK, status, result)
+}
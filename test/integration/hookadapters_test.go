@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const referralABIJSON = `[
+  {
+    "type": "event",
+    "name": "Referred",
+    "inputs": [
+      { "name": "poolId", "type": "bytes32", "indexed": true },
+      { "name": "referrer", "type": "address", "indexed": true },
+      { "name": "amount", "type": "uint256", "indexed": false }
+    ],
+    "anonymous": false
+  }
+]`
+
+var referralAdapterHook = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+
+// referralAdapter takes a referrer on swaps only and decodes Referred events.
+type referralAdapter struct {
+	hookadapters.Base
+	contract abi.ABI
+}
+
+func newReferralAdapter(t *testing.T) *referralAdapter {
+	contract, err := abi.JSON(strings.NewReader(referralABIJSON))
+	require.NoError(t, err)
+	return &referralAdapter{contract: contract}
+}
+
+func (a *referralAdapter) Name() string { return "referral-test" }
+
+func (a *referralAdapter) Match() hookadapters.Match {
+	return hookadapters.Match{Addresses: []common.Address{referralAdapterHook}}
+}
+
+func (a *referralAdapter) EncodeHookData(op hookadapters.Operation, hook common.Address, hookData json.RawMessage) ([]byte, error) {
+	if op != hookadapters.Swap {
+		return nil, hookadapters.ErrNotHandled
+	}
+	var referrer string
+	if err := json.Unmarshal(hookData, &referrer); err != nil || !common.IsHexAddress(referrer) {
+		return nil, fmt.Errorf("swap hookData is the referrer address")
+	}
+	return common.LeftPadBytes(common.HexToAddress(referrer).Bytes(), 32), nil
+}
+
+func (a *referralAdapter) HasEvents() bool { return true }
+
+func (a *referralAdapter) DecodeEvents(hook common.Address, logs []*types.Log) ([]hookadapters.Event, error) {
+	return hookadapters.DecodeABIEvents(&a.contract, hook, logs)
+}
+
+func TestHookAdapterRegistry(t *testing.T) {
+	registry := hookadapters.NewRegistry()
+	adapter := newReferralAdapter(t)
+	require.NoError(t, registry.Register(adapter))
+	assert.ErrorContains(t, registry.Register(adapter), "already registered")
+
+	found, err := registry.Lookup(referralAdapterHook)
+	require.NoError(t, err)
+	assert.Equal(t, adapter, found)
+
+	// Without code hash matchers unknown hooks resolve offline to nothing
+	found, err = registry.Lookup(common.HexToAddress("0x1234"))
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	assert.ErrorContains(t, registry.Bind(common.HexToAddress("0x1234"), "missing"), "unknown hook adapter")
+	require.NoError(t, registry.Register(hookadapters.Counter{}))
+	require.NoError(t, registry.Bind(common.HexToAddress("0x1234"), hookadapters.CounterName))
+	found, err = registry.Lookup(common.HexToAddress("0x1234"))
+	require.NoError(t, err)
+	assert.Equal(t, hookadapters.CounterName, found.Name())
+}
+
+func TestHookAdapterDecodeEvents(t *testing.T) {
+	adapter := newReferralAdapter(t)
+	event := adapter.contract.Events["Referred"]
+	poolID := common.HexToHash("0x01")
+	referrer := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(42))
+	require.NoError(t, err)
+
+	logs := []*types.Log{
+		{
+			Address: referralAdapterHook,
+			Topics:  []common.Hash{event.ID, poolID, common.BytesToHash(referrer.Bytes())},
+			Data:    data,
+			Index:   3,
+		},
+		// Logs of other contracts are skipped
+		{Address: ethereum.ManagerAddress, Topics: []common.Hash{event.ID}, Data: data},
+	}
+	// Only adapters that decode events make /performSwap wait for them
+	assert.True(t, adapter.HasEvents())
+	assert.False(t, hookadapters.Counter{}.HasEvents())
+
+	events, err := adapter.DecodeEvents(referralAdapterHook, logs)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Referred", events[0].Event)
+	assert.Equal(t, uint(3), events[0].LogIndex)
+	assert.Equal(t, map[string]interface{}{
+		"poolId":   poolID.Hex(),
+		"referrer": referrer.Hex(),
+		"amount":   "42",
+	}, events[0].Fields)
+}
+
+func TestHookAdapterEncodesHookData(t *testing.T) {
+	require.NoError(t, hookadapters.Default.Register(newReferralAdapter(t)))
+	referrer := "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+
+	status, result := postJSON(t, "/hookData/encode", map[string]interface{}{
+		"hook":      referralAdapterHook.Hex(),
+		"operation": "swap",
+		"hookData":  referrer,
+	})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "0x00000000000000000000000070997970c51812dc3a010c7d01b50e0d17dc79c8", result["hookData"])
+
+	status, result = postJSON(t, "/hookData/encode", map[string]interface{}{
+		"hook":     referralAdapterHook.Hex(),
+		"hookData": map[string]interface{}{"referrer": referrer},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "referral-test adapter")
+
+	// Other operations fall back to raw hex
+	status, result = postJSON(t, "/hookData/encode", map[string]interface{}{
+		"hook":      referralAdapterHook.Hex(),
+		"operation": "addLiquidity",
+		"hookData":  "0xabcd",
+	})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "0xabcd", result["hookData"])
+
+	status, _ = postJSON(t, "/hookData/encode", map[string]interface{}{"operation": "flash"})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestHookAdaptersRoute(t *testing.T) {
+	result := getJSON(t, "/hookAdapters")
+	adapters := result["adapters"].([]interface{})
+	var counter map[string]interface{}
+	for _, adapter := range adapters {
+		if info := adapter.(map[string]interface{}); info["name"] == hookadapters.CounterName {
+			counter = info
+		}
+	}
+	require.NotNil(t, counter)
+	assert.Contains(t, counter["bound"], ethereum.HookAddress.Hex())
+
+	resp, err := http.Get(testServer.URL + "/hooks/0x0000000000000000000000000000000000001234/state")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/hookdata"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var referralHook = common.HexToAddress("0x2725685Ef2DefFBa748CAFF8665985BE635B8aC0")
+
+func referralRegistry(t *testing.T) *hookdata.Registry {
+	registry, err := hookdata.NewRegistry(map[string][]config.HookDataField{
+		referralHook.Hex(): {
+			{Name: "referrer", Type: "address"},
+			{Name: "share", Type: "uint16"},
+			{Name: "update", Type: "tuple", Components: []config.HookDataField{
+				{Name: "price", Type: "uint256"},
+				{Name: "payload", Type: "bytes"},
+			}},
+		},
+	}, "")
+	require.NoError(t, err)
+	return registry
+}
+
+func TestHookDataEncodeSchema(t *testing.T) {
+	registry := referralRegistry(t)
+	referrer := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+
+	data, err := registry.Encode(referralHook, json.RawMessage(`{
+		"referrer": "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"share": 250,
+		"update": {"price": "340282366920938463463374607431768211456", "payload": "0xbeef"}
+	}`))
+	require.NoError(t, err)
+
+	// The same layout packed directly
+	uint16Type, _ := abi.NewType("uint16", "", nil)
+	addressType, _ := abi.NewType("address", "", nil)
+	tupleType, _ := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "price", Type: "uint256"},
+		{Name: "payload", Type: "bytes"},
+	})
+	expected, err := abi.Arguments{{Type: addressType}, {Type: uint16Type}, {Type: tupleType}}.Pack(
+		referrer,
+		uint16(250),
+		struct {
+			Price   *big.Int
+			Payload []byte
+		}{new(big.Int).Lsh(big.NewInt(1), 128), []byte{0xbe, 0xef}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+}
+
+func TestHookDataRawAndEmpty(t *testing.T) {
+	registry := referralRegistry(t)
+
+	data, err := registry.Encode(referralHook, nil)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	// Raw hex is passed through, with or without a schema
+	data, err = registry.Encode(common.Address{}, json.RawMessage(`"0x0102"`))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, data)
+}
+
+func TestHookDataRejected(t *testing.T) {
+	registry := referralRegistry(t)
+
+	for body, message := range map[string]string{
+		`{"referrer": "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "share": 1}`:                                                  "missing fields update",
+		`{"referrer": "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "share": 1, "update": {"price": 1, "payload": "0x"}, "x": 1}`: "unknown fields x",
+		`{"referrer": "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "share": 70000, "update": {"price": 1, "payload": "0x"}}`:     "does not fit uint16",
+		`{"referrer": "0x1234", "share": 1, "update": {"price": 1, "payload": "0x"}}`:                                             "expected an address",
+		`"0xzz"`: "invalid hex",
+		`42`:     "expected a hex string or an object",
+	} {
+		_, err := registry.Encode(referralHook, json.RawMessage(body))
+		assert.ErrorIs(t, err, hookdata.ErrInvalidHookData, body)
+		assert.ErrorContains(t, err, message, body)
+	}
+
+	_, err := registry.Encode(common.Address{}, json.RawMessage(`{"referrer": "0x0"}`))
+	assert.ErrorIs(t, err, hookdata.ErrNoSchema)
+}
+
+func TestHookDataSchemaRequiresNames(t *testing.T) {
+	_, err := hookdata.NewRegistry(map[string][]config.HookDataField{
+		referralHook.Hex(): {{Type: "address"}},
+	}, "")
+	assert.ErrorContains(t, err, "has no name")
+}
+
+func TestEncodeHookDataRoute(t *testing.T) {
+	status, result := postJSON(t, "/hookData/encode", map[string]interface{}{
+		"hook":     referralHook.Hex(),
+		"hookData": "0xabcd",
+	})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "0xabcd", result["hookData"])
+
+	// The test config registers no schemas
+	status, result = postJSON(t, "/hookData/encode", map[string]interface{}{
+		"hook":     referralHook.Hex(),
+		"hookData": map[string]interface{}{"referrer": referralHook.Hex()},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "no hookData schema")
+}
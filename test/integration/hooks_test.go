@@ -0,0 +1,44 @@
+package integration
+
+import (
+	"math/big"
+	"testing"
+	"uniswap-v4-rpc/pkg/hooks"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookPermissions(t *testing.T) {
+	// The Counter hook deployed by script/Anvil.s.sol
+	counter := common.HexToAddress("0x2725685Ef2DefFBa748CAFF8665985BE635B8aC0")
+	permissions := hooks.FromAddress(counter)
+	assert.Equal(t, []string{"beforeAddLiquidity", "beforeRemoveLiquidity", "beforeSwap", "afterSwap"}, permissions.Callbacks())
+	assert.Equal(t, hooks.Flags(counter), permissions.Flags())
+
+	// Bits above the 14 flags are ignored
+	assert.Equal(t, uint16(hooks.AllHookMask), hooks.Flags(common.HexToAddress("0xffffffffffffffffffffffffffffffffffffffff")))
+	assert.Empty(t, hooks.FromAddress(common.Address{}).Callbacks())
+}
+
+func TestValidatePoolKey(t *testing.T) {
+	static, dynamic := big.NewInt(3000), big.NewInt(0x800000)
+	counter := common.HexToAddress("0x2725685Ef2DefFBa748CAFF8665985BE635B8aC0")
+	noFlags := common.HexToAddress("0x1000000000000000000000000000000000000000")
+	// beforeSwapReturnDelta without beforeSwap
+	deltaOnly := common.BigToAddress(big.NewInt(hooks.BeforeSwapReturnsDeltaFlag))
+
+	assert.NoError(t, hooks.ValidatePoolKey(common.Address{}, static))
+	assert.NoError(t, hooks.ValidatePoolKey(counter, static))
+	assert.NoError(t, hooks.ValidatePoolKey(counter, dynamic))
+	assert.NoError(t, hooks.ValidatePoolKey(noFlags, dynamic))
+
+	for _, err := range []error{
+		hooks.ValidatePoolKey(common.Address{}, dynamic),
+		hooks.ValidatePoolKey(noFlags, static),
+		hooks.ValidatePoolKey(deltaOnly, static),
+	} {
+		assert.ErrorIs(t, err, hooks.ErrInvalidHook)
+	}
+	assert.ErrorContains(t, hooks.ValidatePoolKey(deltaOnly, static), "beforeSwapReturnDelta needs beforeSwap")
+}
@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookviews"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var counterHook = common.HexToAddress("0x2725685Ef2DefFBa748CAFF8665985BE635B8aC0")
+
+func TestHookViewsCounter(t *testing.T) {
+	registry, err := hookviews.NewRegistry(nil)
+	require.NoError(t, err)
+	registry.Register(counterHook, ethereum.CounterABI)
+
+	views, err := registry.Views(counterHook)
+	require.NoError(t, err)
+	var names []string
+	for _, view := range views {
+		names = append(names, view.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"afterSwapCount", "beforeAddLiquidityCount", "beforeRemoveLiquidityCount",
+		"beforeSwapCount", "getHookPermissions", "poolManager",
+	}, names)
+
+	poolID := common.HexToHash("0x01")
+	call, err := registry.Pack(counterHook, "beforeSwapCount", []interface{}{poolID.Hex()})
+	require.NoError(t, err)
+	expected, err := ethereum.CounterABI.Pack("beforeSwapCount", poolID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, call.Data)
+
+	// Outputs are keyed by name, integers as decimal strings
+	out, err := ethereum.CounterABI.Methods["beforeSwapCount"].Outputs.Pack(big.NewInt(7))
+	require.NoError(t, err)
+	outputs, err := call.Decode(out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"count": "7"}, outputs)
+}
+
+func TestHookViewsDecodeTuple(t *testing.T) {
+	registry, err := hookviews.NewRegistry(nil)
+	require.NoError(t, err)
+	registry.Register(counterHook, ethereum.CounterABI)
+
+	call, err := registry.Pack(counterHook, "getHookPermissions", []interface{}{})
+	require.NoError(t, err)
+	method := ethereum.CounterABI.Methods["getHookPermissions"]
+	assert.Equal(t, method.ID, call.Data)
+
+	// Unnamed outputs are keyed by position
+	out, err := method.Outputs.Pack(struct {
+		BeforeInitialize, AfterInitialize, BeforeAddLiquidity, AfterAddLiquidity bool
+		BeforeRemoveLiquidity, AfterRemoveLiquidity, BeforeSwap, AfterSwap       bool
+		BeforeDonate, AfterDonate, BeforeSwapReturnDelta, AfterSwapReturnDelta   bool
+		AfterAddLiquidityReturnDelta, AfterRemoveLiquidityReturnDelta            bool
+	}{BeforeSwap: true, AfterSwap: true})
+	require.NoError(t, err)
+	outputs, err := call.Decode(out)
+	require.NoError(t, err)
+	permissions := outputs["0"].(map[string]interface{})
+	assert.Equal(t, true, permissions["beforeSwap"])
+	assert.Equal(t, false, permissions["beforeInitialize"])
+}
+
+func TestHookViewsRejected(t *testing.T) {
+	registry, err := hookviews.NewRegistry(nil)
+	require.NoError(t, err)
+	registry.Register(counterHook, ethereum.CounterABI)
+
+	_, err = registry.Pack(common.Address{}, "beforeSwapCount", nil)
+	assert.ErrorIs(t, err, hookviews.ErrNoABI)
+	_, err = registry.Pack(counterHook, "unlockCallback", nil)
+	assert.ErrorIs(t, err, hookviews.ErrUnknownView)
+	_, err = registry.Pack(counterHook, "beforeSwapCount", []interface{}{})
+	assert.ErrorIs(t, err, hookviews.ErrInvalidArguments)
+	_, err = registry.Pack(counterHook, "beforeSwapCount", []interface{}{"0x01"})
+	assert.ErrorIs(t, err, hookviews.ErrInvalidArguments)
+	assert.ErrorContains(t, err, "expected 32 bytes")
+}
+
+func TestHookViewsLoadArtifact(t *testing.T) {
+	// A forge artifact keeps the ABI under "abi"
+	path := filepath.Join(t.TempDir(), "Counter.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"abi": `+ethereum.CounterABIJSON+`, "bytecode": {"object": "0x"}}`), 0o600))
+
+	registry, err := hookviews.NewRegistry(map[string]string{counterHook.Hex(): path})
+	require.NoError(t, err)
+	assert.Equal(t, []string{counterHook.Hex()}, registry.Hooks())
+
+	_, err = hookviews.NewRegistry(map[string]string{"0x1234": path})
+	assert.ErrorContains(t, err, "invalid hook address")
+}
+
+func TestHookViewsRoutes(t *testing.T) {
+	// The configured hook is read with the bundled Counter ABI
+	result := getJSON(t, "/hooks")
+	assert.Contains(t, result["hooks"], ethereum.HookAddress.Hex())
+	result = getJSON(t, "/hooks/"+ethereum.HookAddress.Hex()+"/views")
+	assert.Len(t, result["views"], len(ethereum.CounterABI.Methods))
+
+	status, result := postJSON(t, "/hooks/"+ethereum.HookAddress.Hex()+"/views/swap", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, result["error"], "unknown view function")
+
+	status, _ = postJSON(t, "/hooks/"+ethereum.HookAddress.Hex()+"/views/beforeSwapCount", map[string]interface{}{
+		"args": []string{hexutil.Encode([]byte{1})},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestGetHookStatsCountsSwaps(t *testing.T) {
+	path := "/hookStats?currency0=" + ethereum.Token0_address.Hex() + "&currency1=" + ethereum.Token1_address.Hex()
+	counts := func() (*big.Int, *big.Int) {
+		result := getJSON(t, path)
+		assert.Equal(t, ethereum.HookAddress.Hex(), result["hook"])
+		pools := result["pools"].([]interface{})
+		require.Len(t, pools, 1)
+		stats := pools[0].(map[string]interface{})
+		before, ok := new(big.Int).SetString(stats["beforeSwapCount"].(string), 10)
+		require.True(t, ok)
+		after, ok := new(big.Int).SetString(stats["afterSwapCount"].(string), 10)
+		require.True(t, ok)
+		return before, after
+	}
+	before0, after0 := counts()
+
+	status, result := postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"amount":     "1000000000",
+		"zeroForOne": true,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	before1, after1 := counts()
+	assert.Equal(t, new(big.Int).Add(before0, big.NewInt(1)).String(), before1.String())
+	assert.Equal(t, new(big.Int).Add(after0, big.NewInt(1)).String(), after1.String())
+}
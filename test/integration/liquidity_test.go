@@ -10,17 +10,18 @@ import (
 	"uniswap-v4-rpc/internal/ethereum"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAddLiquidity(t *testing.T) {
 	// Prepare add liquidity parameters
 	addLiquidityParams := map[string]interface{}{
-		"currency0": ethereum.Token0_address,
-		"currency1": ethereum.Token1_address,
-		"amount0":   "1000000000000000000", // 1 of token0
-		"amount1":   "1000000000000000000", // 1 of token1
-		"minTick":   "-887220",             // Example value, adjust as needed
-		"maxTick":   "887220",              // Example value, adjust as needed
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000000000000", // 1 of token0
+		"amount1Desired": "1000000000000000000", // 1 of token1
+		"tickLower":      -887220,
+		"tickUpper":      887220,
 	}
 
 	jsonParams, err := json.Marshal(addLiquidityParams)
@@ -45,3 +46,119 @@ func TestAddLiquidity(t *testing.T) {
 	assert.Contains(t, result, "balancesAfter")
 	assert.Contains(t, result, "deltaBalances")
 }
+
+func TestAddLiquidityRejectsUnalignedTicks(t *testing.T) {
+	status, result := postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000000000000",
+		"tickLower":      -100,
+		"tickUpper":      120,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "not a multiple of tickSpacing")
+
+	status, result = postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000000000000",
+		"tickLower":      -120,
+		"priceLower":     "0.5",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "not both")
+}
+
+func TestRemoveLiquidity(t *testing.T) {
+	status, result := postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000000000000",
+		"amount1Desired": "1000000000000000000",
+		"tickLower":      -600,
+		"tickUpper":      600,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	status, result = postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"tickLower":  -600,
+		"tickUpper":  600,
+		"percentage": 50,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	assert.Contains(t, result, "txHash")
+	assert.Contains(t, result, "received")
+	assert.Contains(t, result, "fees")
+
+	received := result["received"].(map[string]interface{})
+	principal := result["principal"].(map[string]interface{})
+	assert.NotEqual(t, "0", received["currency0"])
+	assert.NotEqual(t, "0", principal["currency0"])
+}
+
+func TestRemoveLiquidityValidatesAmount(t *testing.T) {
+	status, result := postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"liquidity":  "1000",
+		"percentage": 50,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "either liquidity or percentage")
+
+	status, result = postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"owner":      "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"percentage": 50,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "owned by the router")
+}
+
+func TestCollectFees(t *testing.T) {
+	status, result := postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000000000000",
+		"amount1Desired": "1000000000000000000",
+		"tickLower":      -600,
+		"tickUpper":      600,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	status, result = postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"amount":     "1000000000",
+		"zeroForOne": true,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	status, result = postJSON(t, "/collectFees", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"tickLower": -600,
+		"tickUpper": 600,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	assert.Contains(t, result, "txHash")
+
+	expected := result["expectedFees"].(map[string]interface{})
+	collected := result["collected"].(map[string]interface{})
+	assert.NotEqual(t, "0", expected["currency0"])
+	assert.Equal(t, expected["currency0"], collected["currency0"])
+	assert.Equal(t, expected["currency1"], collected["currency1"])
+}
+
+func TestCollectFeesRejectsForeignOwner(t *testing.T) {
+	status, result := postJSON(t, "/collectFees", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"owner":     "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "owned by the router")
+}
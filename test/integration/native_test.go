@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyVariants(t *testing.T) {
+	native := ethereum.NewCurrency(common.Address{})
+	assert.True(t, native.IsNative())
+	assert.Equal(t, common.Address{}, native.Address())
+	assert.IsType(t, ethereum.NativeCurrency{}, native)
+
+	token := ethereum.NewCurrency(ethereum.Token0_address)
+	assert.False(t, token.IsNative())
+	assert.Equal(t, ethereum.Token0_address, token.Address())
+}
+
+func TestNativeETHRequests(t *testing.T) {
+	eth := common.Address{}.Hex()
+
+	// ETH has no permit to relay
+	status, result := postJSON(t, "/performSwapWithPermit", map[string]interface{}{
+		"currency0":   eth,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000000",
+		"zeroForOne":  true,
+		"userAddress": "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "native ETH has no permit")
+
+	// An exact output paid in ETH needs a limit to send as value
+	status, result = postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0":  eth,
+		"currency1":  ethereum.Token1_address,
+		"amount":     "1000000000",
+		"zeroForOne": true,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "amountLimit is required")
+}
+
+// nativeAmounts parses the native ETH accounting of a response.
+func nativeAmounts(t *testing.T, result map[string]interface{}) (value, spent, refunded *big.Int) {
+	native, ok := result["native"].(map[string]interface{})
+	require.True(t, ok, result)
+	parse := func(field string) *big.Int {
+		amount, ok := new(big.Int).SetString(native[field].(string), 10)
+		require.True(t, ok, field)
+		return amount
+	}
+	return parse("value"), parse("spent"), parse("refunded")
+}
+
+func TestNativeETHLiquidityAndSwapRefund(t *testing.T) {
+	eth := common.Address{}.Hex()
+	status, result := postJSON(t, "/initialize", map[string]interface{}{
+		"currency0": eth,
+		"currency1": ethereum.Token1_address,
+	})
+	require.Contains(t, []int{http.StatusOK, http.StatusConflict}, status, result)
+	status, result = postJSON(t, "/approve", map[string]interface{}{
+		"currency0": eth,
+		"currency1": ethereum.Token1_address,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	// Full range at the initial 1:1 price takes equal amounts; the router
+	// refunds whatever ETH the pool does not take
+	status, result = postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0":      eth,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000000000000000000",
+		"amount1Desired": "1000000000000000000",
+		"tickLower":      -887220,
+		"tickUpper":      887220,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	value, spent, refunded := nativeAmounts(t, result)
+	assert.Positive(t, spent.Sign())
+	assert.True(t, spent.Cmp(value) <= 0)
+	assert.Equal(t, value, new(big.Int).Add(spent, refunded))
+
+	// An exact output sends its whole limit and gets back what the swap
+	// did not use
+	status, result = postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0":   eth,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000",
+		"zeroForOne":  true,
+		"amountLimit": "1000000000000000",
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	value, spent, refunded = nativeAmounts(t, result)
+	assert.Equal(t, "1000000000000000", value.String())
+	assert.Positive(t, spent.Sign())
+	assert.Positive(t, refunded.Sign())
+	assert.Equal(t, value, new(big.Int).Add(spent, refunded))
+}
@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/signer"
+	"uniswap-v4-rpc/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runtime code of a minimal contract wallet that returns the ERC-1271 magic
+// value for any signature.
+var contractWalletCode = common.FromHex("0x631626ba7e60e01b60005260206000f3")
+
+// deployWallet deploys runtime code from the server signer and returns its
+// address.
+func deployWallet(t *testing.T, runtime []byte) common.Address {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	chainID, err := ethereum.Client.ChainID(ctx)
+	require.NoError(t, err)
+
+	// The init code copies the runtime code that follows it and returns it
+	initCode := append([]byte{0x60, byte(len(runtime)), 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, byte(len(runtime)), 0x60, 0x00, 0xf3}, runtime...)
+	auth := signer.NewTransactor(ethereum.Signer, chainID)
+	auth.Context = ctx
+	address, tx, _, err := bind.DeployContract(auth, abi.ABI{}, initCode, ethereum.Client)
+	require.NoError(t, err)
+	_, err = bind.WaitDeployed(ctx, ethereum.Client, tx)
+	require.NoError(t, err)
+	return address
+}
+
+func TestSwapPermitRejectsSignerMismatch(t *testing.T) {
+	// The private key belongs to 0x328809Bc894f92807417D2dAD6b7C998c1aFdac6,
+	// so a permit signed with it can never be valid for the anvil default account.
+	swapParams := map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000000",
+		"zeroForOne":  true,
+		"userAddress": "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"privateKey":  "9c0257114eb9399a2985f8e75dad7600c5d89fe3824ffa99ec1c3eb8bf3b0501",
+	}
+
+	jsonParams, err := json.Marshal(swapParams)
+	assert.NoError(t, err)
+
+	resp, err := http.Post(testServer.URL+"/performSwapWithPermit", "application/json", bytes.NewBuffer(jsonParams))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var result map[string]interface{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	assert.NoError(t, err)
+	assert.Contains(t, result["error"], "permit signer does not match owner")
+}
+
+func TestSwapPermitRequiresKeyOrSignature(t *testing.T) {
+	status, result := postJSON(t, "/performSwapWithPermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000000",
+		"zeroForOne":  true,
+		"userAddress": "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "privateKey or permit signatures are required")
+
+	status, result = postJSON(t, "/addLiquidityPermit", map[string]interface{}{
+		"currency0":        ethereum.Token0_address,
+		"currency1":        ethereum.Token1_address,
+		"amount":           "1000000000",
+		"userAddress":      "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"permit0Signature": "0x1234",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "a permit signature is required for every token")
+}
+
+func TestPermitSignatureMustBe65Bytes(t *testing.T) {
+	permit := &utils.Permit{
+		Value:     big.NewInt(1),
+		Nonce:     big.NewInt(0),
+		Deadline:  big.NewInt(1),
+		Signature: make([]byte, 130),
+	}
+	err := utils.VerifyPermitSignature(permit)
+	assert.ErrorIs(t, err, utils.ErrPermitSignerMismatch)
+	assert.True(t, utils.IsPermitError(err))
+
+	sig := make([]byte, 65)
+	sig[31], sig[63], sig[64] = 1, 2, 1
+	permit.Signature = sig
+	v, r, s, err := permit.VRS()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(28), v)
+	assert.Equal(t, byte(1), r[31])
+	assert.Equal(t, byte(2), s[31])
+}
+
+func TestContractWalletPermitRejectedBeforeRelay(t *testing.T) {
+	wallet := deployWallet(t, contractWalletCode)
+
+	noncesBefore := map[common.Address]uint64{}
+	for _, status := range ethereum.Relayers.Status() {
+		noncesBefore[status.Address] = status.Nonce
+	}
+
+	// Contract wallets are unsupported even when the wallet would accept
+	// the signature, so nothing is relayed
+	sig := make([]byte, 65)
+	sig[64] = 27
+	status, result := postJSON(t, "/performSwapWithPermit", map[string]interface{}{
+		"currency0":       ethereum.Token0_address,
+		"currency1":       ethereum.Token1_address,
+		"amount":          "1000000000",
+		"zeroForOne":      true,
+		"userAddress":     wallet.Hex(),
+		"permitSignature": hexutil.Encode(sig),
+		"deadline":        big.NewInt(time.Now().Unix() + 3600).String(),
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "contract wallet")
+
+	status, result = postJSON(t, "/donatePermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount0":     "1000",
+		"userAddress": wallet.Hex(),
+		"privateKey":  "9c0257114eb9399a2985f8e75dad7600c5d89fe3824ffa99ec1c3eb8bf3b0501",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "contract wallet")
+
+	for _, status := range ethereum.Relayers.Status() {
+		assert.Equal(t, noncesBefore[status.Address], status.Nonce)
+	}
+}
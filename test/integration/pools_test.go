@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/pools"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func registryPool(currency0, currency1, hook common.Address, block uint64, logIndex uint) pools.Pool {
+	key := ethereum.PoolKey{
+		Currency0:   currency0,
+		Currency1:   currency1,
+		Fee:         big.NewInt(3000),
+		TickSpacing: big.NewInt(60),
+		Hooks:       hook,
+	}
+	return pools.Pool{
+		ID:           key.ID(),
+		Currency0:    currency0,
+		Currency1:    currency1,
+		Fee:          key.Fee,
+		TickSpacing:  key.TickSpacing,
+		Hooks:        hook,
+		SqrtPriceX96: new(big.Int).Lsh(big.NewInt(1), 96),
+		Block:        block,
+		LogIndex:     logIndex,
+	}
+}
+
+func TestPoolRegistry(t *testing.T) {
+	tokenA := common.HexToAddress("0x000000000000000000000000000000000000000a")
+	tokenB := common.HexToAddress("0x000000000000000000000000000000000000000b")
+	tokenC := common.HexToAddress("0x000000000000000000000000000000000000000c")
+	hook := common.HexToAddress("0x2725685Ef2DefFBa748CAFF8665985BE635B8aC0")
+
+	registry := pools.NewRegistry()
+	_, _, started := registry.Synced()
+	assert.False(t, started)
+
+	ab := registryPool(tokenA, tokenB, common.Address{}, 5, 1)
+	abHooked := registryPool(tokenA, tokenB, hook, 5, 0)
+	bc := registryPool(tokenB, tokenC, hook, 9, 0)
+	registry.Add(10, common.HexToHash("0x10"), bc, ab, abHooked)
+	// An Initialize seen again keeps the first entry
+	again := ab
+	again.Block = 12
+	registry.Add(12, common.HexToHash("0x12"), again)
+	// An older block does not move the synced block back
+	registry.Add(11, common.HexToHash("0x11"))
+
+	synced, hash, started := registry.Synced()
+	assert.True(t, started)
+	assert.Equal(t, uint64(12), synced)
+	assert.Equal(t, common.HexToHash("0x12"), hash)
+	assert.Equal(t, 3, registry.Len())
+
+	got, ok := registry.Get(ab.ID)
+	require.True(t, ok)
+	assert.Equal(t, uint64(5), got.Block)
+
+	ids := func(list []pools.Pool) []common.Hash {
+		var out []common.Hash
+		for _, pool := range list {
+			out = append(out, pool.ID)
+		}
+		return out
+	}
+	// Ordered by block and log index
+	assert.Equal(t, []common.Hash{abHooked.ID, ab.ID, bc.ID}, ids(registry.List(pools.Filter{})))
+	assert.Equal(t, []common.Hash{abHooked.ID, ab.ID, bc.ID}, ids(registry.List(pools.Filter{Currency: tokenB})))
+	assert.Equal(t, []common.Hash{bc.ID}, ids(registry.List(pools.Filter{Currency0: tokenB})))
+	assert.Equal(t, []common.Hash{abHooked.ID, bc.ID}, ids(registry.List(pools.Filter{Hooks: &hook})))
+	noHook := common.Address{}
+	assert.Equal(t, []common.Hash{ab.ID}, ids(registry.List(pools.Filter{Hooks: &noHook})))
+	assert.Empty(t, registry.List(pools.Filter{Currency: tokenC, Currency1: tokenB}))
+
+	registry.Reset()
+	assert.Equal(t, 0, registry.Len())
+	_, hash, started = registry.Synced()
+	assert.False(t, started)
+	assert.Equal(t, common.Hash{}, hash)
+}
+
+func TestPoolKeyOf(t *testing.T) {
+	pool := registryPool(common.HexToAddress("0x0a"), common.HexToAddress("0x0b"), common.Address{}, 1, 0)
+	assert.Equal(t, pool.ID, ethereum.PoolKeyOf(pool).ID())
+}
+
+func TestGetPoolRejectsInvalidID(t *testing.T) {
+	resp, err := http.Get(testServer.URL + "/pools/0x1234")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(testServer.URL + "/pools?currency=0x1234")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestInitializedPoolIsServed(t *testing.T) {
+	// Currencies no earlier run used, so the pool is initialized here. The
+	// PoolManager does not call them on initialize.
+	seed := []byte(time.Now().String())
+	currency0 := common.BytesToAddress(crypto.Keccak256(seed, []byte{0}))
+	currency1 := common.BytesToAddress(crypto.Keccak256(seed, []byte{1}))
+	if currency1.Hex() < currency0.Hex() {
+		currency0, currency1 = currency1, currency0
+	}
+	status, result := postJSON(t, "/initialize", map[string]interface{}{
+		"currency0": currency0.Hex(),
+		"currency1": currency1.Hex(),
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	poolID := result["poolId"].(string)
+
+	// The routes only read the registry, which the poller brings up to date
+	path := "/pools?currency0=" + currency0.Hex() + "&currency1=" + currency1.Hex()
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(testServer.URL + path)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		var listed map[string]interface{}
+		return resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&listed) == nil && listed["count"] == float64(1)
+	}, 30*time.Second, 250*time.Millisecond)
+
+	pool := getJSON(t, "/pools/"+poolID)
+	assert.Equal(t, poolID, pool["poolId"])
+	assert.Equal(t, currency0.Hex(), pool["currency0"])
+	assert.Equal(t, currency1.Hex(), pool["currency1"])
+	assert.Equal(t, "0", pool["current"].(map[string]interface{})["liquidity"])
+}
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4actions"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionPlanEncoding(t *testing.T) {
+	config := ethereum.PositionConfig{
+		PoolKey:   ethereum.PoolKey{Currency0: ethereum.Token0_address, Currency1: ethereum.Token1_address, Fee: big.NewInt(3000), TickSpacing: big.NewInt(60)},
+		TickLower: big.NewInt(-600),
+		TickUpper: big.NewInt(600),
+	}
+	owner := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	plan := new(v4actions.Plan).
+		MintPosition(config, big.NewInt(1000), big.NewInt(10), big.NewInt(20), owner, []byte{}).
+		SettlePair(ethereum.Token0_address, ethereum.Token1_address)
+	encoded, err := plan.Encode()
+	require.NoError(t, err)
+
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	bytesArray, _ := abi.NewType("bytes[]", "", nil)
+	values, err := abi.Arguments{{Type: bytesType}, {Type: bytesArray}}.Unpack(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{v4actions.MintPosition, v4actions.SettlePair}, values[0])
+
+	params := values[1].([][]byte)
+	require.Len(t, params, 2)
+	// config (7 words), liquidity, amount0Max, amount1Max, owner, hookData
+	// offset and length
+	mint := params[0]
+	require.Len(t, mint, 13*32)
+	assert.Equal(t, common.LeftPadBytes(ethereum.Token0_address.Bytes(), 32), mint[:32])
+	assert.Equal(t, int64(-600), new(big.Int).Sub(new(big.Int).SetBytes(mint[5*32:6*32]), new(big.Int).Lsh(big.NewInt(1), 256)).Int64())
+	assert.Equal(t, int64(1000), new(big.Int).SetBytes(mint[7*32:8*32]).Int64())
+	assert.Equal(t, owner.Bytes(), mint[10*32+12:11*32])
+	assert.Len(t, params[1], 64)
+
+	// Encoding errors surface from Encode
+	_, err = new(v4actions.Plan).Add(v4actions.Sweep, abi.Arguments{{Type: bytesType}}, "not bytes").SettlePair(common.Address{}, common.Address{}).Encode()
+	assert.Error(t, err)
+}
+
+func TestMintedTokenIDs(t *testing.T) {
+	event := ethereum.PositionManagerABI.Events["MintPosition"]
+	receipt := &types.Receipt{Logs: []*types.Log{
+		{Address: ethereum.PositionManagerAddress, Topics: []common.Hash{event.ID, common.BigToHash(big.NewInt(7))}},
+		{Address: common.HexToAddress("0x01"), Topics: []common.Hash{event.ID, common.BigToHash(big.NewInt(8))}},
+	}}
+	ids := ethereum.MintedTokenIDs(receipt)
+	require.Len(t, ids, 1)
+	assert.Equal(t, int64(7), ids[0].Int64())
+}
+
+func TestPositionManagerRoutesNeedConfig(t *testing.T) {
+	if ethereum.HasPositionManager() {
+		t.Skip("position manager is configured")
+	}
+	status, result := postJSON(t, "/mintPosition", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000",
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, result["error"], "position_manager_address")
+}
+
+// positionNFTLiquidity reads the liquidity of a position NFT.
+func positionNFTLiquidity(t *testing.T, tokenID string) *big.Int {
+	liquidity, ok := new(big.Int).SetString(getJSON(t, "/positionNFT/"+tokenID)["liquidity"].(string), 10)
+	require.True(t, ok)
+	return liquidity
+}
+
+func TestPositionManagerLifecycle(t *testing.T) {
+	if !ethereum.HasPositionManager() {
+		t.Skip("position_manager_address is not configured")
+	}
+	status, result := postJSON(t, "/approve", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	status, result = postJSON(t, "/mintPosition", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"tickLower":      -600,
+		"tickUpper":      600,
+		"amount0Desired": "1000000000000000000",
+		"amount1Desired": "1000000000000000000",
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	tokenID := result["tokenId"].(string)
+	assert.Equal(t, ethereum.Signer.Address().Hex(), result["owner"])
+	minted := positionNFTLiquidity(t, tokenID)
+	assert.Equal(t, result["params"].(map[string]interface{})["liquidity"], minted.String())
+
+	status, result = postJSON(t, "/increasePosition", map[string]interface{}{
+		"tokenId":        tokenID,
+		"amount0Desired": "500000000000000000",
+		"amount1Desired": "500000000000000000",
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	added, ok := new(big.Int).SetString(result["params"].(map[string]interface{})["liquidityAdded"].(string), 10)
+	require.True(t, ok)
+	increased := positionNFTLiquidity(t, tokenID)
+	assert.Equal(t, new(big.Int).Add(minted, added), increased)
+
+	status, result = postJSON(t, "/decreasePosition", map[string]interface{}{
+		"tokenId":    tokenID,
+		"percentage": 50,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	removed, ok := new(big.Int).SetString(result["liquidityRemoved"].(string), 10)
+	require.True(t, ok)
+	assert.Equal(t, new(big.Int).Div(increased, big.NewInt(2)), removed)
+	assert.Equal(t, new(big.Int).Sub(increased, removed), positionNFTLiquidity(t, tokenID))
+
+	status, result = postJSON(t, "/decreasePosition", map[string]interface{}{
+		"tokenId": tokenID,
+		"burn":    true,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	assert.Equal(t, true, result["burned"])
+	resp, err := http.Get(testServer.URL + "/positionNFT/" + tokenID)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
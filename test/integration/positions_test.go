@@ -0,0 +1,325 @@
+package integration
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/positions"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionRegistryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "positions.json")
+	registry, err := positions.NewRegistry(path)
+	require.NoError(t, err)
+
+	poolID := common.HexToHash("0x01")
+	first := positions.Position{Label: "a", PoolID: poolID, TickLower: -600, TickUpper: 600, Salt: positions.DeriveSalt("a")}
+	require.NoError(t, registry.Register(first))
+	require.NoError(t, registry.Register(first))
+
+	// A label points at one position and a position has one label
+	moved := first
+	moved.TickUpper = 1200
+	assert.ErrorIs(t, registry.Register(moved), positions.ErrLabelConflict)
+	alias := first
+	alias.Label = "b"
+	assert.ErrorIs(t, registry.Register(alias), positions.ErrLabelConflict)
+
+	second := positions.Position{Label: "b", PoolID: poolID, TickLower: -600, TickUpper: 600, Salt: positions.DeriveSalt("b")}
+	require.NoError(t, registry.Register(second))
+	assert.NotEqual(t, first.Salt, second.Salt)
+
+	reloaded, err := positions.NewRegistry(path)
+	require.NoError(t, err)
+	list := reloaded.List(&poolID)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].Label)
+	assert.Equal(t, first.Salt, list[0].Salt)
+
+	assert.True(t, reloaded.Remove("a"))
+	_, ok := reloaded.Get("a")
+	assert.False(t, ok)
+
+	salt := positions.DepositorSalt(depositorB, common.HexToHash("0x01"))
+	reloaded.RecordDeposit(salt, depositorB)
+	reloaded, err = positions.NewRegistry(path)
+	require.NoError(t, err)
+	depositor, ok := reloaded.Depositor(salt)
+	assert.True(t, ok)
+	assert.Equal(t, depositorB, depositor)
+	assert.Len(t, reloaded.List(&poolID), 1)
+}
+
+func TestPositionRegistryReadsPositionArray(t *testing.T) {
+	// Registries were written as a bare array before deposits were recorded
+	path := filepath.Join(t.TempDir(), "positions.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"label":"a","tickLower":-600,"tickUpper":600}]`), 0600))
+	registry, err := positions.NewRegistry(path)
+	require.NoError(t, err)
+	entry, ok := registry.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, 600, entry.TickUpper)
+}
+
+func TestLabelledPositions(t *testing.T) {
+	for _, label := range []string{"range-a", "range-b"} {
+		status, result := postJSON(t, "/addLiquidity", map[string]interface{}{
+			"currency0":      ethereum.Token0_address,
+			"currency1":      ethereum.Token1_address,
+			"amount0Desired": "1000000000000000000",
+			"amount1Desired": "1000000000000000000",
+			"tickLower":      -1200,
+			"tickUpper":      1200,
+			"label":          label,
+		})
+		require.Equal(t, http.StatusOK, status, result)
+	}
+
+	// Same range, separate positions
+	a, b := getJSON(t, "/positions/range-a"), getJSON(t, "/positions/range-b")
+	assert.NotEqual(t, a["position"].(map[string]interface{})["salt"], b["position"].(map[string]interface{})["salt"])
+	assert.NotEqual(t, "0", a["liquidity"])
+
+	status, result := postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"label":      "range-a",
+		"percentage": 100,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	status, _ = postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"label":      "range-a",
+		"percentage": 100,
+	})
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.NotEqual(t, "0", getJSON(t, "/positions/range-b")["liquidity"])
+}
+
+func TestPositionLabelConflict(t *testing.T) {
+	require.NoError(t, ethereum.Positions.Register(positions.Position{
+		Label: "taken", TickLower: -600, TickUpper: 600, Salt: positions.DeriveSalt("taken"),
+	}))
+	defer ethereum.Positions.Remove("taken")
+
+	status, result := postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0":      ethereum.Token0_address,
+		"currency1":      ethereum.Token1_address,
+		"amount0Desired": "1000",
+		"label":          "taken",
+		"tickLower":      -600,
+		"tickUpper":      600,
+		"mode":           "unsigned",
+	})
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, result["error"], "position label conflict")
+}
+
+const (
+	// depositorAKey is the server's anvil account, which holds test tokens
+	depositorAKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	depositorBKey = "9c0257114eb9399a2985f8e75dad7600c5d89fe3824ffa99ec1c3eb8bf3b0501"
+)
+
+var (
+	depositorA = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	depositorB = common.HexToAddress("0x328809Bc894f92807417D2dAD6b7C998c1aFdac6")
+)
+
+func TestRemoveLiquidityPermitRequiresDepositor(t *testing.T) {
+	// A full range position, as /addLiquidityPermit adds
+	require.NoError(t, ethereum.Positions.Register(positions.Position{
+		Label:     "deposit-a",
+		PoolID:    registryPool(ethereum.Token0_address, ethereum.Token1_address, ethereum.HookAddress, 0, 0).ID,
+		TickLower: -887220,
+		TickUpper: 887220,
+		Salt:      positions.DeriveSalt("deposit-a"),
+		Depositor: depositorA,
+	}))
+	defer ethereum.Positions.Remove("deposit-a")
+
+	status, result := postJSON(t, "/removeLiquidityPermit", map[string]interface{}{
+		"label":       "deposit-a",
+		"percentage":  100,
+		"userAddress": depositorB.Hex(),
+		"privateKey":  depositorBKey,
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, result["error"], "was not deposited by")
+
+	// Nor can B top up A's position
+	status, _ = postJSON(t, "/addLiquidityPermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000",
+		"label":       "deposit-a",
+		"userAddress": depositorB.Hex(),
+		"privateKey":  depositorBKey,
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+}
+
+func TestRemoveLiquidityPermitOnlyReachesOwnDeposits(t *testing.T) {
+	status, result := postJSON(t, "/addLiquidityPermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000000",
+		"salt":        "0x01",
+		"userAddress": depositorA.Hex(),
+		"privateKey":  depositorAKey,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	assert.NotEqual(t, result["salt"], result["positionSalt"])
+
+	// B naming the same salt, or A's position salt, reaches their own empty
+	// positions only
+	for _, salt := range []interface{}{"0x01", result["positionSalt"], "0x00"} {
+		status, result := postJSON(t, "/removeLiquidityPermit", map[string]interface{}{
+			"currency0":   ethereum.Token0_address,
+			"currency1":   ethereum.Token1_address,
+			"salt":        salt,
+			"percentage":  100,
+			"userAddress": depositorB.Hex(),
+			"privateKey":  depositorBKey,
+		})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, result["error"], "has no liquidity")
+	}
+
+	status, result = postJSON(t, "/removeLiquidityPermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"salt":        "0x01",
+		"percentage":  100,
+		"userAddress": depositorA.Hex(),
+		"privateKey":  depositorAKey,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+	assert.Equal(t, "1000000000", result["liquidityRemoved"])
+}
+
+func TestRemoveLiquidityRefusesPermitDeposits(t *testing.T) {
+	require.NoError(t, ethereum.Positions.Register(positions.Position{
+		Label:     "deposit-b",
+		PoolID:    registryPool(ethereum.Token0_address, ethereum.Token1_address, ethereum.HookAddress, 0, 0).ID,
+		TickLower: -887220,
+		TickUpper: 887220,
+		Salt:      positions.DeriveSalt("deposit-b"),
+		Depositor: depositorB,
+	}))
+	defer ethereum.Positions.Remove("deposit-b")
+
+	status, result := postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"label":      "deposit-b",
+		"percentage": 100,
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, result["error"], "was not deposited by")
+
+	salt := positions.DepositorSalt(depositorB, common.HexToHash("0x02"))
+	ethereum.Positions.RecordDeposit(salt, depositorB)
+	status, result = postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"salt":       salt.Hex(),
+		"percentage": 100,
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, result["error"], "holds a permit deposit")
+
+	// Nor can the server collect their fees
+	status, result = postJSON(t, "/collectFees", map[string]interface{}{"label": "deposit-b"})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, result["error"], "was not deposited by")
+	status, result = postJSON(t, "/collectFees", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"salt":      salt.Hex(),
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, result["error"], "holds a permit deposit")
+}
+
+func TestRemoveLiquidityRefusesPositionDepositedWithPermit(t *testing.T) {
+	status, result := postJSON(t, "/addLiquidityPermit", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"amount":      "1000000000",
+		"salt":        "0x03",
+		"userAddress": depositorA.Hex(),
+		"privateKey":  depositorAKey,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	// The server account's route cannot reach the deposit, even though it
+	// holds liquidity
+	status, result = postJSON(t, "/removeLiquidity", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"salt":       result["positionSalt"],
+		"percentage": 100,
+	})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, result["error"], "holds a permit deposit")
+}
+
+func TestPositionRegistryPending(t *testing.T) {
+	registry, err := positions.NewRegistry("")
+	require.NoError(t, err)
+	entry := positions.Position{Label: "p", TickLower: -600, TickUpper: 600, Salt: positions.DeriveSalt("p"), Pending: true}
+	require.NoError(t, registry.Register(entry))
+
+	got, _ := registry.Get("p")
+	assert.True(t, got.Pending)
+	assert.True(t, registry.Release("p"))
+	_, ok := registry.Get("p")
+	assert.False(t, ok)
+
+	// A confirmed entry is not released
+	require.NoError(t, registry.Register(entry))
+	registry.Confirm("p")
+	assert.False(t, registry.Release("p"))
+	got, _ = registry.Get("p")
+	assert.False(t, got.Pending)
+
+	// Registering the same position confirmed confirms a pending entry
+	other := positions.Position{Label: "q", TickLower: -600, TickUpper: 600, Salt: positions.DeriveSalt("q"), Pending: true}
+	require.NoError(t, registry.Register(other))
+	other.Pending = false
+	require.NoError(t, registry.Register(other))
+	got, _ = registry.Get("q")
+	assert.False(t, got.Pending)
+}
+
+func TestUnsignedAddLiquidityRegistersPending(t *testing.T) {
+	add := func(tickLower, tickUpper int) (int, map[string]interface{}) {
+		return postJSON(t, "/addLiquidity", map[string]interface{}{
+			"currency0":      ethereum.Token0_address,
+			"currency1":      ethereum.Token1_address,
+			"amount0Desired": "1000",
+			"amount1Desired": "1000",
+			"tickLower":      tickLower,
+			"tickUpper":      tickUpper,
+			"label":          "unsigned-pending",
+			"mode":           "unsigned",
+		})
+	}
+	status, result := add(-600, 600)
+	require.Equal(t, http.StatusOK, status, result)
+	defer ethereum.Positions.Remove("unsigned-pending")
+
+	entry, ok := ethereum.Positions.Get("unsigned-pending")
+	require.True(t, ok)
+	assert.True(t, entry.Pending)
+
+	// Nothing was sent, so the label can move to another range
+	status, result = add(-1200, 1200)
+	require.Equal(t, http.StatusOK, status, result)
+	entry, _ = ethereum.Positions.Get("unsigned-pending")
+	assert.Equal(t, -1200, entry.TickLower)
+	assert.True(t, entry.Pending)
+}
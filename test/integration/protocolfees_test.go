@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolFeePacking(t *testing.T) {
+	fee := ethereum.PackProtocolFee(1000, 500)
+	assert.Equal(t, uint32(500<<12|1000), fee)
+	zeroForOne, oneForZero := ethereum.UnpackProtocolFee(fee)
+	assert.Equal(t, uint32(1000), zeroForOne)
+	assert.Equal(t, uint32(500), oneForZero)
+
+	assert.NoError(t, ethereum.ValidateProtocolFee(fee))
+	assert.ErrorContains(t, ethereum.ValidateProtocolFee(ethereum.PackProtocolFee(1001, 0)), "ProtocolFeeTooLarge")
+	assert.ErrorContains(t, ethereum.ValidateProtocolFee(ethereum.PackProtocolFee(0, 1001)), "ProtocolFeeTooLarge")
+}
+
+func TestProtocolFeeEvents(t *testing.T) {
+	poolID := common.HexToHash("0x01")
+	controller := common.HexToAddress("0x02")
+	receipt := &types.Receipt{Logs: []*types.Log{
+		{
+			Address: ethereum.ManagerAddress,
+			Topics:  []common.Hash{ethereum.ManagerABI.Events["ProtocolFeeUpdated"].ID, poolID},
+			Data:    common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+		},
+		{
+			Address: ethereum.ManagerAddress,
+			Topics:  []common.Hash{ethereum.ManagerABI.Events["ProtocolFeeControllerUpdated"].ID, common.BytesToHash(controller.Bytes())},
+		},
+		// Other contracts' logs are ignored
+		{
+			Address: common.HexToAddress("0x03"),
+			Topics:  []common.Hash{ethereum.ManagerABI.Events["ProtocolFeeUpdated"].ID, poolID},
+		},
+	}}
+	events := ethereum.ProtocolFeeEvents(receipt)
+	require.Len(t, events, 2)
+	assert.Equal(t, "ProtocolFeeUpdated", events[0].Event)
+	assert.Equal(t, poolID, *events[0].PoolID)
+	assert.Equal(t, uint32(1000), *events[0].ProtocolFee)
+	assert.Equal(t, "ProtocolFeeControllerUpdated", events[1].Event)
+	assert.Equal(t, controller, *events[1].Controller)
+}
+
+func TestProtocolFeeRoutesAreAdminOnly(t *testing.T) {
+	status, _ := postJSON(t, "/admin/protocolFees/setFee", map[string]interface{}{
+		"currency0":   ethereum.Token0_address,
+		"currency1":   ethereum.Token1_address,
+		"protocolFee": 1000,
+	})
+	assert.Contains(t, []int{http.StatusForbidden, http.StatusUnauthorized}, status)
+}
@@ -0,0 +1,291 @@
+package integration
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"uniswap-v4-rpc/internal/relayer"
+	"uniswap-v4-rpc/internal/signer"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRelayerBackend serves nonces and balances from maps, records the
+// transactions sent to it and never mines.
+type fakeRelayerBackend struct {
+	mu       sync.Mutex
+	nonces   map[common.Address]uint64
+	balances map[common.Address]*big.Int
+	sent     []*types.Transaction
+}
+
+func (b *fakeRelayerBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nonces[account], nil
+}
+
+func (b *fakeRelayerBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if balance, ok := b.balances[account]; ok {
+		return balance, nil
+	}
+	return new(big.Int), nil
+}
+
+func (b *fakeRelayerBackend) setNonce(account common.Address, nonce uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nonces[account] = nonce
+}
+
+func (b *fakeRelayerBackend) setBalance(account common.Address, balance *big.Int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.balances[account] = balance
+}
+
+func (b *fakeRelayerBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+func (b *fakeRelayerBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(31337), nil
+}
+
+func (b *fakeRelayerBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1e9), nil
+}
+
+func (b *fakeRelayerBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *fakeRelayerBackend) sentTransactions() []*types.Transaction {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*types.Transaction(nil), b.sent...)
+}
+
+func newTestRelayers(t *testing.T, seeds ...string) ([]signer.Signer, *fakeRelayerBackend) {
+	backend := &fakeRelayerBackend{nonces: map[common.Address]uint64{}, balances: map[common.Address]*big.Int{}}
+	var signers []signer.Signer
+	for _, seed := range seeds {
+		s, err := signer.NewLocalSignerFromSeed(seed)
+		require.NoError(t, err)
+		signers = append(signers, s)
+		backend.setBalance(s.Address(), big.NewInt(1e18))
+	}
+	return signers, backend
+}
+
+func TestRelayerPoolRoundRobin(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a", "relayer-b")
+	backend.setNonce(signers[1].Address(), 5)
+
+	pool, err := relayer.NewPool(backend, signers, relayer.RoundRobin, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	expected := []struct {
+		address common.Address
+		nonce   uint64
+	}{
+		{signers[0].Address(), 0},
+		{signers[1].Address(), 5},
+		{signers[0].Address(), 1},
+		{signers[1].Address(), 6},
+	}
+	for _, e := range expected {
+		lease, err := pool.Acquire(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, e.address, lease.Address())
+		assert.Equal(t, e.nonce, lease.Nonce)
+	}
+}
+
+func TestRelayerPoolLeastPending(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a", "relayer-b", "relayer-c")
+	pool, err := relayer.NewPool(backend, signers, relayer.LeastPending, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	first, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	second, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Address(), second.Address())
+
+	// Releasing the first lease makes its account the least busy again
+	first.Failed()
+	_, err = pool.Acquire(ctx)
+	require.NoError(t, err)
+	third, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, second.Address(), third.Address())
+}
+
+func TestRelayerPoolMarksLowBalanceUnhealthy(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a", "relayer-b")
+	backend.setBalance(signers[0].Address(), big.NewInt(1))
+
+	pool, err := relayer.NewPool(backend, signers, relayer.RoundRobin, big.NewInt(1e16))
+	require.NoError(t, err)
+	pool.RefreshBalances(context.Background())
+
+	for i := 0; i < 3; i++ {
+		lease, err := pool.Acquire(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, signers[1].Address(), lease.Address())
+	}
+
+	backend.setBalance(signers[1].Address(), big.NewInt(0))
+	pool.RefreshBalances(context.Background())
+	_, err = pool.Acquire(context.Background())
+	assert.ErrorIs(t, err, relayer.ErrNoHealthyRelayer)
+}
+
+func TestRelayerPoolReloadsNonceAfterFailure(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a")
+	pool, err := relayer.NewPool(backend, signers, relayer.RoundRobin, nil)
+	require.NoError(t, err)
+
+	lease, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), lease.Nonce)
+
+	backend.setNonce(signers[0].Address(), 3)
+	lease.Failed()
+
+	lease, err = pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), lease.Nonce)
+}
+
+func TestRelayerPoolReportsReceiptTimeout(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a")
+	pool, err := relayer.NewPool(backend, signers, relayer.RoundRobin, nil)
+	require.NoError(t, err)
+	pool.ReceiptPollInterval = time.Millisecond
+	pool.ReceiptTimeout = 20 * time.Millisecond
+
+	lease, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	backend.setNonce(signers[0].Address(), 7)
+
+	// The fake backend never mines, so the callback gets no receipt
+	done := make(chan *types.Receipt, 1)
+	lease.Sent(types.NewTransaction(lease.Nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil), func(receipt *types.Receipt) {
+		done <- receipt
+	})
+	select {
+	case receipt := <-done:
+		assert.Nil(t, receipt)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not called after the receipt timeout")
+	}
+
+	status := pool.Status()
+	assert.Equal(t, 0, status[0].Pending)
+	lease, err = pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), lease.Nonce)
+}
+
+func TestRelayerRefundSendsBackSuccessfulPulls(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a")
+	pool, err := relayer.NewPool(backend, signers, relayer.RoundRobin, nil)
+	require.NoError(t, err)
+	account := signers[0].Address()
+	backend.setNonce(account, 4)
+
+	tokenA := common.HexToAddress("0x000000000000000000000000000000000000000a")
+	tokenB := common.HexToAddress("0x000000000000000000000000000000000000000b")
+	transfer := func(token common.Address, amount *big.Int) ([]byte, uint64, error) {
+		return append(token.Bytes(), amount.Bytes()...), 100000, nil
+	}
+	succeeded := &types.Receipt{Status: types.ReceiptStatusSuccessful}
+	reverted := &types.Receipt{Status: types.ReceiptStatusFailed}
+
+	// A successful call and a call without receipt send nothing back
+	for _, receipt := range []*types.Receipt{succeeded, nil} {
+		refund := pool.NewRefund(1, transfer)
+		refund.From(account)
+		refund.Pulled(tokenA, big.NewInt(5))(succeeded)
+		refund.Spent(receipt)
+	}
+	assert.Empty(t, backend.sentTransactions())
+
+	// Only the pull that succeeded is sent back when the call reverts
+	refund := pool.NewRefund(2, transfer)
+	refund.From(account)
+	pulledA := refund.Pulled(tokenA, big.NewInt(5))
+	pulledB := refund.Pulled(tokenB, big.NewInt(7))
+	done := make(chan struct{})
+	go func() {
+		refund.Spent(reverted)
+		close(done)
+	}()
+	// The call's receipt may arrive before those of the pulls
+	pulledA(succeeded)
+	pulledB(reverted)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("refund did not wait for the pulls")
+	}
+
+	sent := backend.sentTransactions()
+	require.Len(t, sent, 1)
+	assert.Equal(t, tokenA, *sent[0].To())
+	assert.Equal(t, append(tokenA.Bytes(), 5), sent[0].Data())
+	assert.Equal(t, uint64(4), sent[0].Nonce())
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(31337)), sent[0])
+	require.NoError(t, err)
+	assert.Equal(t, account, from)
+}
+
+func TestRelayerPoolReusesFailedNonceWhileLeasesAreOutstanding(t *testing.T) {
+	signers, backend := newTestRelayers(t, "relayer-a")
+	pool, err := relayer.NewPool(backend, signers, relayer.RoundRobin, nil)
+	require.NoError(t, err)
+	account := signers[0].Address()
+	ctx := context.Background()
+
+	first, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	second, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{0, 1}, []uint64{first.Nonce, second.Nonce})
+
+	// The node has not seen the second lease yet, so reloading here would
+	// hand out its nonce again
+	backend.setNonce(account, 0)
+	first.Failed()
+	third, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), third.Nonce)
+	fourth, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), fourth.Nonce)
+
+	// Once nothing is outstanding after a failure the node is asked again
+	second.Failed()
+	third.Failed()
+	fourth.Failed()
+	backend.setNonce(account, 9)
+	lease, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), lease.Nonce)
+}
@@ -0,0 +1,184 @@
+package integration
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/routing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routingKey(a, b string, fee int64) ethereum.PoolKey {
+	currency0, currency1 := common.HexToAddress(a), common.HexToAddress(b)
+	if currency1.Hex() < currency0.Hex() {
+		currency0, currency1 = currency1, currency0
+	}
+	return ethereum.PoolKey{Currency0: currency0, Currency1: currency1, Fee: big.NewInt(fee), TickSpacing: big.NewInt(60)}
+}
+
+func TestRoutingPaths(t *testing.T) {
+	a, b, c, d := "0x0a", "0x0b", "0x0c", "0x0d"
+	pools := []ethereum.PoolKey{
+		routingKey(a, b, 3000),
+		routingKey(b, c, 3000),
+		routingKey(a, c, 500),
+		routingKey(c, d, 3000),
+	}
+
+	routes := routing.Paths(pools, common.HexToAddress(a), common.HexToAddress(c), 1)
+	require.Len(t, routes, 1)
+	assert.Equal(t, int64(500), routes[0].Hops[0].PoolKey.Fee.Int64())
+
+	routes = routing.Paths(pools, common.HexToAddress(a), common.HexToAddress(c), 3)
+	require.Len(t, routes, 2)
+	assert.Equal(t, []common.Address{common.HexToAddress(a), common.HexToAddress(b), common.HexToAddress(c)}, routes[0].Currencies())
+
+	routes = routing.Paths(pools, common.HexToAddress(d), common.HexToAddress(a), 3)
+	require.Len(t, routes, 2)
+	assert.False(t, routes[0].Hops[0].ZeroForOne)
+}
+
+func TestRoutingBest(t *testing.T) {
+	a, b, c := "0x0a", "0x0b", "0x0c"
+	pools := []ethereum.PoolKey{routingKey(a, b, 3000), routingKey(b, c, 3000), routingKey(a, c, 500)}
+	routes := routing.Paths(pools, common.HexToAddress(a), common.HexToAddress(c), 2)
+	require.Len(t, routes, 2)
+
+	// The direct pool pays half, each hop of the two hop path 90%
+	quote := func(key ethereum.PoolKey, hookData []byte, zeroForOne, exactIn bool, amount *big.Int) (*big.Int, *big.Int, error) {
+		num, den := big.NewInt(9), big.NewInt(10)
+		if key.Fee.Int64() == 500 {
+			num, den = big.NewInt(1), big.NewInt(2)
+		}
+		if exactIn {
+			out := new(big.Int).Mul(amount, num)
+			return amount, out.Div(out, den), nil
+		}
+		in := new(big.Int).Mul(amount, den)
+		return in.Div(in, num), amount, nil
+	}
+
+	best, err := routing.Best(routes, true, big.NewInt(1000), quote)
+	require.NoError(t, err)
+	require.Len(t, best.Hops, 2)
+	assert.Equal(t, "900", best.Hops[0].AmountOut.String())
+	assert.Equal(t, "810", best.AmountOut().String())
+
+	best, err = routing.Best(routes, false, big.NewInt(810), quote)
+	require.NoError(t, err)
+	require.Len(t, best.Hops, 2)
+	assert.Equal(t, "1000", best.AmountIn().String())
+
+	// Exact input paths name each hop's output, exact output paths its input
+	assert.Equal(t, common.HexToAddress(b), best.PathKeys(true)[0].IntermediateCurrency)
+	assert.Equal(t, common.HexToAddress(a), best.PathKeys(false)[0].IntermediateCurrency)
+
+	failing := func(ethereum.PoolKey, []byte, bool, bool, *big.Int) (*big.Int, *big.Int, error) {
+		return nil, nil, fmt.Errorf("no liquidity")
+	}
+	_, err = routing.Best(routes, true, big.NewInt(1000), failing)
+	assert.ErrorIs(t, err, routing.ErrNoRoute)
+}
+
+func TestRoutingHookData(t *testing.T) {
+	a, b, c := "0x0a", "0x0b", "0x0c"
+	hook := common.HexToAddress("0x0100")
+	pools := []ethereum.PoolKey{routingKey(a, b, 3000), routingKey(b, c, 3000)}
+	pools[1].Hooks = hook
+	routes := routing.Paths(pools, common.HexToAddress(a), common.HexToAddress(c), 2)
+	require.Len(t, routes, 1)
+	routing.SetHookData(routes, map[common.Address][]byte{hook: {0x12, 0x34}})
+
+	// The quote sees the bytes the path sends
+	quoted := map[common.Address][]byte{}
+	quote := func(key ethereum.PoolKey, hookData []byte, zeroForOne, exactIn bool, amount *big.Int) (*big.Int, *big.Int, error) {
+		quoted[key.Hooks] = hookData
+		return amount, amount, nil
+	}
+	best, err := routing.Best(routes, true, big.NewInt(1000), quote)
+	require.NoError(t, err)
+	assert.Nil(t, quoted[common.Address{}])
+	assert.Equal(t, []byte{0x12, 0x34}, quoted[hook])
+
+	path := best.PathKeys(true)
+	assert.Equal(t, []byte{}, path[0].HookData)
+	assert.Equal(t, []byte{0x12, 0x34}, path[1].HookData)
+}
+
+func TestRouteSwapValidation(t *testing.T) {
+	status, result := postJSON(t, "/routeSwap", map[string]interface{}{
+		"currencyIn":  ethereum.Token0_address,
+		"currencyOut": ethereum.Token1_address,
+		"amountIn":    "1000",
+		"amountOut":   "1000",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "Exactly one of amountIn and amountOut")
+}
+
+func TestRouteSwapQuote(t *testing.T) {
+	status, result := postJSON(t, "/routeSwap", map[string]interface{}{
+		"currencyIn":  ethereum.Token0_address,
+		"currencyOut": ethereum.Token1_address,
+		"amountIn":    "1000000000",
+		"quoteOnly":   true,
+	})
+	require.Equal(t, http.StatusOK, status, result)
+
+	route := result["route"].(map[string]interface{})
+	assert.Equal(t, "1000000000", route["amountIn"])
+	assert.NotEqual(t, "0", route["amountOut"])
+	assert.NotEmpty(t, route["hops"])
+}
+
+func TestRouteSwapHookData(t *testing.T) {
+	quote := func(path string, body map[string]interface{}) []interface{} {
+		status, result := postJSON(t, path, body)
+		require.Equal(t, http.StatusOK, status, result)
+		if route, ok := result["route"].(map[string]interface{}); ok {
+			return route["hops"].([]interface{})
+		}
+		routes := result["split"].(map[string]interface{})["routes"].([]interface{})
+		return routes[0].(map[string]interface{})["hops"].([]interface{})
+	}
+	hookData := map[string]interface{}{ethereum.HookAddress.Hex(): "0x1234"}
+	routed := quote("/routeSwap", map[string]interface{}{
+		"currencyIn":  ethereum.Token0_address,
+		"currencyOut": ethereum.Token1_address,
+		"amountIn":    "1000000000",
+		"maxHops":     1,
+		"hookData":    hookData,
+		"quoteOnly":   true,
+	})
+	split := quote("/splitSwap", map[string]interface{}{
+		"currencyIn":  ethereum.Token0_address,
+		"currencyOut": ethereum.Token1_address,
+		"amountIn":    "1000000000",
+		"hookData":    hookData,
+		"quoteOnly":   true,
+	})
+	for _, hops := range [][]interface{}{routed, split} {
+		for _, hop := range hops {
+			hop := hop.(map[string]interface{})
+			if hop["hooks"] == ethereum.HookAddress.Hex() {
+				assert.Equal(t, "0x1234", hop["hookData"])
+			} else {
+				assert.Equal(t, "0x", hop["hookData"])
+			}
+		}
+	}
+
+	status, result := postJSON(t, "/routeSwap", map[string]interface{}{
+		"currencyIn":  ethereum.Token0_address,
+		"currencyOut": ethereum.Token1_address,
+		"amountIn":    "1000000000",
+		"hookData":    map[string]interface{}{"not-a-hook": "0x12"},
+		"quoteOnly":   true,
+	})
+	assert.Equal(t, http.StatusBadRequest, status, result)
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/safe"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// safeTypedData is the SafeTx typed data a wallet would show to Safe owners.
+func safeTypedData(chainID *big.Int, safeAddress common.Address, tx *safe.Transaction) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SafeTx": {
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: safeAddress.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          tx.Value.String(),
+			"data":           hexutil.Encode(tx.Data),
+			"operation":      big.NewInt(int64(tx.Operation)).String(),
+			"safeTxGas":      tx.SafeTxGas.String(),
+			"baseGas":        tx.BaseGas.String(),
+			"gasPrice":       tx.GasPrice.String(),
+			"gasToken":       tx.GasToken.Hex(),
+			"refundReceiver": tx.RefundReceiver.Hex(),
+			"nonce":          tx.Nonce.String(),
+		},
+	}
+}
+
+func TestSafeTxHashMatchesEIP712(t *testing.T) {
+	chainID := big.NewInt(31337)
+	safeAddress := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	router := common.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512")
+
+	for _, tx := range []*safe.Transaction{
+		safe.NewTransaction(router, nil, common.FromHex("0x5a6bcfda0000000000000000000000000000000000000000000000000000000000000001"), big.NewInt(7)),
+		safe.NewTransaction(router, big.NewInt(1e18), nil, big.NewInt(0)),
+		{
+			To:             router,
+			Value:          big.NewInt(5),
+			Data:           []byte{0xde, 0xad},
+			Operation:      safe.DelegateCall,
+			SafeTxGas:      big.NewInt(100000),
+			BaseGas:        big.NewInt(21000),
+			GasPrice:       big.NewInt(1e9),
+			GasToken:       common.HexToAddress("0x0000000000000000000000000000000000000001"),
+			RefundReceiver: common.HexToAddress("0x0000000000000000000000000000000000000002"),
+			Nonce:          big.NewInt(42),
+		},
+	} {
+		expected, _, err := apitypes.TypedDataAndHash(safeTypedData(chainID, safeAddress, tx))
+		require.NoError(t, err)
+		assert.Equal(t, common.BytesToHash(expected), tx.Hash(chainID, safeAddress))
+
+		proposal := tx.Proposal(chainID, safeAddress)
+		assert.Equal(t, common.BytesToHash(expected), proposal.ContractTransactionHash)
+		assert.Equal(t, tx.Nonce.Uint64(), proposal.Nonce)
+	}
+}
+
+func TestSafeProposalJSON(t *testing.T) {
+	tx := safe.NewTransaction(common.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512"), nil, nil, big.NewInt(3))
+	content, err := json.Marshal(tx.Proposal(big.NewInt(1), common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")))
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &fields))
+	for _, field := range []string{"to", "value", "data", "operation", "safeTxGas", "baseGas", "gasPrice", "gasToken", "refundReceiver", "nonce", "contractTransactionHash", "sender", "signature"} {
+		assert.Contains(t, fields, field)
+	}
+	// Amounts are decimal strings and empty calldata is null
+	assert.Equal(t, "0", fields["value"])
+	assert.Nil(t, fields["data"])
+}
+
+func TestSafeModeRequiresSafeAddress(t *testing.T) {
+	status, result := postJSON(t, "/addLiquidity", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"mode":      "safe",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "safeAddress")
+}
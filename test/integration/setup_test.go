@@ -8,7 +8,9 @@ import (
 
 	"uniswap-v4-rpc/internal/config"
 	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/hookadapters"
 	"uniswap-v4-rpc/internal/routes"
+	"uniswap-v4-rpc/internal/signer"
 
 	"github.com/gin-gonic/gin"
 )
@@ -48,8 +50,44 @@ func setup() {
 	}
 	log.Println("Contract addresses initialized successfully")
 
-	if err := ethereum.SetPrivateKey(cfg.PrivateKey); err != nil {
-		log.Fatalf("Failed to set private key: %v", err)
+	serverSigner, err := signer.FromConfig(&cfg.SignerConfig)
+	if err != nil {
+		log.Fatalf("Failed to create signer: %v", err)
+	}
+	ethereum.SetSigner(serverSigner)
+
+	if err := ethereum.InitRelayers(cfg); err != nil {
+		log.Fatalf("Failed to initialize relayer pool: %v", err)
+	}
+
+	if err := ethereum.InitSponsorship(cfg); err != nil {
+		log.Fatalf("Failed to initialize sponsorship policy: %v", err)
+	}
+	ethereum.SetAdminToken(cfg.AdminToken)
+
+	if err := ethereum.InitPositions(cfg); err != nil {
+		log.Fatalf("Failed to initialize position registry: %v", err)
+	}
+
+	if err := ethereum.InitHookData(cfg); err != nil {
+		log.Fatalf("Failed to load hookData schemas: %v", err)
+	}
+
+	if err := ethereum.InitHookViews(cfg); err != nil {
+		log.Fatalf("Failed to load hook ABIs: %v", err)
+	}
+
+	if err := hookadapters.Init(cfg); err != nil {
+		log.Fatalf("Failed to bind hook adapters: %v", err)
+	}
+
+	if err := ethereum.InitPools(cfg); err != nil {
+		log.Fatalf("Failed to start pool registry: %v", err)
+	}
+	// Routes read the registry without syncing it, so tests start from a
+	// synced one
+	if err := ethereum.SyncPools(); err != nil {
+		log.Printf("Failed to sync pool registry: %v", err)
 	}
 
 	// Set up the Gin router
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uniswap-v4-rpc/internal/signer"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// anvil default account 0
+const devPrivateKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+func TestRemoteSignerAgainstStandIn(t *testing.T) {
+	local, err := signer.NewLocalSigner(devPrivateKey)
+	require.NoError(t, err)
+
+	standIn := httptest.NewServer(signer.NewRemoteSignerHandler(local))
+	defer standIn.Close()
+
+	// The address is discovered from the stand-in when not configured
+	remote, err := signer.NewRemoteSigner(standIn.URL, common.Address{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, local.Address(), remote.Address())
+
+	chainID := big.NewInt(31337)
+	tx := types.NewTransaction(7, common.HexToAddress("0x1"), big.NewInt(0), 21000, big.NewInt(1), nil)
+	signedTx, err := remote.SignTx(tx, chainID)
+	require.NoError(t, err)
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	require.NoError(t, err)
+	assert.Equal(t, local.Address(), sender)
+
+	hash := crypto.Keccak256([]byte("permit"))
+	sig, err := remote.SignHash(hash)
+	require.NoError(t, err)
+	pubKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	assert.Equal(t, local.Address(), crypto.PubkeyToAddress(*pubKey))
+}
+
+func TestRemoteSignerRejectsUnknownAccount(t *testing.T) {
+	local, err := signer.NewLocalSigner(devPrivateKey)
+	require.NoError(t, err)
+
+	standIn := httptest.NewServer(signer.NewRemoteSignerHandler(local))
+	defer standIn.Close()
+
+	other := common.HexToAddress("0x328809Bc894f92807417D2dAD6b7C998c1aFdac6")
+	remote, err := signer.NewRemoteSigner(standIn.URL, other, nil)
+	require.NoError(t, err)
+
+	_, err = remote.SignHash(crypto.Keccak256([]byte("permit")))
+	assert.Error(t, err)
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	dir := t.TempDir()
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("correct horse")
+	require.NoError(t, err)
+
+	passphraseFile := filepath.Join(dir, "passphrase.txt")
+	require.NoError(t, os.WriteFile(passphraseFile, []byte("correct horse\n"), 0600))
+
+	passphrase, err := signer.ResolvePassphrase("", passphraseFile)
+	require.NoError(t, err)
+
+	s, err := signer.NewKeystoreSigner(account.URL.Path, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, account.Address, s.Address())
+
+	_, err = signer.NewKeystoreSigner(account.URL.Path, "wrong")
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/internal/routing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// constantProductQuote prices each pool as x*y=k with reserves equal to its
+// fee, so a larger fee means a deeper pool.
+func constantProductQuote(key ethereum.PoolKey, hookData []byte, zeroForOne, exactIn bool, amount *big.Int) (*big.Int, *big.Int, error) {
+	depth := new(big.Int).Mul(key.Fee, big.NewInt(1000))
+	out := new(big.Int).Mul(amount, depth)
+	return amount, out.Div(out, new(big.Int).Add(depth, amount)), nil
+}
+
+func TestSplitOptimizer(t *testing.T) {
+	a, b := "0x0a", "0x0b"
+	pools := []ethereum.PoolKey{routingKey(a, b, 3000), routingKey(a, b, 3000), routingKey(a, b, 500)}
+	pools[1].Hooks = common.HexToAddress("0x0100")
+	routes := routing.Paths(pools, common.HexToAddress(a), common.HexToAddress(b), 1)
+	require.Len(t, routes, 3)
+
+	// Two equally deep pools take half each, the shallow one a small share
+	split, err := routing.Optimize(routes, big.NewInt(3000000), 20, constantProductQuote, nil)
+	require.NoError(t, err)
+	require.Len(t, split.Routes, 3)
+	assert.Equal(t, "3000000", split.AmountIn.String())
+	assert.Equal(t, split.Routes[0].AmountIn().String(), split.Routes[1].AmountIn().String())
+	assert.True(t, split.Routes[2].AmountIn().Cmp(split.Routes[0].AmountIn()) < 0)
+	assert.Equal(t, uint64(routing.BaseGas+3*routing.HopGas), split.GasEstimate)
+
+	single, err := routing.Best(routes, true, big.NewInt(3000000), constantProductQuote)
+	require.NoError(t, err)
+	assert.True(t, split.AmountOut.Cmp(single.AmountOut()) > 0)
+
+	// When gas outweighs the price impact everything goes to one pool
+	expensive := func(gas uint64) *big.Int { return big.NewInt(int64(gas) * 100) }
+	split, err = routing.Optimize(routes, big.NewInt(3000000), 20, constantProductQuote, expensive)
+	require.NoError(t, err)
+	require.Len(t, split.Routes, 1)
+	assert.Equal(t, new(big.Int).Sub(split.AmountOut, split.GasCost).String(), split.NetAmountOut.String())
+}
+
+func TestSplitSwapValidation(t *testing.T) {
+	status, result := postJSON(t, "/splitSwap", map[string]interface{}{
+		"currencyIn":  ethereum.Token0_address,
+		"currencyOut": ethereum.Token1_address,
+		"amountIn":    "1000",
+		"parts":       1000,
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "parts")
+}
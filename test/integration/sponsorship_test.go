@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"uniswap-v4-rpc/internal/config"
+	"uniswap-v4-rpc/internal/sponsorship"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sponsorUser  = common.HexToAddress("0x328809Bc894f92807417D2dAD6b7C998c1aFdac6")
+	sponsorToken = common.HexToAddress("0x36C02dA8a0983159322a80FFE9F24b1acfF8B570")
+	sponsorPool  = common.HexToHash("0x01")
+)
+
+func sponsorRequest(amount, maxGasCost int64) sponsorship.Request {
+	return sponsorship.Request{
+		User:       sponsorUser,
+		PoolID:     sponsorPool,
+		Tokens:     []common.Address{sponsorToken},
+		Amounts:    []*big.Int{big.NewInt(amount)},
+		MaxGasCost: big.NewInt(maxGasCost),
+	}
+}
+
+func TestSponsorshipUserBudget(t *testing.T) {
+	policy, err := sponsorship.NewPolicy(config.SponsorshipConfig{UserDailyGasWei: "1000"})
+	require.NoError(t, err)
+	day := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	policy.SetClock(func() time.Time { return day })
+
+	first, err := policy.Authorize(sponsorRequest(1, 600))
+	require.NoError(t, err)
+
+	// The worst case reservation still counts against the budget
+	_, err = policy.Authorize(sponsorRequest(1, 600))
+	assert.ErrorIs(t, err, sponsorship.ErrUserBudgetExceeded)
+
+	// Settling with the actual cost frees up the rest of the budget
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	first.Sent(tx)
+	first.Done()
+	first.Settle(&types.Receipt{GasUsed: 100, EffectiveGasPrice: big.NewInt(2)})
+
+	second, err := policy.Authorize(sponsorRequest(1, 600))
+	require.NoError(t, err)
+	second.Done()
+
+	// Budgets reset with the UTC day
+	day = day.Add(24 * time.Hour)
+	_, err = policy.Authorize(sponsorRequest(1, 900))
+	assert.NoError(t, err)
+
+	entries, totals := policy.Ledger().Query(&sponsorUser, nil, "2026-01-02")
+	require.Len(t, entries, 2)
+	assert.Equal(t, sponsorship.StatusSettled, entries[0].Status)
+	assert.Equal(t, big.NewInt(200), entries[0].GasCost)
+	assert.Equal(t, sponsorship.StatusCancelled, entries[1].Status)
+	assert.Equal(t, 1, totals[sponsorUser].Transactions)
+	assert.Equal(t, big.NewInt(200), totals[sponsorUser].GasCost)
+}
+
+func TestSponsorshipPoolBudgetAndLimits(t *testing.T) {
+	policy, err := sponsorship.NewPolicy(config.SponsorshipConfig{
+		PoolDailyGasWei: "1000",
+		MaxAmountPerTx:  map[string]string{sponsorToken.Hex(): "500"},
+		AllowedTokens:   []string{sponsorToken.Hex()},
+		AllowedPools:    []string{sponsorPool.Hex()},
+		RelayerFeeBps:   30,
+	})
+	require.NoError(t, err)
+
+	_, err = policy.Authorize(sponsorRequest(501, 1))
+	assert.ErrorIs(t, err, sponsorship.ErrAmountTooLarge)
+
+	req := sponsorRequest(500, 1)
+	req.Tokens = []common.Address{common.HexToAddress("0x1234")}
+	_, err = policy.Authorize(req)
+	assert.ErrorIs(t, err, sponsorship.ErrTokenNotAllowed)
+
+	req = sponsorRequest(500, 1)
+	req.PoolID = common.HexToHash("0x02")
+	_, err = policy.Authorize(req)
+	assert.ErrorIs(t, err, sponsorship.ErrPoolNotAllowed)
+
+	_, err = policy.Authorize(sponsorRequest(500, 800))
+	require.NoError(t, err)
+	req = sponsorRequest(500, 800)
+	req.User = common.HexToAddress("0x5678")
+	_, err = policy.Authorize(req)
+	assert.ErrorIs(t, err, sponsorship.ErrPoolBudgetExceeded)
+	assert.True(t, sponsorship.IsPolicyError(err))
+
+	assert.Equal(t, big.NewInt(3), policy.RelayerFee(big.NewInt(1000)))
+}
+
+func TestSponsorshipUnconfirmedKeepsReservedCost(t *testing.T) {
+	policy, err := sponsorship.NewPolicy(config.SponsorshipConfig{UserDailyGasWei: "1000"})
+	require.NoError(t, err)
+
+	reservation, err := policy.Authorize(sponsorRequest(1, 600))
+	require.NoError(t, err)
+	reservation.Sent(types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	reservation.Sent(types.NewTransaction(1, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	reservation.SetRelayerFee(sponsorToken, big.NewInt(3))
+	reservation.Done()
+	reservation.Settle(&types.Receipt{GasUsed: 100, EffectiveGasPrice: big.NewInt(2)})
+	// A receipt that never arrived settles without a cost
+	reservation.Settle(nil)
+
+	entries, _ := policy.Ledger().Query(&sponsorUser, nil, "")
+	require.Len(t, entries, 1)
+	assert.Equal(t, sponsorship.StatusUnconfirmed, entries[0].Status)
+	assert.Equal(t, big.NewInt(600), entries[0].GasCost)
+	assert.Equal(t, uint64(100), entries[0].GasUsed)
+	assert.Equal(t, big.NewInt(3), entries[0].RelayerFee)
+
+	_, err = policy.Authorize(sponsorRequest(1, 600))
+	assert.ErrorIs(t, err, sponsorship.ErrUserBudgetExceeded)
+}
+
+func TestSponsorshipLedgerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	policy, err := sponsorship.NewPolicy(config.SponsorshipConfig{LedgerPath: path})
+	require.NoError(t, err)
+
+	reservation, err := policy.Authorize(sponsorRequest(1, 700))
+	require.NoError(t, err)
+	reservation.Sent(types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+
+	reloaded, err := sponsorship.NewPolicy(config.SponsorshipConfig{LedgerPath: path})
+	require.NoError(t, err)
+	entries, _ := reloaded.Ledger().Query(nil, nil, "")
+	require.Len(t, entries, 1)
+	assert.Equal(t, sponsorship.StatusReserved, entries[0].Status)
+	assert.Len(t, entries[0].TxHashes, 1)
+}
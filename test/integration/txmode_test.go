@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postJSON(t *testing.T, path string, body interface{}) (int, map[string]interface{}) {
+	jsonParams, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := http.Post(testServer.URL+path, "application/json", bytes.NewBuffer(jsonParams))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return resp.StatusCode, result
+}
+
+func getJSON(t *testing.T, path string) map[string]interface{} {
+	resp, err := http.Get(testServer.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
+
+func TestSwapUnsignedMode(t *testing.T) {
+	status, result := postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0":  ethereum.Token0_address,
+		"currency1":  ethereum.Token1_address,
+		"amount":     "1000000000",
+		"zeroForOne": true,
+		"mode":       "unsigned",
+		"from":       "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+	})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "unsigned", result["mode"])
+
+	txs, ok := result["transactions"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, txs, 1)
+	tx := txs[0].(map[string]interface{})
+	assert.Equal(t, ethereum.SwapRouterAddress.Hex(), tx["to"])
+	for _, field := range []string{"data", "value", "gas", "chainId", "nonce"} {
+		assert.Contains(t, tx, field)
+	}
+}
+
+func TestUnknownModeRejected(t *testing.T) {
+	status, result := postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"amount":    "1000000000",
+		"mode":      "broadcast-later",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "unknown mode")
+}
+
+func TestSendRawTransactionRejectsInvalidTransaction(t *testing.T) {
+	status, result := postJSON(t, "/eth_sendRawTransaction", map[string]interface{}{
+		"rawTransaction": "0x1234",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "Invalid raw transaction")
+}
+
+func TestSendRawTransactionReportsNodeRejection(t *testing.T) {
+	chainID, err := ethereum.Client.ChainID(context.Background())
+	require.NoError(t, err)
+	gasPrice, err := ethereum.Client.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+
+	// A fresh key has nothing to pay for gas, so the node refuses it
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1e18), 21000, gasPrice, nil)
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+	require.NoError(t, err)
+	raw, err := signed.MarshalBinary()
+	require.NoError(t, err)
+
+	status, result := postJSON(t, "/eth_sendRawTransaction", map[string]interface{}{
+		"rawTransaction": hexutil.Encode(raw),
+	})
+	assert.Equal(t, http.StatusBadRequest, status, result)
+	assert.Contains(t, result["error"], "insufficient funds")
+}
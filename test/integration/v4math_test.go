@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"math/big"
+	"testing"
+	"uniswap-v4-rpc/pkg/v4math"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bigFromString(t *testing.T, value string) *big.Int {
+	v, ok := new(big.Int).SetString(value, 10)
+	require.True(t, ok)
+	return v
+}
+
+func TestTickMathMatchesSolidity(t *testing.T) {
+	// Values from TickMath.getSqrtPriceAtTick
+	for tick, expected := range map[int]string{
+		v4math.MinTick: "4295128739",
+		-887220:        "4306310044",
+		-1:             "79224201403219477170569942574",
+		0:              "79228162514264337593543950336",
+		1:              "79232123823359799118286999568",
+		60:             "79466191966197645195421774833",
+		v4math.MaxTick: "1461446703485210103287273052203988822378723970342",
+	} {
+		sqrtPrice, err := v4math.GetSqrtPriceAtTick(tick)
+		require.NoError(t, err)
+		assert.Equal(t, expected, sqrtPrice.String(), "tick %d", tick)
+
+		if tick < v4math.MaxTick {
+			back, err := v4math.GetTickAtSqrtPrice(sqrtPrice)
+			require.NoError(t, err)
+			assert.Equal(t, tick, back)
+		}
+	}
+
+	_, err := v4math.GetSqrtPriceAtTick(v4math.MaxTick + 1)
+	assert.ErrorIs(t, err, v4math.ErrInvalidTick)
+}
+
+func TestTickSnapping(t *testing.T) {
+	assert.Equal(t, -120, v4math.FloorTick(-61, 60))
+	assert.Equal(t, -60, v4math.CeilTick(-61, 60))
+	assert.Equal(t, 60, v4math.FloorTick(119, 60))
+	assert.Equal(t, 120, v4math.CeilTick(61, 60))
+	assert.Equal(t, 60, v4math.CeilTick(60, 60))
+	assert.Equal(t, -887220, v4math.MinUsableTick(60))
+	assert.Equal(t, 887220, v4math.MaxUsableTick(60))
+
+	// ln(4) / ln(1.0001) = 13863.6
+	tick, err := v4math.TickFromPrice("4")
+	require.NoError(t, err)
+	assert.Equal(t, 13863, tick)
+}
+
+func TestLiquidityForAmounts(t *testing.T) {
+	sqrtPrice, _ := v4math.GetSqrtPriceAtTick(0)
+	sqrtLower, _ := v4math.GetSqrtPriceAtTick(-887220)
+	sqrtUpper, _ := v4math.GetSqrtPriceAtTick(887220)
+	oneEther := bigFromString(t, "1000000000000000000")
+
+	// At price 1 over the full range liquidity equals the amount of each
+	liquidity, err := v4math.GetLiquidityForAmounts(sqrtPrice, sqrtLower, sqrtUpper, oneEther, oneEther)
+	require.NoError(t, err)
+	assert.Equal(t, oneEther, liquidity)
+
+	// Adding it never costs more than the desired amounts
+	amount0, amount1 := v4math.GetAmountsForLiquidity(sqrtPrice, sqrtLower, sqrtUpper, liquidity, true)
+	assert.True(t, amount0.Cmp(oneEther) <= 0)
+	assert.True(t, amount1.Cmp(oneEther) <= 0)
+
+	// Below the range the position is all currency0
+	sqrtBelow, _ := v4math.GetSqrtPriceAtTick(-1200)
+	sqrtNarrowLower, _ := v4math.GetSqrtPriceAtTick(-600)
+	sqrtNarrowUpper, _ := v4math.GetSqrtPriceAtTick(600)
+	liquidity, err = v4math.GetLiquidityForAmounts(sqrtBelow, sqrtNarrowLower, sqrtNarrowUpper, oneEther, big.NewInt(0))
+	require.NoError(t, err)
+	amount0, amount1 = v4math.GetAmountsForLiquidity(sqrtBelow, sqrtNarrowLower, sqrtNarrowUpper, liquidity, true)
+	assert.Equal(t, 0, amount1.Sign())
+	assert.True(t, amount0.Cmp(oneEther) <= 0)
+	assert.True(t, amount0.Cmp(bigFromString(t, "999999999999999990")) > 0)
+
+	// Rounding down pays out no more than rounding up charges
+	down0, down1 := v4math.GetAmountsForLiquidity(sqrtPrice, sqrtLower, sqrtUpper, oneEther, false)
+	up0, up1 := v4math.GetAmountsForLiquidity(sqrtPrice, sqrtLower, sqrtUpper, oneEther, true)
+	assert.True(t, down0.Cmp(up0) <= 0)
+	assert.True(t, down1.Cmp(up1) <= 0)
+}
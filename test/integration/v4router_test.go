@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"uniswap-v4-rpc/internal/ethereum"
+	"uniswap-v4-rpc/pkg/v4actions"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV4RouterSwapEncoding(t *testing.T) {
+	poolKey := ethereum.PoolKey{Currency0: ethereum.Token0_address, Currency1: ethereum.Token1_address, Fee: big.NewInt(3000), TickSpacing: big.NewInt(60)}
+	plan := new(v4actions.Plan).
+		SwapExactInSingle(v4actions.ExactInputSingleParams{
+			PoolKey:           poolKey,
+			ZeroForOne:        true,
+			AmountIn:          big.NewInt(1000),
+			AmountOutMinimum:  big.NewInt(900),
+			SqrtPriceLimitX96: big.NewInt(0),
+			HookData:          []byte{},
+		}).
+		SettleAll(ethereum.Token0_address, big.NewInt(1000)).
+		TakeAll(ethereum.Token1_address, big.NewInt(900))
+	encoded, err := plan.Encode()
+	require.NoError(t, err)
+
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	bytesArray, _ := abi.NewType("bytes[]", "", nil)
+	values, err := abi.Arguments{{Type: bytesType}, {Type: bytesArray}}.Unpack(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{v4actions.SwapExactInSingle, v4actions.SettleAll, v4actions.TakeAll}, values[0])
+
+	params := values[1].([][]byte)
+	require.Len(t, params, 3)
+	// The params struct is dynamic: tuple offset, poolKey (5 words),
+	// zeroForOne, amountIn, amountOutMinimum, sqrtPriceLimitX96, hookData
+	// offset and length
+	swap := params[0]
+	require.Len(t, swap, 12*32)
+	assert.Equal(t, int64(32), new(big.Int).SetBytes(swap[:32]).Int64())
+	assert.Equal(t, common.LeftPadBytes(ethereum.Token0_address.Bytes(), 32), swap[32:64])
+	assert.Equal(t, int64(1), new(big.Int).SetBytes(swap[6*32:7*32]).Int64())
+	assert.Equal(t, int64(1000), new(big.Int).SetBytes(swap[7*32:8*32]).Int64())
+	assert.Equal(t, int64(900), new(big.Int).SetBytes(swap[8*32:9*32]).Int64())
+	assert.Len(t, params[1], 64)
+	assert.Len(t, params[2], 64)
+
+	// A two hop path encodes one PathKey per hop
+	hop := v4actions.PathKey{IntermediateCurrency: ethereum.Token1_address, Fee: big.NewInt(3000), TickSpacing: big.NewInt(60), HookData: []byte{}}
+	multi := new(v4actions.Plan).SwapExactOut(v4actions.ExactOutputParams{
+		CurrencyOut:     ethereum.Token0_address,
+		Path:            []v4actions.PathKey{hop, hop},
+		AmountOut:       big.NewInt(1000),
+		AmountInMaximum: big.NewInt(2000),
+	})
+	_, err = multi.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{v4actions.SwapExactOut}, multi.Actions())
+}
+
+func TestSwapRouterSelection(t *testing.T) {
+	status, result := postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"amount":    "1000",
+		"router":    "uniswap",
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result["error"], "invalid router")
+
+	if ethereum.HasV4Router() {
+		t.Skip("V4Router is configured")
+	}
+	status, result = postJSON(t, "/performSwap", map[string]interface{}{
+		"currency0": ethereum.Token0_address,
+		"currency1": ethereum.Token1_address,
+		"amount":    "1000",
+		"router":    "v4router",
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, result["error"], "v4_router_address")
+}